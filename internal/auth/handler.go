@@ -2,11 +2,11 @@ package auth
 
 import (
 	"legendaryum/internal/config"
+	"legendaryum/internal/problem"
+	"legendaryum/internal/revocation"
+	"legendaryum/internal/users"
+	"legendaryum/internal/validation"
 	"legendaryum/pkg/models"
-	"legendaryum/pkg/utils"
-	"net/mail"
-	"regexp"
-	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,78 +15,81 @@ import (
 
 // Handler de autenticación
 
+// Revoker registra revocaciones de access tokens (ver revocation.Store)
+type Revoker interface {
+	// Revoke revoca un access token concreto hasta su expiración
+	Revoke(jti, userID string, expiresAt time.Time) error
+	// RevokeAllForUser invalida todos los access tokens del usuario emitidos antes de at
+	RevokeAllForUser(userID string, at time.Time) error
+}
+
 type Handler struct {
-	DB     *gorm.DB
-	Config *config.Config
+	Config      *config.Config
+	Revocations Revoker
+	Service     *Service
+}
+
+// NewHandler crea el handler de autenticación con los repositorios de Postgres sobre db
+func NewHandler(db *gorm.DB, cfg *config.Config, revocations *revocation.Store) *Handler {
+	return NewHandlerWithRepositories(cfg, revocations, users.NewPostgresUserRepository(db), NewPostgresRefreshTokenRepository(db))
+}
+
+// NewHandlerWithRepositories crea el handler de autenticación con el servicio sobre los
+// repositorios indicados. Ningún endpoint consulta la base directamente: con los repositorios en
+// memoria y un Revoker propio se puede probar sin base de datos.
+func NewHandlerWithRepositories(cfg *config.Config, revocations Revoker, userRepo users.UserRepository, tokenRepo RefreshTokenRepository) *Handler {
+	return &Handler{
+		Config:      cfg,
+		Revocations: revocations,
+		Service:     NewService(userRepo, tokenRepo, cfg),
+	}
 }
 
-func NewHandler(db *gorm.DB, cfg *config.Config) *Handler {
-	return &Handler{DB: db, Config: cfg}
+// invalidRequest responde 400 con los errores de validación de cada campo
+func invalidRequest(c *fiber.Ctx, err error) error {
+	p := problem.New(fiber.StatusBadRequest, problem.CodeValidationFailed, "Datos inválidos: "+err.Error())
+	if errs, ok := err.(validation.Errors); ok {
+		p.With("errors", errs)
+	}
+	return p.Send(c)
 }
 
 // Register godoc
 // @Summary Registrar un nuevo usuario
-// @Description Crea una nueva cuenta de usuario
+// @Description Crea una nueva cuenta de usuario y devuelve un access token y un refresh token
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body models.RegisterRequest true "Datos de registro"
 // @Success 201 {object} map[string]interface{} "Usuario creado exitosamente"
-// @Failure 400 {object} map[string]interface{} "Error en los datos de entrada"
-// @Failure 500 {object} map[string]interface{} "Error interno del servidor"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
 // @Router /auth/register [post]
 func (h *Handler) Register(c *fiber.Ctx) error {
 	var req models.RegisterRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "JSON inválido"})
-	}
-
-	// Validaciones
-	if len(strings.TrimSpace(req.FirstName)) < 2 || len(req.FirstName) > 50 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "El nombre debe tener entre 2 y 50 caracteres"})
-	}
-	if len(strings.TrimSpace(req.LastName)) < 2 || len(req.LastName) > 50 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "El apellido debe tener entre 2 y 50 caracteres"})
-	}
-	if !regexp.MustCompile(`^[a-zA-ZáéíóúÁÉÍÓÚüÜñÑ\s]+$`).MatchString(req.FirstName) || !regexp.MustCompile(`^[a-zA-ZáéíóúÁÉÍÓÚüÜñÑ\s]+$`).MatchString(req.LastName) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Nombre y apellido solo pueden contener letras y espacios"})
-	}
-	if _, err := mail.ParseAddress(req.Email); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Email inválido"})
-	}
-	if len(req.Password) < 6 || len(req.Password) > 100 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "La contraseña debe tener entre 6 y 100 caracteres"})
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidJSON, "JSON inválido")
 	}
 
-	// Email único
-	var count int64
-	h.DB.Model(&models.User{}).Where("email = ?", req.Email).Count(&count)
-	if count > 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "El email ya está registrado"})
+	if err := validation.Struct(&req); err != nil {
+		return invalidRequest(c, err)
 	}
 
-	// Hash de password
-	hash, err := utils.HashPassword(req.Password)
+	// Crear el usuario (email único, hash de la contraseña y rol por defecto)
+	user, err := h.Service.Register(c.UserContext(), req)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Error al hashear la contraseña"})
+		if p, ok := err.(*problem.Problem); ok {
+			return p.Send(c)
+		}
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error al crear usuario")
 	}
 
-	now := time.Now().UTC()
-	user := models.User{
-		FirstName:    req.FirstName,
-		LastName:     req.LastName,
-		Email:        req.Email,
-		PasswordHash: hash,
-		CreatedAt:    now,
-		UpdatedAt:    now,
-	}
-	if err := h.DB.Create(&user).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Error al crear usuario"})
-	}
-
-	token, err := utils.GenerateJWT(user.ID, h.Config.JWTSecret, h.Config.JWTExpiry)
+	// Primer refresh token de la sesión. Si falla, el usuario ya existe y puede iniciar sesión.
+	tokens, err := h.Service.IssueTokens(c.UserContext(), user)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Error al generar token"})
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error al generar token")
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -97,43 +100,47 @@ func (h *Handler) Register(c *fiber.Ctx) error {
 				"first_name": user.FirstName,
 				"last_name":  user.LastName,
 				"email":      user.Email,
+				"role":       user.Role,
 				"created_at": user.CreatedAt,
 			},
-			"token": token,
+			"token":         tokens.Token,
+			"refresh_token": tokens.RefreshToken,
+			"expires_in":    tokens.ExpiresIn,
 		},
 	})
 }
 
 // Login godoc
 // @Summary Iniciar sesión
-// @Description Autentica a un usuario y devuelve un token JWT
+// @Description Autentica a un usuario y devuelve un access token JWT de corta duración junto con un refresh token
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body models.LoginRequest true "Credenciales de inicio de sesión"
 // @Success 200 {object} map[string]interface{} "Login exitoso"
-// @Failure 400 {object} map[string]interface{} "Error en los datos de entrada"
-// @Failure 401 {object} map[string]interface{} "Credenciales inválidas"
-// @Failure 500 {object} map[string]interface{} "Error interno del servidor"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada"
+// @Failure 401 {object} problem.Problem "Credenciales inválidas"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
 // @Router /auth/login [post]
 func (h *Handler) Login(c *fiber.Ctx) error {
 	var req models.LoginRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "JSON inválido"})
-	}
-	if req.Email == "" || req.Password == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": "Email y contraseña son requeridos"})
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidJSON, "JSON inválido")
 	}
-	var user models.User
-	if err := h.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"success": false, "error": "Credenciales inválidas"})
+	if err := validation.Struct(&req); err != nil {
+		return invalidRequest(c, err)
 	}
-	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"success": false, "error": "Credenciales inválidas"})
+	user, err := h.Service.Authenticate(c.UserContext(), req.Email, req.Password)
+	if err != nil {
+		if p, ok := err.(*problem.Problem); ok {
+			return p.Send(c)
+		}
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error al verificar las credenciales")
 	}
-	token, err := utils.GenerateJWT(user.ID, h.Config.JWTSecret, h.Config.JWTExpiry)
+	tokens, err := h.Service.IssueTokens(c.UserContext(), user)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"success": false, "error": "Error al generar token"})
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error al generar token")
 	}
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
@@ -143,8 +150,118 @@ func (h *Handler) Login(c *fiber.Ctx) error {
 				"first_name": user.FirstName,
 				"last_name":  user.LastName,
 				"email":      user.Email,
+				"role":       user.Role,
 			},
-			"token": token,
+			"token":         tokens.Token,
+			"refresh_token": tokens.RefreshToken,
+			"expires_in":    tokens.ExpiresIn,
 		},
 	})
 }
+
+// Refresh godoc
+// @Summary Renovar la sesión
+// @Description Intercambia un refresh token válido por un nuevo access token y un nuevo refresh token. El refresh token usado queda invalidado; si se vuelve a presentar un token ya rotado se revoca toda la familia de tokens de esa sesión.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshRequest true "Refresh token obtenido en el login, registro o una renovación anterior"
+// @Success 200 {object} models.TokenResponse "Tokens renovados"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada"
+// @Failure 401 {object} problem.Problem "Refresh token inválido, expirado o reutilizado"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /auth/refresh [post]
+func (h *Handler) Refresh(c *fiber.Ctx) error {
+	var req models.RefreshRequest
+	if err := c.BodyParser(&req); err != nil {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidJSON, "JSON inválido")
+	}
+	if err := validation.Struct(&req); err != nil {
+		return invalidRequest(c, err)
+	}
+
+	tokens, err := h.Service.RotateRefreshToken(c.UserContext(), req.RefreshToken)
+	if err != nil {
+		switch err {
+		case errRefreshTokenExpired:
+			return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeRefreshTokenExpired, "Refresh token expirado")
+		case errRefreshTokenReused:
+			return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeRefreshTokenReused, "Refresh token reutilizado: la sesión fue revocada")
+		case errRefreshTokenInvalid:
+			return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeInvalidToken, "Refresh token inválido")
+		}
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error al renovar la sesión")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    tokens,
+	})
+}
+
+// Logout godoc
+// @Summary Cerrar sesión
+// @Description Revoca el access token usado en la request. Si se envía el refresh token de la sesión, también se revoca toda su familia.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshRequest false "Refresh token de la sesión a cerrar (opcional)"
+// @Security Bearer
+// @Success 200 {object} map[string]interface{} "Sesión cerrada"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada"
+// @Failure 401 {object} problem.Problem "No autorizado"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /auth/logout [post]
+func (h *Handler) Logout(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	tokenID, _ := c.Locals("token_id").(string)
+	expiresAt, _ := c.Locals("token_expires_at").(time.Time)
+	if userID == "" || tokenID == "" {
+		return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado")
+	}
+
+	// El body es opcional: solo se parsea si se envió algo
+	var req models.RefreshRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidJSON, "JSON inválido")
+		}
+	}
+
+	if err := h.Revocations.Revoke(tokenID, userID, expiresAt); err != nil {
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error al revocar el token")
+	}
+	if req.RefreshToken != "" {
+		if err := h.Service.RevokeRefreshFamily(c.UserContext(), userID, req.RefreshToken); err != nil && err != errRefreshTokenInvalid {
+			return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error al revocar el refresh token")
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Sesión cerrada"})
+}
+
+// LogoutAll godoc
+// @Summary Cerrar todas las sesiones
+// @Description Invalida todos los access tokens emitidos hasta el momento para el usuario y revoca todos sus refresh tokens.
+// @Tags auth
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string]interface{} "Sesiones cerradas"
+// @Failure 401 {object} problem.Problem "No autorizado"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /auth/logout-all [post]
+func (h *Handler) LogoutAll(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	if userID == "" {
+		return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado")
+	}
+
+	if err := h.Revocations.RevokeAllForUser(userID, time.Now()); err != nil {
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error al revocar los tokens")
+	}
+	if err := h.Service.RevokeAllRefreshTokens(c.UserContext(), userID); err != nil {
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error al revocar los refresh tokens")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"success": true, "message": "Todas las sesiones fueron cerradas"})
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"legendaryum/pkg/models"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Almacenamiento de refresh tokens. El servicio de autenticación depende de
+// RefreshTokenRepository y no de GORM, para poder probar los handlers con el repositorio en
+// memoria sin una base de datos.
+
+// ErrRefreshTokenNotFound indica que no existe un refresh token con ese hash
+var ErrRefreshTokenNotFound = errors.New("refresh token no encontrado")
+
+// RefreshTokenRepository guarda los refresh tokens (solo el hash de su valor) y sus revocaciones
+type RefreshTokenRepository interface {
+	// Transaction ejecuta fn con un repositorio cuyos cambios se confirman juntos solo si fn
+	// no devuelve error
+	Transaction(ctx context.Context, fn func(RefreshTokenRepository) error) error
+
+	// FindByHash obtiene un refresh token por el hash de su valor y lo bloquea hasta el final de
+	// la transacción en curso. Devuelve ErrRefreshTokenNotFound si no existe.
+	FindByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	// Create guarda un refresh token nuevo y completa su ID
+	Create(ctx context.Context, token *models.RefreshToken) error
+	// MarkReplaced revoca el token en at y registra el token que lo reemplazó
+	MarkReplaced(ctx context.Context, id, replacedBy string, at time.Time) error
+	// RevokeFamily revoca en at los tokens vigentes de la familia
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+	// RevokeAllForUser revoca en at los tokens vigentes del usuario
+	RevokeAllForUser(ctx context.Context, userID string, at time.Time) error
+}
+
+// postgresRefreshTokenRepository implementa RefreshTokenRepository con GORM
+type postgresRefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewPostgresRefreshTokenRepository crea un repositorio de refresh tokens sobre db (que puede ser
+// una transacción en curso)
+func NewPostgresRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &postgresRefreshTokenRepository{db: db}
+}
+
+func (r *postgresRefreshTokenRepository) Transaction(ctx context.Context, fn func(RefreshTokenRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&postgresRefreshTokenRepository{db: tx})
+	})
+}
+
+func (r *postgresRefreshTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var stored models.RefreshToken
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).
+		First(&stored).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRefreshTokenNotFound
+		}
+		return nil, err
+	}
+	return &stored, nil
+}
+
+func (r *postgresRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *postgresRefreshTokenRepository) MarkReplaced(ctx context.Context, id, replacedBy string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"revoked_at":  at,
+		"replaced_by": replacedBy,
+	}).Error
+}
+
+func (r *postgresRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
+
+func (r *postgresRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+// MemoryRefreshTokenRepository implementa RefreshTokenRepository en memoria, para pruebas sin base
+// de datos. Una transacción trabaja sobre una copia de los tokens que reemplaza al original al
+// confirmarse; no se aísla de otras transacciones simultáneas.
+type MemoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]models.RefreshToken // ID → token
+}
+
+// NewMemoryRefreshTokenRepository crea un repositorio de refresh tokens vacío en memoria
+func NewMemoryRefreshTokenRepository() *MemoryRefreshTokenRepository {
+	return &MemoryRefreshTokenRepository{tokens: make(map[string]models.RefreshToken)}
+}
+
+func (r *MemoryRefreshTokenRepository) Transaction(ctx context.Context, fn func(RefreshTokenRepository) error) error {
+	r.mu.Lock()
+	tx := &MemoryRefreshTokenRepository{tokens: make(map[string]models.RefreshToken, len(r.tokens))}
+	for id, token := range r.tokens {
+		tx.tokens[id] = token
+	}
+	r.mu.Unlock()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.tokens = tx.tokens
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *MemoryRefreshTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+	return nil, ErrRefreshTokenNotFound
+}
+
+func (r *MemoryRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if token.ID == "" {
+		token.ID = uuid.NewString()
+	}
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now().UTC()
+	}
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+func (r *MemoryRefreshTokenRepository) MarkReplaced(ctx context.Context, id, replacedBy string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if token, ok := r.tokens[id]; ok {
+		token.RevokedAt = &at
+		token.ReplacedBy = &replacedBy
+		r.tokens[id] = token
+	}
+	return nil
+}
+
+func (r *MemoryRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	return r.revokeWhere(at, func(token models.RefreshToken) bool { return token.FamilyID == familyID })
+}
+
+func (r *MemoryRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID string, at time.Time) error {
+	return r.revokeWhere(at, func(token models.RefreshToken) bool { return token.UserID == userID })
+}
+
+// revokeWhere revoca en at los tokens vigentes que cumplen match
+func (r *MemoryRefreshTokenRepository) revokeWhere(at time.Time, match func(models.RefreshToken) bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, token := range r.tokens {
+		if token.RevokedAt == nil && match(token) {
+			token.RevokedAt = &at
+			r.tokens[id] = token
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"legendaryum/internal/config"
+	"legendaryum/internal/problem"
+	"legendaryum/internal/users"
+	"legendaryum/pkg/models"
+	"legendaryum/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Service contiene las reglas del registro, el inicio de sesión y los refresh tokens,
+// independientes del almacenamiento
+type Service struct {
+	users  users.UserRepository
+	tokens RefreshTokenRepository
+	cfg    *config.Config
+}
+
+// NewService crea el servicio de autenticación sobre los repositorios indicados. De cfg se usan
+// el secreto y las duraciones de los tokens.
+func NewService(userRepo users.UserRepository, tokenRepo RefreshTokenRepository, cfg *config.Config) *Service {
+	return &Service{users: userRepo, tokens: tokenRepo, cfg: cfg}
+}
+
+// Register crea un usuario con el rol member a partir de una solicitud ya validada.
+// Si el email ya está registrado devuelve un problema 400 already_exists.
+func (s *Service) Register(ctx context.Context, req models.RegisterRequest) (*models.User, error) {
+	if _, err := s.users.FindByEmail(ctx, req.Email); err == nil {
+		return nil, problem.New(fiber.StatusBadRequest, problem.CodeAlreadyExists, "El email ya está registrado")
+	} else if err != users.ErrUserNotFound {
+		return nil, err
+	}
+
+	// Hash de password
+	hash, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		FirstName:    req.FirstName,
+		LastName:     req.LastName,
+		Email:        req.Email,
+		PasswordHash: hash,
+		Role:         models.RoleMember,
+	}
+	if err := s.users.Create(ctx, user); err != nil {
+		// Otro registro con el mismo email pudo crearse después de la verificación
+		if err == users.ErrEmailTaken {
+			return nil, problem.New(fiber.StatusBadRequest, problem.CodeAlreadyExists, "El email ya está registrado")
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// Authenticate verifica las credenciales y devuelve el usuario. Si el email no existe o la
+// contraseña no coincide devuelve un problema 401 invalid_credentials (sin distinguir los casos).
+func (s *Service) Authenticate(ctx context.Context, email, password string) (*models.User, error) {
+	user, err := s.users.FindByEmail(ctx, email)
+	if err != nil {
+		if err == users.ErrUserNotFound {
+			return nil, problem.New(fiber.StatusUnauthorized, problem.CodeInvalidCredentials, "Credenciales inválidas")
+		}
+		return nil, err
+	}
+	if !utils.CheckPasswordHash(password, user.PasswordHash) {
+		return nil, problem.New(fiber.StatusUnauthorized, problem.CodeInvalidCredentials, "Credenciales inválidas")
+	}
+	return user, nil
+}
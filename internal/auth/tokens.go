@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"legendaryum/internal/users"
+	"legendaryum/pkg/models"
+	"legendaryum/pkg/utils"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Emisión y rotación de tokens (access JWT + refresh opaco)
+
+var (
+	errRefreshTokenInvalid = errors.New("refresh token inválido")
+	errRefreshTokenExpired = errors.New("refresh token expirado")
+	errRefreshTokenReused  = errors.New("refresh token reutilizado")
+)
+
+// IssueTokens genera un access token y el primer refresh token de una sesión nueva (login o registro)
+func (s *Service) IssueTokens(ctx context.Context, user *models.User) (*models.TokenResponse, error) {
+	tokens, _, err := s.issueTokens(ctx, s.tokens, user.ID, user.Role, "")
+	return tokens, err
+}
+
+// issueTokens genera un access token y un refresh token nuevo dentro de la familia indicada.
+// Si familyID está vacío se inicia una familia nueva (login o registro).
+// El rol se incluye en el access token para que el middleware pueda autorizar sin consultar la base.
+func (s *Service) issueTokens(ctx context.Context, repo RefreshTokenRepository, userID, role, familyID string) (*models.TokenResponse, *models.RefreshToken, error) {
+	accessExpiry, err := time.ParseDuration(s.cfg.JWTExpiry)
+	if err != nil {
+		return nil, nil, err
+	}
+	refreshExpiry, err := time.ParseDuration(s.cfg.RefreshTokenExpiry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accessToken, err := utils.GenerateJWT(userID, role, s.cfg.JWTSecret, s.cfg.JWTExpiry)
+	if err != nil {
+		return nil, nil, err
+	}
+	refreshToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if familyID == "" {
+		familyID = uuid.NewString()
+	}
+	stored := models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: time.Now().UTC().Add(refreshExpiry),
+	}
+	if err := repo.Create(ctx, &stored); err != nil {
+		return nil, nil, err
+	}
+
+	return &models.TokenResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(accessExpiry.Seconds()),
+	}, &stored, nil
+}
+
+// RotateRefreshToken valida un refresh token y lo reemplaza por uno nuevo de la misma familia.
+// Si el token ya había sido rotado se considera un robo: se revoca la familia completa.
+func (s *Service) RotateRefreshToken(ctx context.Context, rawToken string) (*models.TokenResponse, error) {
+	var tokens *models.TokenResponse
+	var outcome error
+
+	err := s.tokens.Transaction(ctx, func(repo RefreshTokenRepository) error {
+		now := time.Now().UTC()
+
+		// La fila queda bloqueada para que dos renovaciones simultáneas no roten el mismo token
+		stored, err := repo.FindByHash(ctx, utils.HashToken(rawToken))
+		if err != nil {
+			if err == ErrRefreshTokenNotFound {
+				outcome = errRefreshTokenInvalid
+				return nil
+			}
+			return err
+		}
+
+		if stored.RevokedAt != nil {
+			if stored.ReplacedBy == nil {
+				outcome = errRefreshTokenInvalid
+				return nil
+			}
+			// Token ya rotado presentado otra vez: revocar toda la familia.
+			// La transacción se confirma igualmente para persistir la revocación.
+			outcome = errRefreshTokenReused
+			return repo.RevokeFamily(ctx, stored.FamilyID, now)
+		}
+		if now.After(stored.ExpiresAt) {
+			outcome = errRefreshTokenExpired
+			return nil
+		}
+
+		// El rol se relee en cada renovación para que los cambios de rol se apliquen al rotar
+		user, err := s.users.FindByID(ctx, stored.UserID)
+		if err != nil {
+			if err == users.ErrUserNotFound {
+				outcome = errRefreshTokenInvalid
+				return nil
+			}
+			return err
+		}
+
+		issued, replacement, err := s.issueTokens(ctx, repo, user.ID, user.Role, stored.FamilyID)
+		if err != nil {
+			return err
+		}
+		if err := repo.MarkReplaced(ctx, stored.ID, replacement.ID, now); err != nil {
+			return err
+		}
+		tokens = issued
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if outcome != nil {
+		return nil, outcome
+	}
+	return tokens, nil
+}
+
+// RevokeRefreshFamily revoca la familia del refresh token indicado, si pertenece al usuario
+func (s *Service) RevokeRefreshFamily(ctx context.Context, userID, rawToken string) error {
+	stored, err := s.tokens.FindByHash(ctx, utils.HashToken(rawToken))
+	if err != nil {
+		if err == ErrRefreshTokenNotFound {
+			return errRefreshTokenInvalid
+		}
+		return err
+	}
+	if stored.UserID != userID {
+		return errRefreshTokenInvalid
+	}
+	return s.tokens.RevokeFamily(ctx, stored.FamilyID, time.Now().UTC())
+}
+
+// RevokeAllRefreshTokens revoca todos los refresh tokens vigentes del usuario
+func (s *Service) RevokeAllRefreshTokens(ctx context.Context, userID string) error {
+	return s.tokens.RevokeAllForUser(ctx, userID, time.Now().UTC())
+}
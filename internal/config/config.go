@@ -6,27 +6,37 @@ import (
 
 // Config representa la configuración de la aplicación
 type Config struct {
-	Port      string
-	JWTSecret string
-	JWTExpiry string
-	DBHost    string
-	DBPort    string
-	DBUser    string
-	DBPass    string
-	DBName    string
+	Port               string
+	JWTSecret          string
+	JWTExpiry          string
+	RefreshTokenExpiry string
+	DBHost             string
+	DBPort             string
+	DBUser             string
+	DBPass             string
+	DBName             string
+	TrashRetention     string // Tiempo que una tarea eliminada permanece en la papelera
+	TrashPurgeInterval string // Cada cuánto se purgan las tareas vencidas de la papelera
+	TaskWorkflow       string // Transiciones de estado permitidas (vacío: flujo por defecto)
+	ShutdownTimeout    string // Plazo para terminar las requests en curso y las tareas en segundo plano al apagar
 }
 
 // Load carga la configuración desde variables de entorno
 func Load() (*Config, error) {
 	cfg := &Config{
-		Port:      getEnv("PORT", "8080"),
-		JWTSecret: getEnv("JWT_SECRET", "your-secret-key"),
-		JWTExpiry: getEnv("JWT_EXPIRY", "24h"),
-		DBHost:    getEnv("DB_HOST", "localhost"),
-		DBPort:    getEnv("DB_PORT", "5432"),
-		DBUser:    getEnv("DB_USER", "postgres"),
-		DBPass:    getEnv("DB_PASS", "postgres"),
-		DBName:    getEnv("DB_NAME", "legendaryum_db"),
+		Port:               getEnv("PORT", "8080"),
+		JWTSecret:          getEnv("JWT_SECRET", "your-secret-key"),
+		JWTExpiry:          getEnv("JWT_EXPIRY", "15m"),
+		RefreshTokenExpiry: getEnv("REFRESH_TOKEN_EXPIRY", "720h"),
+		DBHost:             getEnv("DB_HOST", "localhost"),
+		DBPort:             getEnv("DB_PORT", "5432"),
+		DBUser:             getEnv("DB_USER", "postgres"),
+		DBPass:             getEnv("DB_PASS", "postgres"),
+		DBName:             getEnv("DB_NAME", "legendaryum_db"),
+		TrashRetention:     getEnv("TRASH_RETENTION", "720h"),
+		TrashPurgeInterval: getEnv("TRASH_PURGE_INTERVAL", "1h"),
+		TaskWorkflow:       getEnv("TASK_WORKFLOW", ""),
+		ShutdownTimeout:    getEnv("SHUTDOWN_TIMEOUT", "10s"),
 	}
 
 	return cfg, nil
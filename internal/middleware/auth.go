@@ -2,44 +2,53 @@ package middleware
 
 import (
 	"legendaryum/internal/config"
+	"legendaryum/internal/problem"
+	"legendaryum/internal/revocation"
 	"legendaryum/pkg/utils"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-// AuthMiddleware valida el token JWT y extrae el ID del usuario
-func AuthMiddleware(cfg *config.Config) fiber.Handler {
+// AuthMiddleware valida el token JWT, verifica que no esté revocado y extrae el ID del usuario
+func AuthMiddleware(cfg *config.Config, revocations *revocation.Store) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Obtener el token del header Authorization
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Token no proporcionado",
-			})
+			return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Token no proporcionado")
 		}
 
 		// Verificar que el header tenga el formato correcto
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Formato de token inválido",
-			})
+			return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeInvalidToken, "Formato de token inválido")
 		}
 
 		// Validar el token
 		claims, err := utils.ValidateJWT(parts[1], cfg.JWTSecret)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Token inválido o expirado",
-			})
+			return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeInvalidToken, "Token inválido o expirado")
 		}
 
-		// Guardar el ID del usuario en el contexto
+		// Verificar la lista de revocación (logout / logout-all)
+		if revocations != nil {
+			revoked, err := revocations.IsRevoked(claims)
+			if err != nil {
+				return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al validar el token")
+			}
+			if revoked {
+				return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeInvalidToken, "Token revocado")
+			}
+		}
+
+		// Guardar el ID y el rol del usuario y los datos del token en el contexto
 		c.Locals("user_id", claims.UserID)
+		c.Locals("role", claims.Role)
+		c.Locals("token_id", claims.ID)
+		if claims.ExpiresAt != nil {
+			c.Locals("token_expires_at", claims.ExpiresAt.Time)
+		}
 
 		return c.Next()
 	}
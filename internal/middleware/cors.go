@@ -29,8 +29,8 @@ func CORSMiddleware() fiber.Handler {
 
 	config := cors.Config{
 		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH,HEAD",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Requested-With, X-HTTP-Method-Override",
-		ExposeHeaders:    "Content-Length, Content-Type, Authorization",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Requested-With, X-HTTP-Method-Override, If-Match, If-None-Match",
+		ExposeHeaders:    "Content-Length, Content-Type, Authorization, ETag",
 		AllowCredentials: true,
 		MaxAge:           86400, // 24 horas
 	}
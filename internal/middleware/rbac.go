@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"legendaryum/internal/problem"
+	"legendaryum/pkg/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CurrentRole devuelve el rol del usuario autenticado.
+// Los tokens emitidos antes de existir los roles no lo incluyen y se tratan como member.
+func CurrentRole(c *fiber.Ctx) string {
+	if role, ok := c.Locals("role").(string); ok && role != "" {
+		return role
+	}
+	return models.RoleMember
+}
+
+// RequireRole permite continuar solo si el usuario autenticado tiene alguno de los roles indicados.
+// Debe usarse después de AuthMiddleware.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role := CurrentRole(c)
+		for _, allowed := range roles {
+			if role == allowed {
+				return c.Next()
+			}
+		}
+		return forbidden(c)
+	}
+}
+
+// RequirePermission permite continuar solo si el rol del usuario autenticado tiene todos los permisos indicados.
+// Debe usarse después de AuthMiddleware.
+func RequirePermission(perms ...models.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role := CurrentRole(c)
+		for _, perm := range perms {
+			if !models.HasPermission(role, perm) {
+				return forbidden(c)
+			}
+		}
+		return c.Next()
+	}
+}
+
+func forbidden(c *fiber.Ctx) error {
+	return problem.Respond(c, fiber.StatusForbidden, problem.CodeForbidden, "No tienes permisos suficientes para realizar esta acción.")
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"embed"
 	"fmt"
+	"legendaryum/internal/problem"
 	"os"
 	"strings"
 
@@ -29,9 +30,7 @@ func SwaggerUI() fiber.Handler {
 		if c.Path() == "/swagger.json" {
 			swaggerFile, err := swaggerDocs.ReadFile("swagger.json")
 			if err != nil {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"error": "Error al leer la documentación Swagger",
-				})
+				return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error al leer la documentación Swagger")
 			}
 
 			// Obtener la URL base correcta
@@ -0,0 +1,202 @@
+package problem
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Errores de la API en formato application/problem+json (RFC 7807). Todas las respuestas de error
+// tienen type, title, status, detail e instance, más un code estable para que los clientes
+// distingan los errores sin depender del texto. Los datos propios de cada error (errores de
+// validación, ETag vigente, transiciones permitidas...) van como miembros de extensión.
+
+// ContentType es el tipo de las respuestas de error
+const ContentType = "application/problem+json"
+
+// typePrefix identifica el tipo de problema a partir de su código
+const typePrefix = "urn:legendaryum:problem:"
+
+// Códigos de error
+const (
+	CodeBadRequest           = "bad_request"            // Solicitud incorrecta (p. ej. una referencia inexistente)
+	CodeInvalidJSON          = "invalid_json"           // El cuerpo no es JSON válido
+	CodeInvalidParameter     = "invalid_parameter"      // Un parámetro de ruta o de consulta es inválido
+	CodeValidationFailed     = "validation_failed"      // Campos inválidos; el detalle de cada uno va en errors
+	CodeUnauthenticated      = "unauthenticated"        // Falta el usuario autenticado o el token
+	CodeInvalidToken         = "invalid_token"          // Token inválido, expirado o revocado
+	CodeInvalidCredentials   = "invalid_credentials"    // Email o contraseña incorrectos
+	CodeRefreshTokenExpired  = "refresh_token_expired"  // El refresh token expiró
+	CodeRefreshTokenReused   = "refresh_token_reused"   // Se presentó un refresh token ya rotado
+	CodeForbidden            = "forbidden"              // El usuario no tiene permiso
+	CodeNotFound             = "not_found"              // El recurso no existe o no es visible
+	CodeInvalidReference     = "invalid_reference"      // Un ID del cuerpo no existe o no es accesible
+	CodeInvalidPatch         = "invalid_patch"          // El patch está mal formado
+	CodeConflict             = "conflict"               // El estado actual impide la operación
+	CodeAlreadyExists        = "already_exists"         // Ya existe un recurso con ese nombre o email
+	CodeInvalidTransition    = "invalid_transition"     // El flujo de estados no admite el cambio
+	CodeDependencyCycle      = "dependency_cycle"       // El cambio crearía un ciclo de dependencias
+	CodeOpenSubtasks         = "open_subtasks"          // La tarea tiene subtareas abiertas
+	CodeTaskBlocked          = "task_blocked"           // La tarea está bloqueada por tareas abiertas
+	CodeBulkFailed           = "bulk_operation_failed"  // Falló una operación masiva atómica
+	CodeVersionMismatch      = "version_mismatch"       // If-Match no coincide con la versión vigente
+	CodePreconditionRequired = "precondition_required"  // Falta la cabecera If-Match
+	CodeUnsupportedMediaType = "unsupported_media_type" // Content-Type no soportado
+	CodeInternal             = "internal_error"         // Error inesperado del servidor
+	CodeNotReady             = "not_ready"              // Una dependencia del servidor no está disponible
+)
+
+// titles es el resumen de cada tipo de problema
+var titles = map[string]string{
+	CodeBadRequest:           "Solicitud incorrecta",
+	CodeInvalidJSON:          "JSON inválido",
+	CodeInvalidParameter:     "Parámetro inválido",
+	CodeValidationFailed:     "Datos inválidos",
+	CodeUnauthenticated:      "No autenticado",
+	CodeInvalidToken:         "Token inválido",
+	CodeInvalidCredentials:   "Credenciales inválidas",
+	CodeRefreshTokenExpired:  "Refresh token expirado",
+	CodeRefreshTokenReused:   "Refresh token reutilizado",
+	CodeForbidden:            "Acceso denegado",
+	CodeNotFound:             "Recurso no encontrado",
+	CodeInvalidReference:     "Referencia inválida",
+	CodeInvalidPatch:         "Patch inválido",
+	CodeConflict:             "Conflicto con el estado actual",
+	CodeAlreadyExists:        "El recurso ya existe",
+	CodeInvalidTransition:    "Transición de estado no permitida",
+	CodeDependencyCycle:      "Ciclo de dependencias",
+	CodeOpenSubtasks:         "Subtareas abiertas",
+	CodeTaskBlocked:          "Tarea bloqueada",
+	CodeBulkFailed:           "Operación masiva fallida",
+	CodeVersionMismatch:      "Versión desactualizada",
+	CodePreconditionRequired: "Se requiere If-Match",
+	CodeUnsupportedMediaType: "Tipo de contenido no soportado",
+	CodeInternal:             "Error interno",
+	CodeNotReady:             "Servicio no disponible",
+}
+
+// statusCodes es el código por defecto de cada estado HTTP
+var statusCodes = map[int]string{
+	fiber.StatusBadRequest:           CodeBadRequest,
+	fiber.StatusUnauthorized:         CodeUnauthenticated,
+	fiber.StatusForbidden:            CodeForbidden,
+	fiber.StatusNotFound:             CodeNotFound,
+	fiber.StatusConflict:             CodeConflict,
+	fiber.StatusPreconditionFailed:   CodeVersionMismatch,
+	fiber.StatusUnsupportedMediaType: CodeUnsupportedMediaType,
+	fiber.StatusUnprocessableEntity:  CodeValidationFailed,
+	fiber.StatusPreconditionRequired: CodePreconditionRequired,
+	fiber.StatusInternalServerError:  CodeInternal,
+	fiber.StatusServiceUnavailable:   CodeNotReady,
+}
+
+// Problem es una respuesta de error según RFC 7807
+type Problem struct {
+	Type     string `json:"type" example:"urn:legendaryum:problem:validation_failed"` // Tipo de problema (derivado del código)
+	Title    string `json:"title" example:"Datos inválidos"`                          // Resumen del tipo de problema
+	Status   int    `json:"status" example:"400"`                                     // Código HTTP
+	Detail   string `json:"detail" example:"'priority' debe ser uno de: low, medium, high."`
+	Instance string `json:"instance,omitempty" example:"/tasks"` // Ruta de la solicitud
+	Code     string `json:"code" example:"validation_failed"`    // Código estable del error
+
+	// Extensions son los miembros adicionales del problema (se serializan al mismo nivel)
+	Extensions map[string]interface{} `json:"-" swaggerignore:"true"`
+}
+
+// New crea un problema con el código indicado
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   typePrefix + code,
+		Title:  Title(code, status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// FromStatus crea un problema con el código por defecto del estado HTTP
+func FromStatus(status int, detail string) *Problem {
+	return New(status, CodeFor(status), detail)
+}
+
+// CodeFor devuelve el código por defecto de un estado HTTP
+func CodeFor(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= fiber.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// Title devuelve el resumen de un código, o el texto del estado HTTP si el código no es conocido
+func Title(code string, status int) string {
+	if title, ok := titles[code]; ok {
+		return title
+	}
+	return http.StatusText(status)
+}
+
+// With agrega un miembro de extensión al problema
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+func (p *Problem) Error() string { return p.Detail }
+
+// MarshalJSON serializa el problema con sus extensiones al mismo nivel que los miembros estándar
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	body := make(map[string]interface{}, len(p.Extensions)+6)
+	for key, value := range p.Extensions {
+		body[key] = value
+	}
+	body["type"] = p.Type
+	body["title"] = p.Title
+	body["status"] = p.Status
+	body["detail"] = p.Detail
+	body["code"] = p.Code
+	if p.Instance != "" {
+		body["instance"] = p.Instance
+	}
+	return json.Marshal(body)
+}
+
+// Send escribe el problema como respuesta. Si no tiene instance se usa la ruta de la solicitud.
+func (p *Problem) Send(c *fiber.Ctx) error {
+	if p.Instance == "" {
+		p.Instance = c.Path()
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, ContentType)
+	return c.Status(p.Status).Send(body)
+}
+
+// Respond escribe un problema con el código indicado
+func Respond(c *fiber.Ctx, status int, code, detail string) error {
+	return New(status, code, detail).Send(c)
+}
+
+// ErrorHandler es el manejador de errores de Fiber: los *Problem se escriben tal cual, los
+// *fiber.Error (rutas inexistentes, cuerpos demasiado grandes...) con el código de su estado y el
+// resto como error interno. Los errores internos se registran en el log con el método y la ruta,
+// ya que la respuesta no incluye su detalle.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	switch e := err.(type) {
+	case *Problem:
+		return e.Send(c)
+	case *fiber.Error:
+		return FromStatus(e.Code, e.Message).Send(c)
+	}
+	log.Printf("Error interno en %s %s: %v", c.Method(), c.Path(), err)
+	return Respond(c, fiber.StatusInternalServerError, CodeInternal, "Error interno del servidor.")
+}
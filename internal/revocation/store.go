@@ -0,0 +1,184 @@
+package revocation
+
+import (
+	"legendaryum/pkg/models"
+	"legendaryum/pkg/utils"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Lista de revocación de access tokens.
+// La fuente de verdad es Postgres (tabla revoked_tokens y users.tokens_valid_after);
+// el caché en memoria evita consultar la base en cada request.
+
+// cacheTTL es el tiempo durante el cual se confía en una respuesta "no revocado" cacheada.
+// Una revocación hecha en otra instancia de la API se aplica, como máximo, pasado este tiempo.
+const cacheTTL = 30 * time.Second
+
+// sweepInterval es cada cuánto se limpian del caché las entradas vencidas
+const sweepInterval = time.Minute
+
+// watermark es la marca "tokens válidos después de" cacheada para un usuario
+type watermark struct {
+	validAfter *time.Time
+	loadedAt   time.Time
+}
+
+// Store consulta y registra revocaciones de access tokens
+type Store struct {
+	db *gorm.DB
+
+	mu         sync.RWMutex
+	revoked    map[string]time.Time // jti revocado -> expiración del token
+	notRevoked map[string]time.Time // jti válido -> momento en que se consultó
+	watermarks map[string]watermark // userID -> marca de invalidación
+	lastSweep  time.Time
+}
+
+// NewStore crea un store de revocación sobre la base de datos indicada
+func NewStore(db *gorm.DB) *Store {
+	return &Store{
+		db:         db,
+		revoked:    make(map[string]time.Time),
+		notRevoked: make(map[string]time.Time),
+		watermarks: make(map[string]watermark),
+		lastSweep:  time.Now(),
+	}
+}
+
+// Revoke revoca un access token concreto hasta su expiración
+func (s *Store) Revoke(jti, userID string, expiresAt time.Time) error {
+	entry := models.RevokedToken{JTI: jti, UserID: userID, ExpiresAt: expiresAt}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry).Error; err != nil {
+		return err
+	}
+
+	// Aprovechar para borrar revocaciones de tokens que ya expiraron por sí mismos
+	s.db.Where("expires_at < ?", time.Now().UTC()).Delete(&models.RevokedToken{})
+
+	s.mu.Lock()
+	s.revoked[jti] = expiresAt
+	delete(s.notRevoked, jti)
+	s.mu.Unlock()
+	return nil
+}
+
+// RevokeAllForUser invalida todos los access tokens del usuario emitidos antes de "at"
+func (s *Store) RevokeAllForUser(userID string, at time.Time) error {
+	at = at.UTC().Truncate(time.Millisecond)
+	if err := s.db.Model(&models.User{}).Where("id = ?", userID).
+		Update("tokens_valid_after", at).Error; err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.watermarks[userID] = watermark{validAfter: &at, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return nil
+}
+
+// IsRevoked indica si el token fue revocado individualmente o por la marca de su usuario
+func (s *Store) IsRevoked(claims *utils.Claims) (bool, error) {
+	s.sweep()
+
+	issuedBefore, err := s.issuedBeforeWatermark(claims)
+	if err != nil || issuedBefore {
+		return issuedBefore, err
+	}
+	if claims.ID == "" {
+		return false, nil
+	}
+
+	now := time.Now()
+	s.mu.RLock()
+	_, revoked := s.revoked[claims.ID]
+	checkedAt, checked := s.notRevoked[claims.ID]
+	s.mu.RUnlock()
+	if revoked {
+		return true, nil
+	}
+	if checked && now.Sub(checkedAt) < cacheTTL {
+		return false, nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.RevokedToken{}).Where("jti = ?", claims.ID).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	if count > 0 {
+		expiresAt := now
+		if claims.ExpiresAt != nil {
+			expiresAt = claims.ExpiresAt.Time
+		}
+		s.revoked[claims.ID] = expiresAt
+	} else {
+		s.notRevoked[claims.ID] = now
+	}
+	s.mu.Unlock()
+	return count > 0, nil
+}
+
+// issuedBeforeWatermark compara el iat del token con la marca tokens_valid_after del usuario
+func (s *Store) issuedBeforeWatermark(claims *utils.Claims) (bool, error) {
+	s.mu.RLock()
+	mark, cached := s.watermarks[claims.UserID]
+	s.mu.RUnlock()
+
+	if !cached || time.Since(mark.loadedAt) >= cacheTTL {
+		var user models.User
+		if err := s.db.Select("id", "tokens_valid_after").Where("id = ?", claims.UserID).First(&user).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				// El usuario ya no existe: ningún token suyo es válido
+				return true, nil
+			}
+			return false, err
+		}
+		mark = watermark{validAfter: user.TokensValidAfter, loadedAt: time.Now()}
+		s.mu.Lock()
+		s.watermarks[claims.UserID] = mark
+		s.mu.Unlock()
+	}
+
+	if mark.validAfter == nil {
+		return false, nil
+	}
+	if claims.IssuedAt == nil {
+		return true, nil
+	}
+	return claims.IssuedAt.Time.Before(*mark.validAfter), nil
+}
+
+// sweep elimina del caché las entradas que ya no aportan información
+func (s *Store) sweep() {
+	now := time.Now()
+	s.mu.RLock()
+	due := now.Sub(s.lastSweep) >= sweepInterval
+	s.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for jti, expiresAt := range s.revoked {
+		if now.After(expiresAt) {
+			delete(s.revoked, jti)
+		}
+	}
+	for jti, checkedAt := range s.notRevoked {
+		if now.Sub(checkedAt) >= cacheTTL {
+			delete(s.notRevoked, jti)
+		}
+	}
+	for userID, mark := range s.watermarks {
+		if now.Sub(mark.loadedAt) >= cacheTTL {
+			delete(s.watermarks, userID)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"legendaryum/internal/problem"
+	"legendaryum/pkg/database"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Sondas de estado: /livez indica que el proceso atiende requests (si falla, hay que reiniciarlo)
+// y /readyz que además puede atenderlas bien: la base responde y su esquema está en la última
+// migración (si falla, hay que dejar de enviarle tráfico).
+
+// migrationsDir es la carpeta de migraciones por defecto, relativa al directorio de trabajo
+const migrationsDir = "./migrations"
+
+// readinessTimeout es el plazo de cada verificación de /readyz
+const readinessTimeout = 2 * time.Second
+
+// checkResult es el resultado de una verificación de /readyz
+type checkResult struct {
+	Status    string  `json:"status" example:"ok"`       // ok o error
+	LatencyMS float64 `json:"latency_ms" example:"1.25"` // Duración de la verificación en milisegundos
+	Error     string  `json:"error,omitempty"`           // Motivo del fallo
+}
+
+// healthHandler atiende las sondas de estado
+type healthHandler struct {
+	db             *gorm.DB
+	migrationsHead uint  // Última migración de la carpeta de migraciones
+	migrationsErr  error // Error al leer la carpeta de migraciones
+}
+
+// newHealthHandler crea el handler de las sondas. La última migración se lee una sola vez de dir.
+func newHealthHandler(db *gorm.DB, dir string) *healthHandler {
+	head, err := database.LatestMigration(dir)
+	return &healthHandler{db: db, migrationsHead: head, migrationsErr: err}
+}
+
+// Livez godoc
+// @Summary Sonda de vida
+// @Description Indica que el proceso está en ejecución y atiende requests. No verifica dependencias.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{} "El proceso está vivo"
+// @Router /livez [get]
+func (h *healthHandler) Livez(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "La API está en ejecución",
+	})
+}
+
+// Readyz godoc
+// @Summary Sonda de disponibilidad
+// @Description Verifica que la base de datos responde y que su esquema está en la última migración. Informa el estado y la latencia de cada verificación en checks.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{} "La API está lista para recibir tráfico"
+// @Failure 503 {object} problem.Problem "Alguna verificación falló (el detalle va en checks)"
+// @Router /readyz [get]
+func (h *healthHandler) Readyz(c *fiber.Ctx) error {
+	checks := map[string]checkResult{
+		"database":   runCheck(c.UserContext(), h.checkDatabase),
+		"migrations": runCheck(c.UserContext(), h.checkMigrations),
+	}
+
+	var failed []string
+	for _, name := range []string{"database", "migrations"} {
+		if checks[name].Status != "ok" {
+			failed = append(failed, name)
+		}
+	}
+	if len(failed) > 0 {
+		return problem.New(fiber.StatusServiceUnavailable, problem.CodeNotReady,
+			fmt.Sprintf("La API no está lista (verificaciones fallidas: %s).", strings.Join(failed, ", "))).
+			With("checks", checks).Send(c)
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "La API está lista",
+		"data": fiber.Map{
+			"checks": checks,
+		},
+	})
+}
+
+// runCheck ejecuta una verificación con el plazo de readinessTimeout y mide su duración
+func runCheck(ctx context.Context, check func(ctx context.Context) error) checkResult {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := checkResult{Status: "ok", LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// checkDatabase verifica que la base responde
+func (h *healthHandler) checkDatabase(ctx context.Context) error {
+	if h.db == nil {
+		return errors.New("no hay una base de datos configurada")
+	}
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// checkMigrations verifica que el esquema está en la última migración y no quedó a medio aplicar
+func (h *healthHandler) checkMigrations(ctx context.Context) error {
+	if h.migrationsErr != nil {
+		return h.migrationsErr
+	}
+	if h.db == nil {
+		return errors.New("no hay una base de datos configurada")
+	}
+	version, dirty, err := database.MigrationVersion(ctx, h.db)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("la migración %d quedó a medio aplicar", version)
+	}
+	if version != h.migrationsHead {
+		return fmt.Errorf("el esquema está en la versión %d y se esperaba la %d", version, h.migrationsHead)
+	}
+	return nil
+}
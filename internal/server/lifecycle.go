@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Ciclo de vida del proceso. Al cancelarse el contexto de Serve el apagado sigue siempre este orden:
+//  1. El servidor deja de aceptar conexiones y espera hasta ShutdownTimeout a que terminen las
+//     requests en curso.
+//  2. Se cancelan las tareas en segundo plano (Go) y se espera hasta ShutdownTimeout a que terminen.
+//  3. Se cierran los recursos registrados con OnClose (p. ej. el pool de la base), del último al primero.
+// Así ninguna request ni tarea en segundo plano usa la base después de cerrarla, salvo las que
+// excedieron su plazo.
+
+// Server es la aplicación con sus tareas en segundo plano y los recursos que se cierran al apagarla
+type Server struct {
+	App             *fiber.App
+	ShutdownTimeout time.Duration // Plazo de cada etapa del apagado (0: sin límite)
+
+	workers []func(ctx context.Context)
+	closers []func() error
+}
+
+// NewServer crea un Server para app con el plazo de apagado indicado
+func NewServer(app *fiber.App, shutdownTimeout time.Duration) *Server {
+	return &Server{App: app, ShutdownTimeout: shutdownTimeout}
+}
+
+// Go registra una tarea en segundo plano. Se inicia con Serve y debe terminar cuando se cancela su contexto.
+func (s *Server) Go(worker func(ctx context.Context)) {
+	s.workers = append(s.workers, worker)
+}
+
+// OnClose registra un recurso que se cierra al final del apagado
+func (s *Server) OnClose(fn func() error) {
+	s.closers = append(s.closers, fn)
+}
+
+// ListenAndServe atiende la API en addr (p. ej. ":8080") hasta que se cancela ctx; ver Serve
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		// No se inició nada todavía: solo se cierran los recursos
+		return errors.Join(append([]error{err}, s.close()...)...)
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve inicia las tareas en segundo plano y atiende la API en ln hasta que se cancela ctx o el
+// servidor falla. En ambos casos apaga todo antes de volver; si ctx se canceló y el apagado se
+// completó a tiempo devuelve nil.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	workersCtx, cancelWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	for _, worker := range s.workers {
+		workers.Add(1)
+		go func(worker func(ctx context.Context)) {
+			defer workers.Done()
+			worker(workersCtx)
+		}(worker)
+	}
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- s.App.Listener(ln)
+	}()
+
+	var serveErr error
+	select {
+	case serveErr = <-listenErr:
+	case <-ctx.Done():
+		log.Println("Apagando el servidor...")
+	}
+
+	var errs []error
+	if serveErr != nil {
+		errs = append(errs, serveErr)
+	} else if err := s.shutdownApp(); err != nil {
+		errs = append(errs, fmt.Errorf("error esperando las requests en curso: %w", err))
+	}
+
+	cancelWorkers()
+	deadline, cancel := s.shutdownContext()
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-deadline.Done():
+		errs = append(errs, errors.New("las tareas en segundo plano no terminaron a tiempo"))
+	}
+
+	errs = append(errs, s.close()...)
+	if len(errs) == 0 {
+		log.Println("Servidor apagado correctamente")
+	}
+	return errors.Join(errs...)
+}
+
+// shutdownApp deja de aceptar conexiones y espera a que terminen las requests en curso
+func (s *Server) shutdownApp() error {
+	if s.ShutdownTimeout <= 0 {
+		return s.App.Shutdown()
+	}
+	return s.App.ShutdownWithTimeout(s.ShutdownTimeout)
+}
+
+// shutdownContext devuelve el contexto con el plazo de una etapa del apagado
+func (s *Server) shutdownContext() (context.Context, context.CancelFunc) {
+	if s.ShutdownTimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), s.ShutdownTimeout)
+}
+
+// close cierra los recursos del último registrado al primero
+func (s *Server) close() []error {
+	var errs []error
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		if err := s.closers[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
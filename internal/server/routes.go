@@ -0,0 +1,94 @@
+package server
+
+import (
+	"legendaryum/internal/auth"
+	"legendaryum/internal/middleware"
+	"legendaryum/internal/tasks"
+	"legendaryum/internal/users"
+	"legendaryum/pkg/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerRoutes registra todas las rutas de la API. requireAuth exige un access token válido.
+func registerRoutes(app *fiber.App, requireAuth fiber.Handler, authHandler *auth.Handler, taskHandler *tasks.Handler, userHandler *users.Handler, healthHandler *healthHandler) {
+	// Permisos por rol: los viewers solo pueden leer
+	canWriteTasks := middleware.RequirePermission(models.PermTasksWrite)
+	canComment := middleware.RequirePermission(models.PermCommentsWrite)
+
+	// Rutas públicas
+	authGroup := app.Group("/auth")
+	authGroup.Post("/register", authHandler.Register)
+	authGroup.Post("/login", authHandler.Login)
+	authGroup.Post("/refresh", authHandler.Refresh)
+	authGroup.Post("/logout", requireAuth, authHandler.Logout)
+	authGroup.Post("/logout-all", requireAuth, authHandler.LogoutAll)
+
+	// Rutas protegidas
+	tasksGroup := app.Group("/tasks", requireAuth)
+	tasksGroup.Post("/", canWriteTasks, taskHandler.Create)
+	tasksGroup.Get("/", taskHandler.List)
+	tasksGroup.Get("/trash", taskHandler.ListTrash) // Antes de /:id
+	tasksGroup.Post("/bulk", canWriteTasks, taskHandler.Bulk)
+	tasksGroup.Get("/:id", taskHandler.Get)
+	tasksGroup.Put("/:id", canWriteTasks, taskHandler.Update)
+	tasksGroup.Patch("/:id", canWriteTasks, taskHandler.Patch)
+	tasksGroup.Delete("/:id", canWriteTasks, taskHandler.Delete)
+	tasksGroup.Get("/:id/history", taskHandler.History)
+	tasksGroup.Post("/:id/restore", canWriteTasks, taskHandler.Restore)
+
+	// Dependencias entre tareas
+	tasksGroup.Get("/:id/dependencies", taskHandler.ListDependencies)
+	tasksGroup.Post("/:id/dependencies", canWriteTasks, taskHandler.AddDependency)
+	tasksGroup.Delete("/:id/dependencies/:blockerId", canWriteTasks, taskHandler.RemoveDependency)
+
+	// Comentarios de tareas
+	tasksGroup.Get("/:id/comments", taskHandler.ListComments)
+	tasksGroup.Post("/:id/comments", canComment, taskHandler.CreateComment)
+	tasksGroup.Get("/:id/comments/:commentId", taskHandler.GetComment)
+	tasksGroup.Put("/:id/comments/:commentId", canComment, taskHandler.UpdateComment)
+	tasksGroup.Delete("/:id/comments/:commentId", canComment, taskHandler.DeleteComment)
+
+	// Proyectos (los viewers solo pueden leer)
+	projectsGroup := app.Group("/projects", requireAuth)
+	projectsGroup.Post("/", canWriteTasks, taskHandler.CreateProject)
+	projectsGroup.Get("/", taskHandler.ListProjects)
+	projectsGroup.Get("/:id", taskHandler.GetProject)
+	projectsGroup.Put("/:id", canWriteTasks, taskHandler.UpdateProject)
+	projectsGroup.Delete("/:id", canWriteTasks, taskHandler.DeleteProject)
+	projectsGroup.Post("/:id/members", canWriteTasks, taskHandler.AddProjectMember)
+	projectsGroup.Delete("/:id/members/:userId", canWriteTasks, taskHandler.RemoveProjectMember)
+
+	// Etiquetas (los viewers solo pueden leer)
+	labelsGroup := app.Group("/labels", requireAuth)
+	labelsGroup.Get("/", taskHandler.ListLabels)
+	labelsGroup.Post("/", canWriteTasks, taskHandler.CreateLabel)
+	labelsGroup.Post("/bulk", canWriteTasks, taskHandler.BulkApplyLabels)
+	labelsGroup.Put("/:id", canWriteTasks, taskHandler.UpdateLabel)
+	labelsGroup.Delete("/:id", canWriteTasks, taskHandler.DeleteLabel)
+
+	// Vistas guardadas (personales, incluso para viewers)
+	viewsGroup := app.Group("/views", requireAuth)
+	viewsGroup.Get("/", taskHandler.ListViews)
+	viewsGroup.Post("/", taskHandler.CreateView)
+	viewsGroup.Get("/:id", taskHandler.GetView)
+	viewsGroup.Put("/:id", taskHandler.UpdateView)
+	viewsGroup.Delete("/:id", taskHandler.DeleteView)
+	viewsGroup.Get("/:id/tasks", taskHandler.ViewTasks)
+
+	// Administración de usuarios (solo administradores)
+	usersGroup := app.Group("/users", requireAuth, middleware.RequireRole(models.RoleAdmin))
+	usersGroup.Put("/:id/role", userHandler.UpdateRole)
+
+	// Sondas de estado para el orquestador (ver health.go)
+	app.Get("/livez", healthHandler.Livez)
+	app.Get("/readyz", healthHandler.Readyz)
+
+	// Ruta de salud
+	app.Get("/health", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"status":  "ok",
+			"message": "Legendaryum API is running",
+		})
+	})
+}
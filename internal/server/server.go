@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"legendaryum/internal/auth"
+	"legendaryum/internal/config"
+	"legendaryum/internal/middleware"
+	"legendaryum/internal/problem"
+	"legendaryum/internal/revocation"
+	"legendaryum/internal/tasks"
+	"legendaryum/internal/users"
+	"legendaryum/pkg/database"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"gorm.io/gorm"
+)
+
+// Armado de la aplicación: New construye la API completa (middlewares en orden, manejador de
+// errores y rutas) para que la binaria, los tests y otros proyectos monten exactamente la misma
+// API; Run además conecta la base, aplica las migraciones e inicia el servidor con su ciclo de
+// vida (ver lifecycle.go).
+
+// Deps son las dependencias de la aplicación. Solo DB es obligatoria; el resto se crea sobre DB si
+// no se indica.
+type Deps struct {
+	DB          *gorm.DB
+	Revocations *revocation.Store    // Lista de revocación de access tokens
+	Tasks       tasks.TaskRepository // Almacenamiento de tareas del servicio de tareas
+	Users       users.UserRepository // Almacenamiento de usuarios de los servicios de tareas y autenticación
+
+	// MigrationsDir es la carpeta de migraciones cuya última versión exige /readyz (por defecto ./migrations)
+	MigrationsDir string
+}
+
+// New crea la aplicación Fiber con sus middlewares, el manejador de errores y todas las rutas de
+// la API. El flujo de estados (cfg.TaskWorkflow) debe haberse validado antes con tasks.ParseWorkflow.
+func New(cfg *config.Config, deps Deps) *fiber.App {
+	if deps.Revocations == nil {
+		deps.Revocations = revocation.NewStore(deps.DB)
+	}
+	if deps.Tasks == nil {
+		deps.Tasks = tasks.NewPostgresTaskRepository(deps.DB)
+	}
+	if deps.Users == nil {
+		deps.Users = users.NewPostgresUserRepository(deps.DB)
+	}
+	if deps.MigrationsDir == "" {
+		deps.MigrationsDir = migrationsDir
+	}
+
+	// Crear aplicación Fiber
+	app := fiber.New(fiber.Config{
+		ErrorHandler: problem.ErrorHandler,
+	})
+
+	// Middleware globales
+	app.Use(recover.New())
+	app.Use(logger.New())
+
+	app.Use(middleware.CORSMiddleware())
+	app.Use(middleware.SwaggerUI())
+
+	// La lista de revocación es compartida por el middleware y el logout
+	requireAuth := middleware.AuthMiddleware(cfg, deps.Revocations)
+
+	// Handlers
+	authHandler := auth.NewHandlerWithRepositories(cfg, deps.Revocations, deps.Users, auth.NewPostgresRefreshTokenRepository(deps.DB))
+	taskHandler := tasks.NewHandlerWithRepositories(deps.DB, cfg, deps.Tasks, deps.Users)
+	userHandler := users.NewHandler(deps.DB, deps.Revocations)
+	healthHandler := newHealthHandler(deps.DB, deps.MigrationsDir)
+
+	registerRoutes(app, requireAuth, authHandler, taskHandler, userHandler, healthHandler)
+	return app
+}
+
+// Run conecta la base de datos, aplica las migraciones, inicia la purga de la papelera y atiende
+// la API en cfg.Port hasta que se cancela ctx o el servidor falla. Al cancelarse ctx apaga el
+// servidor en orden (ver Server) dentro de cfg.ShutdownTimeout.
+func Run(ctx context.Context, cfg *config.Config) error {
+	// Validar la configuración antes de conectar
+	if _, err := tasks.ParseWorkflow(cfg.TaskWorkflow); err != nil {
+		return fmt.Errorf("TASK_WORKFLOW inválido: %w", err)
+	}
+	trashRetention, err := time.ParseDuration(cfg.TrashRetention)
+	if err != nil {
+		return fmt.Errorf("TRASH_RETENTION inválido: %w", err)
+	}
+	trashPurgeInterval, err := time.ParseDuration(cfg.TrashPurgeInterval)
+	if err != nil || trashPurgeInterval <= 0 {
+		return fmt.Errorf("TRASH_PURGE_INTERVAL inválido: %q", cfg.TrashPurgeInterval)
+	}
+	shutdownTimeout, err := time.ParseDuration(cfg.ShutdownTimeout)
+	if err != nil || shutdownTimeout < 0 {
+		return fmt.Errorf("SHUTDOWN_TIMEOUT inválido: %q", cfg.ShutdownTimeout)
+	}
+
+	// Conectar a la base de datos
+	db, err := database.Open(cfg)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if err := migrate(cfg, db); err != nil {
+		sqlDB.Close()
+		return err
+	}
+
+	srv := NewServer(New(cfg, Deps{DB: db}), shutdownTimeout)
+	// El pool se cierra al final, cuando ya no quedan requests ni tareas que lo usen
+	srv.OnClose(sqlDB.Close)
+	// Purga periódica de la papelera de tareas
+	srv.Go(func(ctx context.Context) {
+		tasks.RunTrashPurger(ctx, db, trashRetention, trashPurgeInterval)
+	})
+
+	log.Printf("Servidor iniciado en el puerto %s", cfg.Port)
+	log.Printf("Documentación Swagger disponible en: http://localhost:%s/docs", cfg.Port)
+	return srv.ListenAndServe(ctx, ":"+cfg.Port)
+}
+
+// migrate aplica las migraciones de ./migrations y migra los modelos
+func migrate(cfg *config.Config, db *gorm.DB) error {
+	if err := database.Migrate(cfg, migrationsDir); err != nil {
+		return err
+	}
+	log.Println("Migraciones aplicadas correctamente")
+
+	if err := database.AutoMigrate(db); err != nil {
+		return fmt.Errorf("error migrando modelos: %w", err)
+	}
+	return nil
+}
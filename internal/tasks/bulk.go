@@ -0,0 +1,271 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"legendaryum/internal/middleware"
+	"legendaryum/internal/problem"
+	"legendaryum/pkg/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxBulkTasks limita la cantidad de operaciones (o de tareas seleccionadas por el filtro) de POST /tasks/bulk
+const maxBulkTasks = 100
+
+// errBulkAborted corta la transacción de POST /tasks/bulk en modo atomic cuando una operación falla
+var errBulkAborted = errors.New("operación masiva abortada")
+
+// bulkActions traduce cada operación al verbo de los mensajes de permiso
+var bulkActions = map[string]string{
+	models.BulkOpUpdate:   "actualizar",
+	models.BulkOpReassign: "reasignar",
+	models.BulkOpDelete:   "eliminar",
+}
+
+// parseBulkRequest valida la forma de la solicitud y devuelve las operaciones a ejecutar. Con un
+// filtro, las operaciones son un update con el mismo patch para cada tarea visible que lo cumple.
+func (h *Handler) parseBulkRequest(c *fiber.Ctx, userID string) (mode string, ops []models.BulkTaskOperation, err error) {
+	var req models.BulkTaskRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return "", nil, problem.New(fiber.StatusBadRequest, problem.CodeInvalidJSON, "Error al procesar la solicitud: JSON inválido.")
+	}
+
+	mode = req.Mode
+	if mode == "" {
+		mode = models.BulkModeAtomic
+	}
+	if mode != models.BulkModeAtomic && mode != models.BulkModeBestEffort {
+		return "", nil, problem.FromStatus(fiber.StatusBadRequest, fmt.Sprintf("Modo inválido: %q. Valores permitidos: %s, %s.", req.Mode, models.BulkModeAtomic, models.BulkModeBestEffort))
+	}
+
+	if req.Filter == nil {
+		if len(req.Patch) > 0 {
+			return "", nil, problem.FromStatus(fiber.StatusBadRequest, "El campo 'patch' solo se usa junto con 'filter'.")
+		}
+		if len(req.Operations) == 0 || len(req.Operations) > maxBulkTasks {
+			return "", nil, problem.FromStatus(fiber.StatusBadRequest, fmt.Sprintf("El campo 'operations' debe tener entre 1 y %d operaciones.", maxBulkTasks))
+		}
+		for i, op := range req.Operations {
+			if _, ok := bulkActions[op.Op]; !ok {
+				return "", nil, problem.FromStatus(fiber.StatusBadRequest, fmt.Sprintf("Operación %d: 'op' inválido: %q. Valores permitidos: %s, %s, %s.", i, op.Op, models.BulkOpUpdate, models.BulkOpDelete, models.BulkOpReassign))
+			}
+			if op.ID == 0 {
+				return "", nil, problem.FromStatus(fiber.StatusBadRequest, fmt.Sprintf("Operación %d: el campo 'id' es requerido.", i))
+			}
+		}
+		return mode, req.Operations, nil
+	}
+
+	// Filtro más patch
+	if len(req.Operations) > 0 {
+		return "", nil, problem.FromStatus(fiber.StatusBadRequest, "Usa 'operations' o 'filter' con 'patch', no ambos.")
+	}
+	if len(req.Filter) == 0 {
+		return "", nil, problem.FromStatus(fiber.StatusBadRequest, "El campo 'filter' debe tener al menos un filtro.")
+	}
+	var patch map[string]interface{}
+	if len(req.Patch) == 0 || json.Unmarshal(req.Patch, &patch) != nil || patch == nil {
+		return "", nil, problem.FromStatus(fiber.StatusBadRequest, "El campo 'patch' debe ser un merge patch (objeto JSON).")
+	}
+	// La paginación y el orden no aplican a una operación masiva
+	for _, key := range []string{"sort", "limit", "cursor"} {
+		if _, ok := req.Filter[key]; ok {
+			return "", nil, problem.FromStatus(fiber.StatusBadRequest, fmt.Sprintf("'%s' no es un filtro válido para una operación masiva.", key))
+		}
+	}
+	spec, err := parseTaskListSpec(req.Filter, userID)
+	if err != nil {
+		return "", nil, problem.FromStatus(fiber.StatusBadRequest, err.Error())
+	}
+
+	query := h.db.Model(&models.Task{})
+	if spec.Query != "" {
+		query = searchTasks(h.db, spec.Query)
+	}
+	role := middleware.CurrentRole(c)
+	var ids []uint
+	if err := query.Scopes(visibleTasks(userID, role), spec.Filter.scope(time.Now(), userID, role)).
+		Order("tasks.id ASC").Limit(maxBulkTasks+1).Pluck("tasks.id", &ids).Error; err != nil {
+		// Loggear error
+		return "", nil, problem.FromStatus(fiber.StatusInternalServerError, "Error interno al obtener las tareas del filtro.")
+	}
+	if len(ids) > maxBulkTasks {
+		return "", nil, problem.FromStatus(fiber.StatusBadRequest, fmt.Sprintf("El filtro selecciona más de %d tareas. Acótalo para aplicar el cambio.", maxBulkTasks))
+	}
+	for _, id := range ids {
+		ops = append(ops, models.BulkTaskOperation{Op: models.BulkOpUpdate, ID: id, Patch: req.Patch})
+	}
+	return mode, ops, nil
+}
+
+// runBulkOperation ejecuta una operación con svc (ligado a la transacción en curso) con los mismos
+// permisos y validaciones que PUT/PATCH y DELETE /tasks/:id. Devuelve la tarea (guardada, o con su
+// estado vigente si hubo conflicto de versión).
+func runBulkOperation(ctx context.Context, svc *Service, op models.BulkTaskOperation, userID, role string) (*models.Task, error) {
+	task, err := svc.GetForWrite(ctx, op.ID, userID, role, bulkActions[op.Op])
+	if err != nil {
+		return nil, err
+	}
+	// La versión opcional cumple el rol de If-Match
+	if op.Version != nil && *op.Version != task.Version {
+		return task, ErrVersionMismatch
+	}
+
+	switch op.Op {
+	case models.BulkOpDelete:
+		return task, svc.Delete(ctx, task, userID)
+	case models.BulkOpReassign:
+		if op.AssigneeID == "" {
+			return task, problem.FromStatus(fiber.StatusBadRequest, "La operación reassign requiere 'assignee_id'.")
+		}
+		_, err := svc.Reassign(ctx, task, op.AssigneeID, userID, role)
+		return task, err
+	default:
+		if len(op.Patch) == 0 {
+			return task, problem.FromStatus(fiber.StatusBadRequest, "La operación update requiere 'patch'.")
+		}
+		_, err := svc.Patch(ctx, task, mimeMergePatch, op.Patch, userID, role)
+		return task, err
+	}
+}
+
+// bulkResult arma el resultado de una operación a partir de lo que devolvió runBulkOperation
+func bulkResult(index int, op models.BulkTaskOperation, task *models.Task, err error) models.BulkTaskResult {
+	result := models.BulkTaskResult{Index: index, ID: op.ID, Op: op.Op}
+	if err == nil {
+		result.Result = models.BulkResultOK
+		result.Status = fiber.StatusOK
+		if op.Op != models.BulkOpDelete {
+			version := task.Version
+			result.Version = &version
+		}
+		return result
+	}
+
+	result.Result = models.BulkResultFailed
+	switch e := err.(type) {
+	case *problem.Problem:
+		result.Status = e.Status
+		result.Code = e.Code
+		result.Message = e.Detail
+		if len(e.Extensions) > 0 {
+			result.Details = e.Extensions
+		}
+	default:
+		if err == ErrVersionMismatch {
+			result.Status = fiber.StatusPreconditionFailed
+			result.Code = problem.CodeVersionMismatch
+			result.Message = "La tarea fue modificada: la versión indicada no es la vigente."
+			version := task.Version
+			result.Version = &version
+		} else {
+			// Loggear error
+			result.Status = fiber.StatusInternalServerError
+			result.Code = problem.CodeInternal
+			result.Message = "Error interno al aplicar la operación."
+		}
+	}
+	return result
+}
+
+// Bulk godoc
+// @Summary Operaciones masivas sobre tareas
+// @Description Aplica hasta 100 operaciones en una sola transacción: update (merge patch en 'patch'), reassign ('assignee_id') y delete (a la papelera). También acepta 'filter' (los mismos filtros que GET /tasks) más 'patch', que se aplica a cada tarea visible que cumple el filtro. Cada operación verifica los permisos y las reglas igual que PUT/PATCH y DELETE /tasks/{id}; 'version' es opcional y equivale a If-Match. En modo atomic (por defecto) si una operación falla no se aplica ninguna y se responde 409; en modo best_effort se aplican las válidas. La respuesta informa el resultado de cada operación.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param request body models.BulkTaskRequest true "Operaciones o filtro más patch"
+// @Security Bearer
+// @Success 200 {object} models.BulkTaskResponse "Reporte por operación"
+// @Failure 400 {object} problem.Problem "Solicitud inválida (modo, operación o filtro desconocido, demasiadas tareas)"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado (los viewers no pueden escribir)"
+// @Failure 409 {object} models.BulkTaskResponse "Modo atomic: una operación falló y no se aplicó ningún cambio"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /tasks/bulk [post]
+func (h *Handler) Bulk(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
+	}
+	role := middleware.CurrentRole(c)
+
+	mode, ops, err := h.parseBulkRequest(c, userID)
+	if err != nil {
+		return respondWriteError(c, nil, err, "Error interno al procesar la operación masiva.")
+	}
+	atomic := mode == models.BulkModeAtomic
+
+	report := models.BulkTaskResponse{Mode: mode, Results: make([]models.BulkTaskResult, len(ops))}
+	failedAt := -1
+	ctx := c.UserContext()
+	txErr := h.service.Transaction(ctx, func(tx *Service) error {
+		for i, op := range ops {
+			var task *models.Task
+			var opErr error
+			if atomic {
+				task, opErr = runBulkOperation(ctx, tx, op, userID, role)
+			} else {
+				// Cada operación en su propio savepoint: si falla, se deshace solo ella
+				opErr = tx.Transaction(ctx, func(sp *Service) error {
+					var err error
+					task, err = runBulkOperation(ctx, sp, op, userID, role)
+					return err
+				})
+			}
+			report.Results[i] = bulkResult(i, op, task, opErr)
+			if opErr != nil && atomic {
+				// Un error inesperado de la base no es un fallo de la operación: se responde 500
+				if report.Results[i].Status == fiber.StatusInternalServerError {
+					return opErr
+				}
+				failedAt = i
+				return errBulkAborted
+			}
+		}
+		return nil
+	})
+	if txErr != nil && txErr != errBulkAborted {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al aplicar las operaciones.")
+	}
+
+	if failedAt >= 0 {
+		// Nada quedó aplicado: las anteriores se deshicieron y las siguientes no se intentaron
+		for i := range ops {
+			switch {
+			case i < failedAt:
+				report.Results[i].Result = models.BulkResultRolledBack
+				report.Results[i].Version = nil
+			case i > failedAt:
+				report.Results[i] = models.BulkTaskResult{Index: i, ID: ops[i].ID, Op: ops[i].Op, Result: models.BulkResultSkipped}
+			}
+		}
+		report.Failed = 1
+		return problem.New(fiber.StatusConflict, problem.CodeBulkFailed,
+			fmt.Sprintf("La operación %d falló: %s No se aplicó ningún cambio.", failedAt, report.Results[failedAt].Message)).
+			With("report", report).
+			Send(c)
+	}
+
+	for _, result := range report.Results {
+		if result.Result == models.BulkResultOK {
+			report.Succeeded++
+		} else {
+			report.Failed++
+		}
+	}
+	message := "Operaciones aplicadas exitosamente."
+	if report.Failed > 0 {
+		message = fmt.Sprintf("Se aplicaron %d de %d operaciones.", report.Succeeded, len(ops))
+	}
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+		"data":    report,
+	})
+}
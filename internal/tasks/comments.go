@@ -0,0 +1,301 @@
+package tasks
+
+import (
+	"legendaryum/internal/middleware"
+	"legendaryum/internal/problem"
+	"legendaryum/internal/validation"
+	"legendaryum/pkg/models"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Comentarios de tareas. Siguen la misma regla de visibilidad que Get:
+// solo el creador o el asignado de la tarea (o un administrador) pueden ver y escribir comentarios.
+
+// parseTaskParams obtiene y valida el ID de la tarea, el usuario autenticado y verifica la visibilidad.
+// Si algo falla, ya escribe la respuesta de error y devuelve ok=false.
+func (h *Handler) parseTaskParams(c *fiber.Ctx) (taskID uint, userID string, ok bool, err error) {
+	id, parseErr := strconv.ParseUint(c.Params("id"), 10, 32)
+	if parseErr != nil {
+		return 0, "", false, problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, "ID de tarea inválido. Debe ser un número entero.")
+	}
+
+	userID, _ = c.Locals("user_id").(string)
+	if userID == "" {
+		return 0, "", false, problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
+	}
+
+	if _, findErr := h.service.findVisible(c.UserContext(), uint(id), userID, middleware.CurrentRole(c)); findErr != nil {
+		if findErr == ErrTaskNotFound {
+			return 0, "", false, problem.Respond(c, fiber.StatusNotFound, problem.CodeNotFound, "Tarea no encontrada o no tienes permiso para verla.")
+		}
+		// Loggear error
+		return 0, "", false, problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener la tarea.")
+	}
+
+	return uint(id), userID, true, nil
+}
+
+// findComment obtiene un comentario de la tarea indicada. Si no existe escribe la respuesta 404.
+func (h *Handler) findComment(c *fiber.Ctx, taskID uint) (*models.TaskComment, bool, error) {
+	commentID, err := strconv.ParseUint(c.Params("commentId"), 10, 32)
+	if err != nil {
+		return nil, false, problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, "ID de comentario inválido. Debe ser un número entero.")
+	}
+
+	var comment models.TaskComment
+	if err := h.db.Where("id = ? AND task_id = ?", commentID, taskID).
+		Preload("Author").First(&comment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, false, problem.Respond(c, fiber.StatusNotFound, problem.CodeNotFound, "Comentario no encontrado.")
+		}
+		// Loggear error
+		return nil, false, problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener el comentario.")
+	}
+	return &comment, true, nil
+}
+
+// parseCommentBody lee y valida el cuerpo de un comentario
+func parseCommentBody(c *fiber.Ctx) (string, bool, error) {
+	var req models.TaskCommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return "", false, problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidJSON, "Error al procesar la solicitud: JSON inválido.")
+	}
+	if err := validation.Struct(&req); err != nil {
+		return "", false, respondValidationError(c, fiber.StatusBadRequest, "El comentario no es válido", err)
+	}
+	body := strings.TrimSpace(req.Body)
+	return body, true, nil
+}
+
+// ListComments godoc
+// @Summary Listar comentarios de una tarea
+// @Description Obtiene los comentarios de una tarea en orden de creación, paginados por cursor. Solo para el creador o el asignado de la tarea.
+// @Tags comments
+// @Produce json
+// @Param id path int true "ID numérico de la tarea" Format(uint)
+// @Param limit query int false "Cantidad máxima de comentarios por página (1-100, por defecto 20)"
+// @Param cursor query string false "Cursor opaco devuelto en meta.next_cursor por la página anterior"
+// @Security Bearer
+// @Success 200 {object} models.TaskCommentListResponse "Página de comentarios"
+// @Failure 400 {object} problem.Problem "ID o parámetros de paginación inválidos"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 404 {object} problem.Problem "Tarea no encontrada"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /tasks/{id}/comments [get]
+func (h *Handler) ListComments(c *fiber.Ctx) error {
+	taskID, _, ok, err := h.parseTaskParams(c)
+	if !ok {
+		return err
+	}
+
+	limit, err := parseLimit(c.Query("limit"))
+	if err != nil {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+	}
+
+	query := h.db.Model(&models.TaskComment{}).Where("task_id = ?", taskID).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al contar los comentarios.")
+	}
+
+	page := query
+	if cursor := c.Query("cursor"); cursor != "" {
+		afterID, err := decodeIDCursor(cursor)
+		if err != nil {
+			return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+		}
+		page = page.Where("id > ?", afterID)
+	}
+
+	var comments []models.TaskComment
+	if err := page.Order("id ASC").Limit(limit + 1).Preload("Author").Find(&comments).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener los comentarios.")
+	}
+
+	var nextCursor *string
+	if len(comments) > limit {
+		comments = comments[:limit]
+		cursor := encodeIDCursor(comments[len(comments)-1].ID)
+		nextCursor = &cursor
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Comentarios obtenidos exitosamente.",
+		"data":    comments,
+		"meta": models.PageMeta{
+			Total:      total,
+			Limit:      limit,
+			Sort:       "id",
+			NextCursor: nextCursor,
+		},
+	})
+}
+
+// CreateComment godoc
+// @Summary Comentar una tarea
+// @Description Agrega un comentario a una tarea. El autor se toma del token JWT.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "ID numérico de la tarea" Format(uint)
+// @Param request body models.TaskCommentRequest true "Contenido del comentario"
+// @Security Bearer
+// @Success 201 {object} models.TaskComment "Comentario creado exitosamente"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 404 {object} problem.Problem "Tarea no encontrada"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /tasks/{id}/comments [post]
+func (h *Handler) CreateComment(c *fiber.Ctx) error {
+	taskID, userID, ok, err := h.parseTaskParams(c)
+	if !ok {
+		return err
+	}
+	body, ok, err := parseCommentBody(c)
+	if !ok {
+		return err
+	}
+
+	comment := models.TaskComment{TaskID: taskID, AuthorID: userID, Body: body}
+	if err := h.db.Create(&comment).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al crear el comentario.")
+	}
+	if err := h.db.Preload("Author").First(&comment, comment.ID).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al cargar el comentario creado.")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Comentario creado exitosamente.",
+		"data":    comment,
+	})
+}
+
+// GetComment godoc
+// @Summary Obtener un comentario
+// @Description Obtiene un comentario de una tarea visible para el usuario autenticado.
+// @Tags comments
+// @Produce json
+// @Param id path int true "ID numérico de la tarea" Format(uint)
+// @Param commentId path int true "ID numérico del comentario" Format(uint)
+// @Security Bearer
+// @Success 200 {object} models.TaskComment "Detalles del comentario"
+// @Failure 400 {object} problem.Problem "ID inválido"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 404 {object} problem.Problem "Tarea o comentario no encontrado"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /tasks/{id}/comments/{commentId} [get]
+func (h *Handler) GetComment(c *fiber.Ctx) error {
+	taskID, _, ok, err := h.parseTaskParams(c)
+	if !ok {
+		return err
+	}
+	comment, ok, err := h.findComment(c, taskID)
+	if !ok {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Comentario obtenido exitosamente.",
+		"data":    comment,
+	})
+}
+
+// UpdateComment godoc
+// @Summary Editar un comentario
+// @Description Edita el contenido de un comentario. Solo el autor del comentario puede editarlo.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "ID numérico de la tarea" Format(uint)
+// @Param commentId path int true "ID numérico del comentario" Format(uint)
+// @Param request body models.TaskCommentRequest true "Nuevo contenido del comentario"
+// @Security Bearer
+// @Success 200 {object} models.TaskComment "Comentario actualizado exitosamente"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado (solo el autor puede editar)"
+// @Failure 404 {object} problem.Problem "Tarea o comentario no encontrado"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /tasks/{id}/comments/{commentId} [put]
+func (h *Handler) UpdateComment(c *fiber.Ctx) error {
+	taskID, userID, ok, err := h.parseTaskParams(c)
+	if !ok {
+		return err
+	}
+	comment, ok, err := h.findComment(c, taskID)
+	if !ok {
+		return err
+	}
+	if comment.AuthorID != userID {
+		return problem.Respond(c, fiber.StatusForbidden, problem.CodeForbidden, "No tienes permiso para editar este comentario.")
+	}
+	body, ok, err := parseCommentBody(c)
+	if !ok {
+		return err
+	}
+
+	if err := h.db.Model(comment).Update("body", body).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al actualizar el comentario.")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Comentario actualizado exitosamente.",
+		"data":    comment,
+	})
+}
+
+// DeleteComment godoc
+// @Summary Eliminar un comentario
+// @Description Elimina un comentario. Solo el autor del comentario puede eliminarlo.
+// @Tags comments
+// @Produce json
+// @Param id path int true "ID numérico de la tarea" Format(uint)
+// @Param commentId path int true "ID numérico del comentario" Format(uint)
+// @Security Bearer
+// @Success 200 {object} models.SuccessResponse "Comentario eliminado exitosamente"
+// @Failure 400 {object} problem.Problem "ID inválido"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado (solo el autor o un administrador puede eliminar)"
+// @Failure 404 {object} problem.Problem "Tarea o comentario no encontrado"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /tasks/{id}/comments/{commentId} [delete]
+func (h *Handler) DeleteComment(c *fiber.Ctx) error {
+	taskID, userID, ok, err := h.parseTaskParams(c)
+	if !ok {
+		return err
+	}
+	comment, ok, err := h.findComment(c, taskID)
+	if !ok {
+		return err
+	}
+	// Los administradores pueden moderar comentarios ajenos
+	if comment.AuthorID != userID && !models.HasPermission(middleware.CurrentRole(c), models.PermTasksManageAll) {
+		return problem.Respond(c, fiber.StatusForbidden, problem.CodeForbidden, "No tienes permiso para eliminar este comentario.")
+	}
+
+	if err := h.db.Delete(comment).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al eliminar el comentario.")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Comentario eliminado exitosamente.",
+		"data":    nil,
+	})
+}
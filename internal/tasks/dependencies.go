@@ -0,0 +1,225 @@
+package tasks
+
+import (
+	"fmt"
+	"legendaryum/internal/middleware"
+	"legendaryum/internal/problem"
+	"legendaryum/internal/validation"
+	"legendaryum/pkg/models"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Dependencias entre tareas ("bloqueada por"). Una tarea no puede completarse mientras
+// alguna de las tareas que la bloquean siga abierta. El grafo de dependencias no admite ciclos.
+
+// dependencyLockKey identifica el advisory lock que serializa las altas de dependencias,
+// para que dos altas simultáneas no puedan cerrar un ciclo entre ambas.
+const dependencyLockKey = 7_204_001
+
+// blockersQuery selecciona las tareas que bloquean a una tarea
+const blockersQuery = "tasks.id IN (SELECT blocked_by_id FROM task_dependencies WHERE task_id = ?)"
+
+// openBlockers devuelve las tareas que bloquean a la tarea y todavía no están completadas
+func openBlockers(db *gorm.DB, taskID uint) ([]models.TaskBlocker, error) {
+	var blockers []models.TaskBlocker
+	err := db.Model(&models.Task{}).
+		Select("tasks.id, tasks.title, tasks.status").
+		Where(blockersQuery, taskID).
+		Where("tasks.status <> ?", "complete").
+		Order("tasks.id ASC").
+		Scan(&blockers).Error
+	return blockers, err
+}
+
+// createsCycle indica si agregar "taskID bloqueada por blockerID" cerraría un ciclo, es decir,
+// si blockerID ya depende (directa o transitivamente) de taskID.
+func createsCycle(db *gorm.DB, taskID, blockerID uint) (bool, error) {
+	if taskID == blockerID {
+		return true, nil
+	}
+	var exists bool
+	err := db.Raw(`WITH RECURSIVE chain AS (
+	SELECT blocked_by_id AS id FROM task_dependencies WHERE task_id = ?
+	UNION
+	SELECT d.blocked_by_id FROM task_dependencies d JOIN chain ON d.task_id = chain.id
+)
+SELECT EXISTS (SELECT 1 FROM chain WHERE id = ?)`, blockerID, taskID).Scan(&exists).Error
+	return exists, err
+}
+
+// requireManageableTask verifica que el usuario pueda modificar la tarea. Si no, escribe la respuesta 403.
+func (h *Handler) requireManageableTask(c *fiber.Ctx, taskID uint, userID string) (bool, error) {
+	var count int64
+	if err := h.db.Model(&models.Task{}).Scopes(manageableTasks(userID, middleware.CurrentRole(c))).
+		Where("tasks.id = ?", taskID).Count(&count).Error; err != nil {
+		// Loggear error
+		return false, problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener la tarea.")
+	}
+	if count == 0 {
+		return false, problem.Respond(c, fiber.StatusForbidden, problem.CodeForbidden, "No tienes permiso para modificar las dependencias de esta tarea.")
+	}
+	return true, nil
+}
+
+// ListDependencies godoc
+// @Summary Listar dependencias de una tarea
+// @Description Obtiene las tareas que bloquean a la tarea (blocked_by) y las tareas que ella bloquea (blocking), solo entre las visibles para el usuario.
+// @Tags dependencies
+// @Produce json
+// @Param id path int true "ID numérico de la tarea" Format(uint)
+// @Security Bearer
+// @Success 200 {object} models.TaskDependenciesResponse "Dependencias de la tarea"
+// @Failure 400 {object} problem.Problem "ID inválido"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 404 {object} problem.Problem "Tarea no encontrada"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /tasks/{id}/dependencies [get]
+func (h *Handler) ListDependencies(c *fiber.Ctx) error {
+	taskID, userID, ok, err := h.parseTaskParams(c)
+	if !ok {
+		return err
+	}
+	visible := visibleTasks(userID, middleware.CurrentRole(c))
+
+	response := models.TaskDependenciesResponse{
+		BlockedBy: []models.TaskBlocker{},
+		Blocking:  []models.TaskBlocker{},
+	}
+	if err := h.db.Model(&models.Task{}).Scopes(visible).
+		Select("tasks.id, tasks.title, tasks.status").
+		Where(blockersQuery, taskID).
+		Order("tasks.id ASC").
+		Scan(&response.BlockedBy).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener las dependencias.")
+	}
+	if err := h.db.Model(&models.Task{}).Scopes(visible).
+		Select("tasks.id, tasks.title, tasks.status").
+		Where("tasks.id IN (SELECT task_id FROM task_dependencies WHERE blocked_by_id = ?)", taskID).
+		Order("tasks.id ASC").
+		Scan(&response.Blocking).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener las dependencias.")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Dependencias obtenidas exitosamente.",
+		"data":    response,
+	})
+}
+
+// AddDependency godoc
+// @Summary Agregar un bloqueante a una tarea
+// @Description Indica que la tarea está bloqueada por otra tarea visible para el usuario. Se rechaza si la dependencia crearía un ciclo. Solo el creador de la tarea (o un administrador) puede modificar sus dependencias.
+// @Tags dependencies
+// @Accept json
+// @Produce json
+// @Param id path int true "ID numérico de la tarea bloqueada" Format(uint)
+// @Param request body models.TaskDependencyRequest true "Tarea bloqueante"
+// @Security Bearer
+// @Success 201 {object} models.TaskDependency "Dependencia agregada exitosamente"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada (bloqueante inexistente o no visible)"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado"
+// @Failure 404 {object} problem.Problem "Tarea no encontrada"
+// @Failure 409 {object} problem.Problem "La dependencia crearía un ciclo"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /tasks/{id}/dependencies [post]
+func (h *Handler) AddDependency(c *fiber.Ctx) error {
+	taskID, userID, ok, err := h.parseTaskParams(c)
+	if !ok {
+		return err
+	}
+	if ok, err := h.requireManageableTask(c, taskID, userID); !ok {
+		return err
+	}
+
+	var req models.TaskDependencyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidJSON, "Error al procesar la solicitud: JSON inválido.")
+	}
+	if err := validation.Struct(&req); err != nil {
+		return respondValidationError(c, fiber.StatusBadRequest, "La dependencia no es válida", err)
+	}
+	if _, err := h.service.findVisible(c.UserContext(), req.BlockedByID, userID, middleware.CurrentRole(c)); err != nil {
+		if err == ErrTaskNotFound {
+			return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidReference, fmt.Sprintf("La tarea bloqueante con ID %d no existe o no tienes permiso para verla.", req.BlockedByID))
+		}
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al verificar la tarea bloqueante.")
+	}
+
+	dependency := models.TaskDependency{TaskID: taskID, BlockedByID: req.BlockedByID, CreatedBy: userID}
+	var cyclic bool
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", dependencyLockKey).Error; err != nil {
+			return err
+		}
+		var err error
+		if cyclic, err = createsCycle(tx, taskID, req.BlockedByID); err != nil || cyclic {
+			return err
+		}
+		// Agregar una dependencia existente no es un error
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&dependency).Error
+	}); err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al agregar la dependencia.")
+	}
+	if cyclic {
+		return problem.Respond(c, fiber.StatusConflict, problem.CodeDependencyCycle, fmt.Sprintf("La tarea %d ya depende de la tarea %d: la dependencia crearía un ciclo.", req.BlockedByID, taskID))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Dependencia agregada exitosamente.",
+		"data":    dependency,
+	})
+}
+
+// RemoveDependency godoc
+// @Summary Quitar un bloqueante de una tarea
+// @Description Elimina la dependencia entre la tarea y la tarea bloqueante. Solo el creador de la tarea (o un administrador) puede modificar sus dependencias.
+// @Tags dependencies
+// @Produce json
+// @Param id path int true "ID numérico de la tarea bloqueada" Format(uint)
+// @Param blockerId path int true "ID numérico de la tarea bloqueante" Format(uint)
+// @Security Bearer
+// @Success 200 {object} models.SuccessResponse "Dependencia eliminada exitosamente"
+// @Failure 400 {object} problem.Problem "ID inválido"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado"
+// @Failure 404 {object} problem.Problem "Tarea o dependencia no encontrada"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /tasks/{id}/dependencies/{blockerId} [delete]
+func (h *Handler) RemoveDependency(c *fiber.Ctx) error {
+	taskID, userID, ok, err := h.parseTaskParams(c)
+	if !ok {
+		return err
+	}
+	blockerID, err := strconv.ParseUint(c.Params("blockerId"), 10, 32)
+	if err != nil {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, "ID de tarea bloqueante inválido. Debe ser un número entero.")
+	}
+	if ok, err := h.requireManageableTask(c, taskID, userID); !ok {
+		return err
+	}
+
+	result := h.db.Where("task_id = ? AND blocked_by_id = ?", taskID, blockerID).Delete(&models.TaskDependency{})
+	if result.Error != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al eliminar la dependencia.")
+	}
+	if result.RowsAffected == 0 {
+		return problem.Respond(c, fiber.StatusNotFound, problem.CodeNotFound, "Dependencia no encontrada.")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Dependencia eliminada exitosamente.",
+	})
+}
@@ -0,0 +1,114 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"legendaryum/internal/problem"
+	"legendaryum/pkg/models"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Control de concurrencia optimista: cada tarea tiene una versión que aumenta con cada cambio y
+// se expone como ETag. PUT y DELETE exigen If-Match con el ETag vigente; GET admite If-None-Match.
+// El ETag de GET también refleja lo que no cambia la versión de la tarea (su progreso y, con
+// ?include=subtasks, el árbol de subtareas), para que If-None-Match no devuelva 304 con datos viejos.
+
+// ErrVersionMismatch indica que la tarea cambió entre la verificación de If-Match y la escritura
+var ErrVersionMismatch = errors.New("la versión de la tarea cambió")
+
+// taskETag devuelve el ETag de la tarea a partir de su ID y su versión
+func taskETag(task *models.Task) string {
+	return fmt.Sprintf(`"%d-%d"`, task.ID, task.Version)
+}
+
+// representationETag devuelve el ETag de la respuesta de GET. Si la respuesta no incluye progreso
+// ni subtareas es el de taskETag; si no, le agrega un hash del progreso y de la versión y el
+// progreso de cada subtarea cargada, de modo que cada representación tenga su propio ETag.
+func representationETag(task *models.Task, includeSubtasks bool) string {
+	if !includeSubtasks && task.Progress == nil {
+		return taskETag(task)
+	}
+	h := fnv.New64a()
+	fmt.Fprintf(h, "include=%t;", includeSubtasks)
+	hashSubtree(h, task)
+	return fmt.Sprintf(`"%d-%d-%x"`, task.ID, task.Version, h.Sum64())
+}
+
+// hashSubtree escribe en h la versión y el progreso de la tarea y, recursivamente, de sus subtareas
+func hashSubtree(h hash.Hash64, task *models.Task) {
+	progress := -1
+	if task.Progress != nil {
+		progress = *task.Progress
+	}
+	fmt.Fprintf(h, "%d:%d:%d[", task.ID, task.Version, progress)
+	for i := range task.Subtasks {
+		hashSubtree(h, &task.Subtasks[i])
+	}
+	fmt.Fprint(h, "]")
+}
+
+// versionMatches indica si alguno de los ETags de la cabecera If-Match corresponde a la versión
+// vigente de la tarea: el de taskETag o cualquiera de los de representationETag de esa versión.
+func versionMatches(header string, task *models.Task) bool {
+	etag := taskETag(task)
+	prefix := strings.TrimSuffix(etag, `"`) + "-"
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag || strings.HasPrefix(candidate, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// etagMatches indica si alguno de los ETags de la cabecera (separados por coma) coincide con etag.
+// Con weak=true se ignora el prefijo W/ (comparación débil, usada por If-None-Match).
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch exige la cabecera If-Match con un ETag de la versión vigente de la tarea. Si falta
+// responde 428 y si no coincide 412, en ambos casos con el ETag actual. Devuelve ok=false si ya respondió.
+func checkIfMatch(c *fiber.Ctx, task *models.Task) (bool, error) {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return false, problem.Respond(c, fiber.StatusPreconditionRequired, problem.CodePreconditionRequired, "Se requiere la cabecera If-Match con el ETag de la tarea (obtenlo con GET /tasks/{id}).")
+	}
+	if !versionMatches(header, task) {
+		return false, preconditionFailed(c, task)
+	}
+	return true, nil
+}
+
+// preconditionFailed responde 412 informando el ETag vigente de la tarea
+func preconditionFailed(c *fiber.Ctx, task *models.Task) error {
+	c.Set(fiber.HeaderETag, taskETag(task))
+	return problem.New(fiber.StatusPreconditionFailed, problem.CodeVersionMismatch,
+		"La tarea fue modificada por otra persona. Vuelve a obtenerla y reintenta el cambio.").
+		With("version", task.Version).
+		Send(c)
+}
+
+// touchTasks aumenta la versión de las tareas que cumplen la condición. Se usa cuando cambia algo
+// que forma parte de su representación sin pasar por PUT (etiquetas, proyecto), para invalidar
+// los ETags que tengan los clientes.
+func touchTasks(tx *gorm.DB, query string, args ...interface{}) error {
+	return tx.Model(&models.Task{}).Where(query, args...).UpdateColumn("version", gorm.Expr("version + 1")).Error
+}
@@ -0,0 +1,150 @@
+package tasks
+
+import (
+	"legendaryum/internal/middleware"
+	"legendaryum/internal/problem"
+	"legendaryum/pkg/models"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Historial de cambios (auditoría) de tareas
+
+// taskSnapshot devuelve los campos auditados de una tarea en un formato comparable
+func taskSnapshot(task models.Task) map[string]interface{} {
+	return map[string]interface{}{
+		"title":       task.Title,
+		"description": task.Description,
+		"status":      task.Status,
+		"priority":    task.Priority,
+		"due_date":    task.DueDate.UTC().Format(time.RFC3339Nano),
+		"assignee_id": task.AssigneeID,
+		"project_id":  optionalID(task.ProjectID),
+		"parent_id":   optionalID(task.ParentID),
+	}
+}
+
+// optionalID devuelve un ID opcional como valor comparable (nil si no está definido)
+func optionalID(id *uint) interface{} {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
+
+// diffSnapshots compara dos snapshots y devuelve solo los campos que cambiaron.
+// Un snapshot nil representa la tarea inexistente (antes de crearla o después de borrarla).
+func diffSnapshots(before, after map[string]interface{}) models.TaskChanges {
+	changes := models.TaskChanges{}
+	keys := before
+	if keys == nil {
+		keys = after
+	}
+	for field := range keys {
+		from, to := before[field], after[field]
+		if from != to {
+			changes[field] = models.FieldChange{From: from, To: to}
+		}
+	}
+	return changes
+}
+
+// recordTaskEvent agrega una entrada al historial. Debe llamarse dentro de la misma
+// transacción que el cambio de la tarea.
+func recordTaskEvent(tx *gorm.DB, taskID uint, actorID, action string, changes models.TaskChanges) error {
+	event := models.TaskEvent{
+		TaskID:  taskID,
+		ActorID: actorID,
+		Action:  action,
+		Changes: changes,
+	}
+	return tx.Create(&event).Error
+}
+
+// History godoc
+// @Summary Historial de una tarea
+// @Description Obtiene el historial de cambios de una tarea (creación, actualizaciones, borrado y restauración) con el valor anterior y el nuevo de cada campo modificado, en orden cronológico y paginado por cursor. También se puede consultar con la tarea en la papelera.
+// @Tags tasks
+// @Produce json
+// @Param id path int true "ID numérico de la tarea" Format(uint)
+// @Param limit query int false "Cantidad máxima de eventos por página (1-100, por defecto 20)"
+// @Param cursor query string false "Cursor opaco devuelto en meta.next_cursor por la página anterior"
+// @Security Bearer
+// @Success 200 {object} models.TaskEventListResponse "Página del historial"
+// @Failure 400 {object} problem.Problem "ID o parámetros de paginación inválidos"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 404 {object} problem.Problem "Tarea no encontrada"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /tasks/{id}/history [get]
+func (h *Handler) History(c *fiber.Ctx) error {
+	taskID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, "ID de tarea inválido. Debe ser un número entero.")
+	}
+
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
+	}
+
+	// El historial sigue disponible con la tarea en la papelera (Unscoped), para quien podía verla
+	var task models.Task
+	if err := h.db.Unscoped().Scopes(visibleTasks(userID, middleware.CurrentRole(c))).
+		Select("tasks.id").Where("tasks.id = ?", taskID).First(&task).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return problem.Respond(c, fiber.StatusNotFound, problem.CodeNotFound, "Tarea no encontrada o no tienes permiso para verla.")
+		}
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener la tarea.")
+	}
+
+	limit, err := parseLimit(c.Query("limit"))
+	if err != nil {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+	}
+
+	query := h.db.Model(&models.TaskEvent{}).Where("task_id = ?", taskID).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al contar el historial.")
+	}
+
+	page := query
+	if cursor := c.Query("cursor"); cursor != "" {
+		afterID, err := decodeIDCursor(cursor)
+		if err != nil {
+			return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+		}
+		page = page.Where("id > ?", afterID)
+	}
+
+	var events []models.TaskEvent
+	if err := page.Order("id ASC").Limit(limit + 1).Find(&events).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener el historial.")
+	}
+
+	var nextCursor *string
+	if len(events) > limit {
+		events = events[:limit]
+		cursor := encodeIDCursor(uint(events[len(events)-1].ID))
+		nextCursor = &cursor
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Historial obtenido exitosamente.",
+		"data":    events,
+		"meta": models.PageMeta{
+			Total:      total,
+			Limit:      limit,
+			Sort:       "id",
+			NextCursor: nextCursor,
+		},
+	})
+}
@@ -0,0 +1,256 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Filtros del listado de tareas. Los query params se validan y se convierten en un taskFilter,
+// que es el único que construye las condiciones de la consulta (siempre con parámetros).
+
+// taskListParams son los parámetros de búsqueda, filtro y orden aceptados por el listado de
+// tareas (y por las vistas guardadas). Cualquier otro se rechaza con 400.
+var taskListParams = map[string]bool{
+	"q": true, "sort": true,
+	"status": true, "priority": true, "project_id": true, "labels": true, "labels_mode": true,
+	"due_before": true, "due_after": true, "created_after": true,
+	"assignee": true, "creator": true, "overdue": true,
+}
+
+// pageParams son los parámetros de paginación, que no forman parte de la especificación del listado
+var pageParams = map[string]bool{"limit": true, "cursor": true}
+
+var (
+	validTaskStatuses   = []string{"pending", "in_progress", "complete"}
+	validTaskPriorities = []string{"low", "medium", "high"}
+)
+
+// filterDateLayouts son los formatos admitidos en los filtros de fecha
+var filterDateLayouts = []string{time.RFC3339, "2006-01-02"}
+
+// taskFilter es el conjunto de filtros ya validados del listado de tareas
+type taskFilter struct {
+	Statuses     []string
+	Priorities   []string
+	ProjectID    uint
+	Labels       []string
+	LabelsMode   string
+	DueBefore    *time.Time
+	DueAfter     *time.Time
+	CreatedAfter *time.Time
+	AssigneeID   string
+	CreatorID    string
+	Overdue      *bool
+}
+
+// taskListSpec es una búsqueda de tareas ya validada: texto, filtros y orden
+type taskListSpec struct {
+	Query  string
+	Filter *taskFilter
+	Sort   []sortField
+}
+
+// queryParams devuelve los query params de la request, sin los de paginación
+func queryParams(c *fiber.Ctx) map[string]string {
+	params := make(map[string]string)
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		if !pageParams[string(key)] {
+			params[string(key)] = string(value)
+		}
+	})
+	return params
+}
+
+// parseTaskListSpec valida la búsqueda, los filtros y el orden de un listado de tareas.
+// Los parámetros desconocidos se rechazan.
+func parseTaskListSpec(params map[string]string, userID string) (*taskListSpec, error) {
+	var unknown []string
+	for key := range params {
+		if !taskListParams[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("Parámetros de filtro desconocidos: %s.", strings.Join(unknown, ", "))
+	}
+
+	spec := &taskListSpec{Query: strings.TrimSpace(params["q"])}
+	if len(spec.Query) > maxSearchQueryLen {
+		return nil, fmt.Errorf("El parámetro 'q' no puede superar los %d caracteres.", maxSearchQueryLen)
+	}
+	var err error
+	if spec.Sort, err = parseSort(params["sort"], spec.Query != ""); err != nil {
+		return nil, err
+	}
+	if spec.Filter, err = parseTaskFilter(params, userID); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// parseTaskFilter valida los filtros del listado. userID resuelve el valor "me"
+// de los filtros assignee y creator.
+func parseTaskFilter(params map[string]string, userID string) (*taskFilter, error) {
+	f := &taskFilter{}
+	var err error
+	if f.Statuses, err = parseSetFilter("status", params["status"], validTaskStatuses); err != nil {
+		return nil, err
+	}
+	if f.Priorities, err = parseSetFilter("priority", params["priority"], validTaskPriorities); err != nil {
+		return nil, err
+	}
+	if f.Labels, f.LabelsMode, err = parseLabelQuery(params["labels"], params["labels_mode"]); err != nil {
+		return nil, err
+	}
+	if raw := params["project_id"]; raw != "" {
+		projectID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil || projectID == 0 {
+			return nil, errors.New("project_id inválido. Debe ser un número entero positivo.")
+		}
+		f.ProjectID = uint(projectID)
+	}
+
+	if f.DueBefore, err = parseDateFilter("due_before", params["due_before"]); err != nil {
+		return nil, err
+	}
+	if f.DueAfter, err = parseDateFilter("due_after", params["due_after"]); err != nil {
+		return nil, err
+	}
+	if f.DueBefore != nil && f.DueAfter != nil && !f.DueAfter.Before(*f.DueBefore) {
+		return nil, errors.New("due_after debe ser anterior a due_before.")
+	}
+	if f.CreatedAfter, err = parseDateFilter("created_after", params["created_after"]); err != nil {
+		return nil, err
+	}
+
+	if f.AssigneeID, err = parseUserFilter("assignee", params["assignee"], userID); err != nil {
+		return nil, err
+	}
+	if f.CreatorID, err = parseUserFilter("creator", params["creator"], userID); err != nil {
+		return nil, err
+	}
+
+	switch raw := params["overdue"]; raw {
+	case "":
+	case "true", "false":
+		overdue := raw == "true"
+		f.Overdue = &overdue
+	default:
+		return nil, fmt.Errorf("overdue inválido: %q. Valores permitidos: true, false.", raw)
+	}
+
+	return f, nil
+}
+
+// parseSetFilter lee un filtro de igualdad ("pending") o de conjunto ("in:pending,in_progress")
+// y valida cada valor contra los permitidos.
+func parseSetFilter(name, raw string, allowed []string) ([]string, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	values := []string{raw}
+	if list, ok := strings.CutPrefix(raw, "in:"); ok {
+		values = nil
+		for _, part := range strings.Split(list, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("%s inválido: la lista 'in:' no puede estar vacía.", name)
+		}
+	}
+	for _, value := range values {
+		if !containsString(allowed, value) {
+			return nil, fmt.Errorf("%s inválido: %q. Valores permitidos: %s.", name, value, strings.Join(allowed, ", "))
+		}
+	}
+	return values, nil
+}
+
+// parseDateFilter acepta una fecha RFC 3339 o solo la fecha (YYYY-MM-DD, en UTC)
+func parseDateFilter(name, raw string) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	for _, layout := range filterDateLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%s inválido: %q. Formatos permitidos: RFC 3339 (2024-06-20T00:00:00Z) o YYYY-MM-DD.", name, raw)
+}
+
+// parseUserFilter acepta "me" (el usuario autenticado) o el UUID de un usuario
+func parseUserFilter(name, raw, userID string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+	if raw == "me" {
+		return userID, nil
+	}
+	if _, err := uuid.Parse(raw); err != nil {
+		return "", fmt.Errorf("%s inválido: %q. Debe ser 'me' o el UUID de un usuario.", name, raw)
+	}
+	return raw, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// scope aplica los filtros a la consulta de tareas del usuario
+func (f *taskFilter) scope(now time.Time, userID, role string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(f.Statuses) > 0 {
+			db = db.Where("tasks.status IN ?", f.Statuses)
+		}
+		if len(f.Priorities) > 0 {
+			db = db.Where("tasks.priority IN ?", f.Priorities)
+		}
+		if f.ProjectID != 0 {
+			db = db.Where("tasks.project_id = ?", f.ProjectID)
+		}
+		if len(f.Labels) > 0 {
+			db = db.Scopes(labelFilter(f.Labels, f.LabelsMode, userID, role))
+		}
+		if f.DueBefore != nil {
+			db = db.Where("tasks.due_date < ?", *f.DueBefore)
+		}
+		if f.DueAfter != nil {
+			db = db.Where("tasks.due_date > ?", *f.DueAfter)
+		}
+		if f.CreatedAfter != nil {
+			db = db.Where("tasks.created_at > ?", *f.CreatedAfter)
+		}
+		if f.AssigneeID != "" {
+			db = db.Where("tasks.assignee_id = ?", f.AssigneeID)
+		}
+		if f.CreatorID != "" {
+			db = db.Where("tasks.creator_id = ?", f.CreatorID)
+		}
+		if f.Overdue != nil {
+			// Vencida: fecha límite pasada y todavía sin completar
+			if *f.Overdue {
+				db = db.Where("tasks.due_date < ? AND tasks.status <> ?", now, "complete")
+			} else {
+				db = db.Where("NOT (tasks.due_date < ? AND tasks.status <> ?)", now, "complete")
+			}
+		}
+		return db
+	}
+}
@@ -3,8 +3,14 @@ package tasks
 import (
 	"fmt"
 	"legendaryum/internal/config"
+	"legendaryum/internal/middleware"
+	"legendaryum/internal/problem"
+	"legendaryum/internal/users"
+	"legendaryum/internal/validation"
 	"legendaryum/pkg/models"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -12,119 +18,94 @@ import (
 
 // Handler maneja las operaciones relacionadas con tareas
 type Handler struct {
-	db  *gorm.DB
-	cfg *config.Config
+	db      *gorm.DB
+	cfg     *config.Config
+	service *Service
 }
 
-// NewHandler crea una nueva instancia del handler de tareas
+// NewHandler crea una nueva instancia del handler de tareas. El flujo de estados
+// (cfg.TaskWorkflow) debe haberse validado antes con ParseWorkflow.
 func NewHandler(db *gorm.DB, cfg *config.Config) *Handler {
+	return NewHandlerWithRepositories(db, cfg, NewPostgresTaskRepository(db), users.NewPostgresUserRepository(db))
+}
+
+// NewHandlerWithRepositories crea el handler de tareas con el servicio sobre los repositorios
+// indicados. Crear, obtener, actualizar, eliminar y las operaciones masivas por ID usan solo el
+// servicio, por lo que con los repositorios en memoria db puede ser nil; el resto de los
+// endpoints consulta db directamente.
+func NewHandlerWithRepositories(db *gorm.DB, cfg *config.Config, taskRepo TaskRepository, userRepo users.UserRepository) *Handler {
+	workflow, err := ParseWorkflow(cfg.TaskWorkflow)
+	if err != nil {
+		panic(fmt.Sprintf("TASK_WORKFLOW inválido: %v", err))
+	}
 	return &Handler{
-		db:  db,
-		cfg: cfg,
+		db:      db,
+		cfg:     cfg,
+		service: NewService(taskRepo, userRepo, workflow),
+	}
+}
+
+// visibleTasks limita una consulta a las tareas que el usuario puede ver: las que creó,
+// las que tiene asignadas y las de los proyectos de los que es miembro, o todas si su rol
+// puede gestionar cualquier tarea.
+func visibleTasks(userID, role string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if models.HasPermission(role, models.PermTasksManageAll) {
+			return db
+		}
+		return db.Where(
+			"tasks.creator_id = ? OR tasks.assignee_id = ? OR tasks.project_id IN (SELECT project_id FROM project_members WHERE user_id = ?)",
+			userID, userID, userID,
+		)
+	}
+}
+
+// manageableTasks limita una consulta a las tareas que el usuario puede modificar o eliminar:
+// las que creó, o todas si su rol puede gestionar cualquier tarea.
+func manageableTasks(userID, role string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if models.HasPermission(role, models.PermTasksManageAll) {
+			return db
+		}
+		return db.Where("tasks.creator_id = ?", userID)
 	}
 }
 
 // Create godoc
 // @Summary Crear una nueva tarea
-// @Description Crea una nueva tarea en el sistema Legendaryum. El creador se toma del token JWT. Si assignee_id no se especifica, la tarea se asigna al creador.
+// @Description Crea una nueva tarea en el sistema Legendaryum. El creador se toma del token JWT. Si assignee_id no se especifica, la tarea se asigna al creador. Si se indica project_id, el creador debe ser miembro del proyecto. Si se indica parent_id, la tarea se crea como subtarea (y hereda el proyecto del padre si no se indica otro).
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param request body models.TaskRequest true "Datos necesarios para crear una tarea"
 // @Security Bearer
 // @Success 201 {object} models.Task "Tarea creada exitosamente" // Usar models.Task para la respuesta completa
-// @Failure 400 {object} models.ErrorResponse "Error en los datos de entrada (JSON inválido, campos requeridos, assignee no encontrado)"
-// @Failure 401 {object} models.ErrorResponse "No autorizado (token JWT faltante o inválido)"
-// @Failure 500 {object} models.ErrorResponse "Error interno del servidor"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada (JSON inválido, campos requeridos, assignee no encontrado, proyecto inexistente o ajeno)"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado (los viewers no pueden crear tareas)"
+// @Failure 409 {object} problem.Problem "No se pueden agregar subtareas abiertas a una tarea completada"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
 // @Router /tasks [post]
 func (h *Handler) Create(c *fiber.Ctx) error {
 	var req models.TaskRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Error al procesar la solicitud: JSON inválido.",
-		})
-	}
-
-	// Validar campos requeridos (ya existen, se pueden mejorar los mensajes)
-	if req.Title == "" || req.Description == "" || req.DueDate.IsZero() {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Los campos 'title', 'description' y 'due_date' son requeridos.",
-		})
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidJSON, "Error al procesar la solicitud: JSON inválido.")
 	}
 
 	// Obtener el ID del usuario del token JWT
 	creatorID, ok := c.Locals("user_id").(string)
 	if !ok || creatorID == "" {
 		// Esto no debería ocurrir si el middleware AuthMiddleware funciona, pero es una seguridad
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Usuario no autenticado.",
-		})
-	}
-
-	// Si no se especifica assignee_id, usar el ID del creador
-	assigneeID := req.AssigneeID
-	if assigneeID == "" {
-		assigneeID = creatorID
-	}
-
-	// Validar que el assignee existe si se especificó uno diferente al creador
-	if assigneeID != creatorID {
-		var assignee models.User
-		if err := h.db.First(&assignee, "id = ?", assigneeID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"status":  "error",
-					"message": fmt.Sprintf("El usuario asignado con ID %s no existe.", assigneeID),
-				})
-			}
-			// Loggear error
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Error interno al verificar usuario asignado.",
-			})
-		}
-	}
-
-	// Establecer valores por defecto si no se especifican (basado en tags validate omitempty)
-	status := req.Status
-	if status == "" {
-		status = "pending"
-	}
-	priority := req.Priority
-	if priority == "" {
-		priority = "medium"
-	}
-
-	task := models.Task{
-		Title:       req.Title,
-		Description: req.Description,
-		Status:      status,   // Usar el valor (por defecto o del request)
-		Priority:    priority, // Usar el valor (por defecto o del request)
-		DueDate:     req.DueDate,
-		CreatorID:   creatorID,
-		AssigneeID:  assigneeID,
-	}
-
-	if err := h.db.Create(&task).Error; err != nil {
-		// Loggear error
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Error interno al crear la tarea.",
-		})
+		return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
 	}
 
-	// Cargar las relaciones creator y assignee para la respuesta
-	if err := h.db.Preload("Creator").Preload("Assignee").First(&task, task.ID).Error; err != nil {
-		// Loggear error
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Error interno al cargar los datos de la tarea creada.",
-		})
+	// Validar la solicitud, las referencias y los permisos, y crear la tarea con su evento de creación
+	task, err := h.service.Create(c.UserContext(), req, creatorID, middleware.CurrentRole(c))
+	if err != nil {
+		return respondWriteError(c, nil, err, "Error interno al crear la tarea.")
 	}
 
+	c.Set(fiber.HeaderETag, taskETag(task))
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"status":  "success",
 		"message": "Tarea creada exitosamente.",
@@ -134,107 +115,181 @@ func (h *Handler) Create(c *fiber.Ctx) error {
 
 // List godoc
 // @Summary Listar tareas
-// @Description Obtiene todas las tareas donde el usuario autenticado es el creador o el asignado. Permite filtrar por estado y prioridad.
+// @Description Obtiene las tareas donde el usuario autenticado es el creador o el asignado y las de los proyectos de los que es miembro, paginadas por cursor. Permite buscar por texto, filtrar por estado, prioridad, proyecto, etiquetas, fechas, creador, asignado y vencimiento, y ordenar por varios campos.
 // @Tags tasks
 // @Accept json
 // @Produce json
-// @Param status query string false "Filtrar por estado de la tarea ('pending', 'in_progress', 'complete')" Enums: pending, in_progress, complete
-// @Param priority query string false "Filtrar por prioridad de la tarea ('low', 'medium', 'high')" Enums: low, medium, high
+// @Param status query string false "Filtrar por estado ('pending', 'in_progress', 'complete') o por varios con 'in:', por ejemplo 'in:pending,in_progress'"
+// @Param priority query string false "Filtrar por prioridad ('low', 'medium', 'high') o por varias con 'in:', por ejemplo 'in:medium,high'"
+// @Param project_id query int false "Filtrar por proyecto"
+// @Param due_before query string false "Tareas con fecha límite anterior a esta fecha (RFC 3339 o YYYY-MM-DD)"
+// @Param due_after query string false "Tareas con fecha límite posterior a esta fecha (RFC 3339 o YYYY-MM-DD)"
+// @Param created_after query string false "Tareas creadas después de esta fecha (RFC 3339 o YYYY-MM-DD)"
+// @Param assignee query string false "Filtrar por asignado: 'me' o el UUID de un usuario"
+// @Param creator query string false "Filtrar por creador: 'me' o el UUID de un usuario"
+// @Param overdue query bool false "Con 'true' solo las tareas vencidas (fecha límite pasada y sin completar); con 'false' las no vencidas"
+// @Param q query string false "Búsqueda de texto completo en título y descripción (sintaxis web: frases entre comillas, OR, -excluir). Ordena por relevancia y agrega 'rank' y 'snippet' a cada tarea"
+// @Param labels query string false "Filtrar por nombres de etiqueta separados por coma, por ejemplo 'bug,frontend'"
+// @Param labels_mode query string false "Con 'any' (por defecto) basta una de las etiquetas; con 'all' deben estar todas" Enums(any, all)
+// @Param limit query int false "Cantidad máxima de tareas por página (1-100, por defecto 20)"
+// @Param cursor query string false "Cursor opaco devuelto en meta.next_cursor por la página anterior"
+// @Param sort query string false "Campos de ordenamiento separados por coma, con '-' para descendente (id, title, status, priority, due_date, created_at, updated_at y, con q, rank). Por defecto '-created_at' ('-rank' con q)"
 // @Security Bearer
-// @Success 200 {array} models.Task "Lista de tareas" // Usar array de models.Task
-// @Failure 401 {object} models.ErrorResponse "No autorizado (token JWT faltante o inválido)"
-// @Failure 500 {object} models.ErrorResponse "Error interno del servidor"
+// @Success 200 {object} models.TaskListResponse "Página de tareas con metadatos de paginación"
+// @Failure 400 {object} problem.Problem "Parámetros de paginación, ordenamiento o filtros inválidos o desconocidos"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
 // @Router /tasks [get]
 func (h *Handler) List(c *fiber.Ctx) error {
 	userID, ok := c.Locals("user_id").(string)
 	if !ok || userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Usuario no autenticado.",
-		})
+		return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
 	}
 
-	// Obtener filtros de query params
-	status := c.Query("status")
-	priority := c.Query("priority")
+	// Búsqueda, filtros y orden validados a partir de los query params
+	spec, err := parseTaskListSpec(queryParams(c), userID)
+	if err != nil {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+	}
+	return h.listTasks(c, userID, spec)
+}
+
+// listTasks ejecuta un listado de tareas ya validado y responde con la página pedida en los
+// parámetros limit y cursor. Lo usan GET /tasks y GET /views/:id/tasks.
+func (h *Handler) listTasks(c *fiber.Ctx, userID string, spec *taskListSpec) error {
+	// Parámetros de paginación
+	limit, err := parseLimit(c.Query("limit"))
+	if err != nil {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+	}
+	sortFields := spec.Sort
+	var cursorValues []interface{}
+	if cursor := c.Query("cursor"); cursor != "" {
+		if cursorValues, err = decodeCursor(cursor, sortFields); err != nil {
+			return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+		}
+	}
 
-	// Construir la consulta base
-	query := h.db.Where("creator_id = ? OR assignee_id = ?", userID, userID)
+	// Construir la consulta base (con ?q= sobre los resultados de la búsqueda, con su relevancia)
+	role := middleware.CurrentRole(c)
+	query := h.db.Model(&models.Task{})
+	if spec.Query != "" {
+		query = searchTasks(h.db, spec.Query)
+	}
+	query = query.Scopes(visibleTasks(userID, role), spec.Filter.scope(time.Now(), userID, role))
+	// Permitir reutilizar la consulta filtrada para el conteo y para la página
+	query = query.Session(&gorm.Session{})
 
-	// Aplicar filtros si existen
-	if status != "" {
-		// Opcional: validar que el estado es un valor válido del ENUM si es necesario
-		query = query.Where("status = ?", status)
+	// Total de tareas que cumplen los filtros (sin tener en cuenta el cursor)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al contar las tareas.")
 	}
-	if priority != "" {
-		// Opcional: validar que la prioridad es un valor válido del ENUM si es necesario
-		query = query.Where("priority = ?", priority)
+
+	page := query
+	if cursorValues != nil {
+		condition, args := keysetCondition(sortFields, cursorValues)
+		page = page.Where(condition, args...)
 	}
 
+	// Se pide un elemento extra para saber si existe una página siguiente
 	var tasks []models.Task
-	if err := query.Preload("Creator").Preload("Assignee").Find(&tasks).Error; err != nil {
+	if err := page.Preload("Creator").Preload("Assignee").Preload("Labels", usableLabels(userID, role)).
+		Order(orderClause(sortFields)).Limit(limit + 1).
+		Find(&tasks).Error; err != nil {
 		// Loggear error
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Error interno al obtener las tareas.",
-		})
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener las tareas.")
+	}
+
+	var nextCursor *string
+	if len(tasks) > limit {
+		tasks = tasks[:limit]
+		cursor := encodeCursor(sortFields, tasks[len(tasks)-1])
+		nextCursor = &cursor
+	}
+
+	if err := attachProgress(h.db, tasks); err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al calcular el progreso de las tareas.")
+	}
+	if spec.Query != "" {
+		if err := attachSnippets(h.db, tasks, spec.Query); err != nil {
+			// Loggear error
+			return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al generar los fragmentos de la búsqueda.")
+		}
 	}
 
 	return c.JSON(fiber.Map{
 		"status":  "success",
 		"message": "Tareas obtenidas exitosamente.",
 		"data":    tasks,
+		"meta": models.PageMeta{
+			Total:      total,
+			Limit:      limit,
+			Sort:       sortKey(sortFields),
+			NextCursor: nextCursor,
+		},
 	})
 }
 
 // Get godoc
 // @Summary Obtener una tarea específica
-// @Description Obtiene los detalles de una tarea por su ID si el usuario autenticado es el creador o el asignado.
+// @Description Obtiene los detalles de una tarea por su ID si el usuario autenticado es el creador, el asignado o miembro de su proyecto. Incluye el progreso (porcentaje de subtareas directas completadas) y, con include=subtasks, el árbol de subtareas.
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param id path int true "ID numérico de la tarea a obtener" Format(uint)
+// @Param include query string false "Relaciones adicionales a incluir" Enums(subtasks)
+// @Param If-None-Match header string false "ETag conocido por el cliente; si la respuesta no cambió (incluidos el progreso y las subtareas) se responde 304"
 // @Security Bearer
-// @Success 200 {object} models.Task "Detalles de la tarea" // Usar models.Task
-// @Failure 400 {object} models.ErrorResponse "ID inválido"
-// @Failure 401 {object} models.ErrorResponse "No autorizado (token JWT faltante o inválido)"
-// @Failure 403 {object} models.ErrorResponse "Acceso denegado (la tarea no pertenece al usuario)"
-// @Failure 404 {object} models.ErrorResponse "Tarea no encontrada"
-// @Failure 500 {object} models.ErrorResponse "Error interno del servidor"
+// @Success 200 {object} models.Task "Detalles de la tarea (con el ETag en la cabecera)" // Usar models.Task
+// @Success 304 "La tarea no cambió desde el ETag indicado en If-None-Match"
+// @Failure 400 {object} problem.Problem "ID inválido"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Acceso denegado (la tarea no pertenece al usuario)"
+// @Failure 404 {object} problem.Problem "Tarea no encontrada"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
 // @Router /tasks/{id} [get]
 func (h *Handler) Get(c *fiber.Ctx) error {
 	taskID, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": "ID de tarea inválido. Debe ser un número entero.",
-		})
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, "ID de tarea inválido. Debe ser un número entero.")
 	}
 
 	userID, ok := c.Locals("user_id").(string)
 	if !ok || userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Usuario no autenticado.",
-		})
-	}
-
-	var task models.Task
-	if err := h.db.Where("id = ? AND (creator_id = ? OR assignee_id = ?)", taskID, userID, userID).
-		Preload("Creator").Preload("Assignee").
-		First(&task).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// Si no se encuentra O si no pertenece al usuario, retorna 404
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Tarea no encontrada o no tienes permiso para verla.",
-			})
+		return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
+	}
+
+	includeSubtasks := false
+	if include := c.Query("include"); include != "" {
+		for _, part := range strings.Split(include, ",") {
+			if strings.TrimSpace(part) != "subtasks" {
+				return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, fmt.Sprintf("Valor de include inválido: %q. Valores permitidos: subtasks.", part))
+			}
+			includeSubtasks = true
 		}
-		// Loggear error
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Error interno al obtener la tarea.",
-		})
+	}
+
+	role := middleware.CurrentRole(c)
+	task, err := h.service.Get(c.UserContext(), uint(taskID), userID, role)
+	if err != nil {
+		return respondWriteError(c, nil, err, "Error interno al obtener la tarea.")
+	}
+
+	if includeSubtasks {
+		if err := h.loadSubtasks(task, userID, role); err != nil {
+			// Loggear error
+			return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener las subtareas.")
+		}
+	}
+
+	// La respuesta no cambió desde la que ya tiene el cliente
+	etag := representationETag(task, includeSubtasks)
+	c.Set(fiber.HeaderETag, etag)
+	if header := c.Get(fiber.HeaderIfNoneMatch); header != "" && etagMatches(header, etag, true) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
 	return c.JSON(fiber.Map{
@@ -245,197 +300,184 @@ func (h *Handler) Get(c *fiber.Ctx) error {
 }
 
 // Update godoc
-// @Summary Actualizar una tarea
-// @Description Actualiza los datos de una tarea existente. Solo el creador de la tarea puede actualizarla.
+// @Summary Reemplazar una tarea
+// @Description Reemplaza todos los campos editables de una tarea. Los campos omitidos toman los valores por defecto de la creación (status 'pending', priority 'medium', el creador como asignado y sin proyecto ni tarea padre). Para cambios parciales usar PATCH. Solo el creador de la tarea (o un administrador) puede actualizarla. Una tarea no puede completarse mientras tenga subtareas abiertas o tareas que la bloqueen abiertas (se informan en blocked_by). Requiere If-Match con el ETag vigente (control de concurrencia optimista).
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param id path int true "ID numérico de la tarea a actualizar" Format(uint)
-// @Param request body models.TaskRequest true "Datos para actualizar la tarea"
+// @Param If-Match header string true "ETag vigente de la tarea (devuelto por GET, POST, PUT o PATCH)"
+// @Param request body models.TaskRequest true "Tarea completa"
 // @Security Bearer
 // @Success 200 {object} models.Task "Tarea actualizada exitosamente" // Usar models.Task
-// @Failure 400 {object} models.ErrorResponse "Error en los datos de entrada (ID inválido, JSON inválido, nuevo assignee no encontrado)"
-// @Failure 401 {object} models.ErrorResponse "No autorizado (token JWT faltante o inválido)"
-// @Failure 403 {object} models.ErrorResponse "Permiso denegado (solo el creador puede actualizar)"
-// @Failure 404 {object} models.ErrorResponse "Tarea no encontrada"
-// @Failure 500 {object} models.ErrorResponse "Error interno del servidor"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada (ID inválido, JSON inválido, campos requeridos, nuevo assignee no encontrado, proyecto inexistente o ajeno)"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado (solo el creador o un administrador puede actualizar; los viewers no pueden escribir; la transición de estado está reservada a otro actor)"
+// @Failure 404 {object} problem.Problem "Tarea no encontrada"
+// @Failure 409 {object} problem.Problem "El flujo de estados no admite la transición (se informan las permitidas en allowed_transitions), la tarea tiene subtareas o bloqueantes abiertos, o la nueva tarea padre está completada"
+// @Failure 412 {object} problem.Problem "If-Match no coincide: la tarea fue modificada (se devuelve el ETag vigente)"
+// @Failure 428 {object} problem.Problem "Falta la cabecera If-Match"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
 // @Router /tasks/{id} [put]
 func (h *Handler) Update(c *fiber.Ctx) error {
-	taskID, err := strconv.ParseUint(c.Params("id"), 10, 32)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": "ID de tarea inválido. Debe ser un número entero.",
-		})
-	}
-
-	userID, ok := c.Locals("user_id").(string)
-	if !ok || userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Usuario no autenticado.",
-		})
+	task, userID, ok, err := h.loadTaskForWrite(c, "actualizar")
+	if !ok {
+		return err
 	}
 
 	var req models.TaskRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Error al procesar la solicitud: JSON inválido.",
-		})
-	}
-
-	var task models.Task
-	// Buscar tarea y verificar que el usuario es el creador
-	if err := h.db.Where("id = ? AND creator_id = ?", taskID, userID).First(&task).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// Si no se encuentra O si no es el creador, retorna 403 (Permiso denegado)
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"status":  "error",
-				"message": "No tienes permiso para actualizar esta tarea.",
-			})
-		}
-		// Loggear error
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Error interno al obtener la tarea para actualizar.",
-		})
-	}
-
-	// Aplicar actualizaciones desde la solicitud si los campos están presentes
-	// Fiber BodyParser con structs y validate tags ayuda, pero GORM Save necesita que copies
-	// los campos que realmente quieres actualizar.
-	updates := make(map[string]interface{})
-
-	if req.Title != "" {
-		updates["title"] = req.Title
-	}
-	if req.Description != "" {
-		updates["description"] = req.Description
-	}
-	// Comprobar si DueDate no es la hora cero por defecto
-	if !req.DueDate.IsZero() {
-		updates["due_date"] = req.DueDate
-	}
-	// Comprobar si Status está en la lista de valores permitidos o no está vacío
-	if req.Status != "" {
-		// Opcional: Validar req.Status con los valores del ENUM antes de añadir a updates
-		updates["status"] = req.Status
-	}
-	// Comprobar si Priority está en la lista de valores permitidos o no está vacío
-	if req.Priority != "" {
-		// Opcional: Validar req.Priority con los valores del ENUM antes de añadir a updates
-		updates["priority"] = req.Priority
-	}
-	if req.AssigneeID != "" {
-		// Validar que el nuevo assignee existe
-		var newAssignee models.User
-		if err := h.db.First(&newAssignee, "id = ?", req.AssigneeID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"status":  "error",
-					"message": fmt.Sprintf("El nuevo usuario asignado con ID %s no existe.", req.AssigneeID),
-				})
-			}
-			// Loggear error
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Error interno al verificar nuevo usuario asignado.",
-			})
-		}
-		updates["assignee_id"] = req.AssigneeID
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidJSON, "Error al procesar la solicitud: JSON inválido.")
 	}
+	changed, err := h.service.Replace(c.UserContext(), task, req, userID, middleware.CurrentRole(c))
+	return h.respondSaved(c, task, changed, err)
+}
 
-	// Usar Updates para actualizar solo los campos proporcionados
-	if len(updates) > 0 {
-		if err := h.db.Model(&task).Updates(updates).Error; err != nil {
-			// Loggear error
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Error interno al actualizar la tarea.",
-			})
-		}
-	} else {
-		// Si no hay campos para actualizar, retornar éxito con la tarea actual
-		return c.JSON(fiber.Map{
-			"status":  "success",
-			"message": "No se proporcionaron campos para actualizar.",
-			"data":    task, // Devolver la tarea sin modificar si no hay updates
-		})
+// Patch godoc
+// @Summary Modificar parcialmente una tarea
+// @Description Aplica un JSON Merge Patch (RFC 7396, Content-Type application/merge-patch+json) o un JSON Patch (RFC 6902, Content-Type application/json-patch+json) a los campos editables de la tarea (title, description, status, priority, due_date, assignee_id, project_id, parent_id). El resultado se valida completo y se guarda de forma atómica. En merge patch, null borra el campo (por ejemplo "project_id": null quita la tarea del proyecto). Requiere If-Match con el ETag vigente.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path int true "ID numérico de la tarea" Format(uint)
+// @Param If-Match header string true "ETag vigente de la tarea"
+// @Param request body object true "Merge patch (objeto) o JSON patch (arreglo de operaciones)"
+// @Security Bearer
+// @Success 200 {object} models.Task "Tarea actualizada exitosamente"
+// @Failure 400 {object} problem.Problem "ID inválido o patch mal formado"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado (solo el creador o un administrador puede actualizar; los viewers no pueden escribir; la transición de estado está reservada a otro actor)"
+// @Failure 409 {object} problem.Problem "El patch no se puede aplicar (falla una operación test o un path no existe), el flujo de estados no admite la transición, o la tarea tiene subtareas o bloqueantes abiertos"
+// @Failure 412 {object} problem.Problem "If-Match no coincide: la tarea fue modificada (se devuelve el ETag vigente)"
+// @Failure 415 {object} problem.Problem "Content-Type no soportado"
+// @Failure 422 {object} problem.Problem "La tarea resultante no cumple el esquema, o el assignee, proyecto o tarea padre no son válidos"
+// @Failure 428 {object} problem.Problem "Falta la cabecera If-Match"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /tasks/{id} [patch]
+func (h *Handler) Patch(c *fiber.Ctx) error {
+	contentType := strings.ToLower(strings.TrimSpace(strings.Split(c.Get(fiber.HeaderContentType), ";")[0]))
+	if contentType != mimeMergePatch && contentType != mimeJSONPatch {
+		return problem.Respond(c, fiber.StatusUnsupportedMediaType, problem.CodeUnsupportedMediaType, fmt.Sprintf("Content-Type no soportado. Usa %s o %s.", mimeMergePatch, mimeJSONPatch))
+	}
+
+	task, userID, ok, err := h.loadTaskForWrite(c, "actualizar")
+	if !ok {
+		return err
+	}
+
+	changed, err := h.service.Patch(c.UserContext(), task, contentType, c.Body(), userID, middleware.CurrentRole(c))
+	return h.respondSaved(c, task, changed, err)
+}
+
+// loadTaskForWrite obtiene y valida el ID de la tarea y el usuario autenticado, verifica que el
+// usuario pueda modificar la tarea y que If-Match coincida con su versión. Si algo falla, ya
+// escribe la respuesta de error y devuelve ok=false.
+func (h *Handler) loadTaskForWrite(c *fiber.Ctx, action string) (task *models.Task, userID string, ok bool, err error) {
+	taskID, parseErr := strconv.ParseUint(c.Params("id"), 10, 32)
+	if parseErr != nil {
+		return nil, "", false, problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, "ID de tarea inválido. Debe ser un número entero.")
 	}
 
-	// Cargar las relaciones creator y assignee después de actualizar
-	if err := h.db.Preload("Creator").Preload("Assignee").First(&task, taskID).Error; err != nil {
-		// Loggear error
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Error interno al cargar los datos actualizados de la tarea.",
-		})
+	userID, _ = c.Locals("user_id").(string)
+	if userID == "" {
+		return nil, "", false, problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
 	}
 
+	// Buscar tarea y verificar que el usuario es el creador (o un administrador)
+	task, findErr := h.service.GetForWrite(c.UserContext(), uint(taskID), userID, middleware.CurrentRole(c), action)
+	if findErr != nil {
+		return nil, "", false, respondWriteError(c, nil, findErr, fmt.Sprintf("Error interno al obtener la tarea para %s.", action))
+	}
+	if ok, err := checkIfMatch(c, task); !ok {
+		return nil, "", false, err
+	}
+	return task, userID, true, nil
+}
+
+// validationError convierte los errores de validación de una solicitud en un problema con el
+// detalle de cada campo en "errors"
+func validationError(status int, message string, err error) *problem.Problem {
+	p := problem.New(status, problem.CodeValidationFailed, fmt.Sprintf("%s: %s.", message, err.Error()))
+	if errs, ok := err.(validation.Errors); ok {
+		p.With("errors", errs)
+	}
+	return p
+}
+
+// respondValidationError responde con los errores de validación de la solicitud
+func respondValidationError(c *fiber.Ctx, status int, message string, err error) error {
+	return validationError(status, message, err).Send(c)
+}
+
+// respondWriteError escribe la respuesta de un error devuelto por el servicio: los problemas tal
+// cual, ErrVersionMismatch como 412 con el ETag vigente de task y ErrTaskNotFound (la tarea se
+// eliminó mientras se modificaba) como 404. internalMessage se usa para los errores inesperados.
+func respondWriteError(c *fiber.Ctx, task *models.Task, err error, internalMessage string) error {
+	if err == ErrVersionMismatch {
+		return preconditionFailed(c, task)
+	}
+	if err == ErrTaskNotFound {
+		return problem.Respond(c, fiber.StatusNotFound, problem.CodeNotFound, "Tarea no encontrada.")
+	}
+	if p, ok := err.(*problem.Problem); ok {
+		return p.Send(c)
+	}
+	// Loggear error
+	return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, internalMessage)
+}
+
+// respondSaved responde con la tarea guardada por PUT o PATCH, o con el error del servicio
+func (h *Handler) respondSaved(c *fiber.Ctx, task *models.Task, changed bool, err error) error {
+	if err != nil {
+		return respondWriteError(c, task, err, "Error interno al actualizar la tarea.")
+	}
+
+	message := "Tarea actualizada exitosamente."
+	if !changed {
+		message = "La tarea no tiene cambios."
+	}
+	c.Set(fiber.HeaderETag, taskETag(task))
 	return c.JSON(fiber.Map{
 		"status":  "success",
-		"message": "Tarea actualizada exitosamente.",
+		"message": message,
 		"data":    task,
 	})
 }
 
+// sameID compara dos IDs opcionales
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
 // Delete godoc
 // @Summary Eliminar una tarea
-// @Description Elimina una tarea existente por su ID. Solo el creador de la tarea puede eliminarla.
+// @Description Mueve una tarea y sus subtareas a la papelera (borrado lógico). Se pueden restaurar con POST /tasks/{id}/restore hasta que se purguen al vencer el período de retención. Solo el creador de la tarea (o un administrador) puede eliminarla. Requiere If-Match con el ETag vigente.
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param id path int true "ID numérico de la tarea a eliminar" Format(uint)
+// @Param If-Match header string true "ETag vigente de la tarea"
 // @Security Bearer
 // @Success 200 {object} models.SuccessResponse "Tarea eliminada exitosamente" // Usar una respuesta simple para eliminación
-// @Failure 400 {object} models.ErrorResponse "ID inválido"
-// @Failure 401 {object} models.ErrorResponse "No autorizado (token JWT faltante o inválido)"
-// @Failure 403 {object} models.ErrorResponse "Permiso denegado (solo el creador puede eliminar)"
-// @Failure 404 {object} models.ErrorResponse "Tarea no encontrada"
-// @Failure 500 {object} models.ErrorResponse "Error interno del servidor"
+// @Failure 400 {object} problem.Problem "ID inválido"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado (solo el creador o un administrador puede eliminar; los viewers no pueden escribir)"
+// @Failure 404 {object} problem.Problem "Tarea no encontrada"
+// @Failure 412 {object} problem.Problem "If-Match no coincide: la tarea fue modificada (se devuelve el ETag vigente)"
+// @Failure 428 {object} problem.Problem "Falta la cabecera If-Match"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
 // @Router /tasks/{id} [delete]
 func (h *Handler) Delete(c *fiber.Ctx) error {
-	taskID, err := strconv.ParseUint(c.Params("id"), 10, 32)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status":  "error",
-			"message": "ID de tarea inválido. Debe ser un número entero.",
-		})
+	task, userID, ok, err := h.loadTaskForWrite(c, "eliminar")
+	if !ok {
+		return err
 	}
 
-	userID, ok := c.Locals("user_id").(string)
-	if !ok || userID == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Usuario no autenticado.",
-		})
-	}
-
-	var task models.Task
-	// Buscar tarea y verificar que el usuario es el creador
-	if err := h.db.Where("id = ? AND creator_id = ?", taskID, userID).First(&task).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// Si no se encuentra O si no es el creador, retorna 403
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"status":  "error",
-				"message": "No tienes permiso para eliminar esta tarea.",
-			})
-		}
-		// Loggear error
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Error interno al obtener la tarea para eliminar.",
-		})
-	}
-
-	// Eliminar la tarea
-	if err := h.db.Delete(&task).Error; err != nil {
-		// Loggear error
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"status":  "error",
-			"message": "Error interno al eliminar la tarea.",
-		})
+	if err := h.service.Delete(c.UserContext(), task, userID); err != nil {
+		return respondWriteError(c, task, err, "Error interno al eliminar la tarea.")
 	}
 
 	// Definir una estructura simple para la respuesta de éxito si no hay datos que devolver
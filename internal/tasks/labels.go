@@ -0,0 +1,524 @@
+package tasks
+
+import (
+	"fmt"
+	"legendaryum/internal/middleware"
+	"legendaryum/internal/problem"
+	"legendaryum/internal/validation"
+	"legendaryum/pkg/models"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Etiquetas: personales (solo las usa su dueño) o de proyecto (las usan todos sus miembros).
+// Se asocian a tareas mediante la tabla task_labels y permiten filtrar el listado de tareas.
+
+const (
+	defaultLabelColor = "#808080"
+	maxBulkLabelTasks = 100
+)
+
+// usableLabels limita una consulta a las etiquetas que el usuario puede ver y aplicar:
+// las personales propias y las de los proyectos de los que es miembro (todas las de
+// proyecto para un administrador).
+func usableLabels(userID, role string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if models.HasPermission(role, models.PermTasksManageAll) {
+			return db.Where("labels.owner_id = ? OR labels.project_id IS NOT NULL", userID)
+		}
+		return db.Where(
+			"labels.owner_id = ? OR labels.project_id IN (SELECT project_id FROM project_members WHERE user_id = ?)",
+			userID, userID,
+		)
+	}
+}
+
+// labelFilter agrega al listado de tareas el filtro ?labels=a,b. Con mode "any" basta con que la
+// tarea tenga alguna de las etiquetas; con "all" debe tenerlas todas. Los nombres no distinguen
+// mayúsculas y solo se consideran las etiquetas que el usuario puede usar (ver usableLabels).
+func labelFilter(names []string, mode, userID, role string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		matching := db.Session(&gorm.Session{NewDB: true}).Table("task_labels").
+			Select("task_labels.task_id").
+			Joins("JOIN labels ON labels.id = task_labels.label_id").
+			Scopes(usableLabels(userID, role)).
+			Where("lower(labels.name) IN ?", names)
+		if mode == "all" {
+			matching = matching.Group("task_labels.task_id").
+				Having("COUNT(DISTINCT lower(labels.name)) = ?", len(names))
+		}
+		return db.Where("tasks.id IN (?)", matching)
+	}
+}
+
+// parseLabelQuery lee los parámetros labels y labels_mode del listado de tareas
+func parseLabelQuery(rawLabels, rawMode string) ([]string, string, error) {
+	mode := rawMode
+	if mode == "" {
+		mode = "any"
+	}
+	if mode != "any" && mode != "all" {
+		return nil, "", fmt.Errorf("labels_mode inválido: %q. Valores permitidos: any, all", rawMode)
+	}
+	if rawLabels == "" {
+		return nil, mode, nil
+	}
+	var names []string
+	seen := map[string]bool{}
+	for _, part := range strings.Split(rawLabels, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names, mode, nil
+}
+
+// canManageLabel indica si el usuario puede editar o eliminar la etiqueta: las personales solo su
+// dueño; las de proyecto quien la creó, los owners del proyecto o un administrador.
+func (h *Handler) canManageLabel(label *models.Label, userID, role string) (bool, error) {
+	if label.OwnerID != nil {
+		return *label.OwnerID == userID, nil
+	}
+	if label.CreatedBy == userID || models.HasPermission(role, models.PermTasksManageAll) {
+		return true, nil
+	}
+	var count int64
+	err := h.db.Model(&models.ProjectMember{}).
+		Where("project_id = ? AND user_id = ? AND role = ?", *label.ProjectID, userID, models.ProjectRoleOwner).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// labelNameTaken indica si ya existe otra etiqueta con el mismo nombre en el mismo ámbito
+func (h *Handler) labelNameTaken(label *models.Label) (bool, error) {
+	query := h.db.Model(&models.Label{}).Where("lower(name) = lower(?)", label.Name)
+	if label.OwnerID != nil {
+		query = query.Where("owner_id = ?", *label.OwnerID)
+	} else {
+		query = query.Where("project_id = ?", *label.ProjectID)
+	}
+	if label.ID != 0 {
+		query = query.Where("id <> ?", label.ID)
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// parseLabelBody lee y valida los datos de una etiqueta
+func parseLabelBody(c *fiber.Ctx) (models.LabelRequest, bool, error) {
+	var req models.LabelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return req, false, problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidJSON, "Error al procesar la solicitud: JSON inválido.")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validation.Struct(&req); err != nil {
+		return req, false, respondValidationError(c, fiber.StatusBadRequest, "Los datos de la etiqueta no son válidos", err)
+	}
+	// Las comas separan etiquetas en el filtro ?labels=
+	if strings.Contains(req.Name, ",") {
+		return req, false, respondValidationError(c, fiber.StatusBadRequest, "Los datos de la etiqueta no son válidos",
+			validation.Errors{{Field: "name", Error: "no puede contener comas"}})
+	}
+	if req.Color == "" {
+		req.Color = defaultLabelColor
+	}
+	return req, true, nil
+}
+
+// loadManageableLabel obtiene la etiqueta del parámetro :id y verifica que el usuario pueda
+// gestionarla. Si algo falla, ya escribe la respuesta de error y devuelve ok=false.
+func (h *Handler) loadManageableLabel(c *fiber.Ctx) (*models.Label, bool, error) {
+	labelID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return nil, false, problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, "ID de etiqueta inválido. Debe ser un número entero.")
+	}
+	userID, _ := c.Locals("user_id").(string)
+	if userID == "" {
+		return nil, false, problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
+	}
+	role := middleware.CurrentRole(c)
+
+	var label models.Label
+	if err := h.db.Scopes(usableLabels(userID, role)).Where("labels.id = ?", labelID).First(&label).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, false, problem.Respond(c, fiber.StatusNotFound, problem.CodeNotFound, "Etiqueta no encontrada.")
+		}
+		// Loggear error
+		return nil, false, problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener la etiqueta.")
+	}
+
+	allowed, err := h.canManageLabel(&label, userID, role)
+	if err != nil {
+		// Loggear error
+		return nil, false, problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al verificar permisos sobre la etiqueta.")
+	}
+	if !allowed {
+		return nil, false, problem.Respond(c, fiber.StatusForbidden, problem.CodeForbidden, "No tienes permiso para modificar esta etiqueta.")
+	}
+	return &label, true, nil
+}
+
+// ListLabels godoc
+// @Summary Listar etiquetas
+// @Description Obtiene las etiquetas que el usuario puede aplicar: las personales y las de sus proyectos, ordenadas por nombre.
+// @Tags labels
+// @Produce json
+// @Param project_id query int false "Solo las etiquetas de este proyecto"
+// @Security Bearer
+// @Success 200 {array} models.Label "Etiquetas disponibles"
+// @Failure 400 {object} problem.Problem "Parámetros inválidos"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /labels [get]
+func (h *Handler) ListLabels(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
+	}
+
+	query := h.db.Scopes(usableLabels(userID, middleware.CurrentRole(c)))
+	if raw := c.Query("project_id"); raw != "" {
+		projectID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, "project_id inválido. Debe ser un número entero.")
+		}
+		query = query.Where("labels.project_id = ?", projectID)
+	}
+
+	labels := []models.Label{}
+	if err := query.Order("lower(labels.name) ASC, labels.id ASC").Find(&labels).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener las etiquetas.")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Etiquetas obtenidas exitosamente.",
+		"data":    labels,
+	})
+}
+
+// CreateLabel godoc
+// @Summary Crear una etiqueta
+// @Description Crea una etiqueta personal o, si se indica project_id, una etiqueta del proyecto (el usuario debe ser miembro). El nombre es único dentro de su ámbito sin distinguir mayúsculas.
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param request body models.LabelRequest true "Datos de la etiqueta"
+// @Security Bearer
+// @Success 201 {object} models.Label "Etiqueta creada exitosamente"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada (nombre o color inválidos, proyecto inexistente o ajeno)"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 409 {object} problem.Problem "Ya existe una etiqueta con ese nombre"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /labels [post]
+func (h *Handler) CreateLabel(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
+	}
+	req, ok, err := parseLabelBody(c)
+	if !ok {
+		return err
+	}
+
+	label := models.Label{Name: req.Name, Color: req.Color, CreatedBy: userID}
+	if req.ProjectID != nil {
+		allowed, err := h.service.canUseProject(c.UserContext(), *req.ProjectID, userID, middleware.CurrentRole(c))
+		if err != nil {
+			// Loggear error
+			return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al verificar el proyecto.")
+		}
+		if !allowed {
+			return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidReference, fmt.Sprintf("El proyecto con ID %d no existe o no eres miembro.", *req.ProjectID))
+		}
+		label.ProjectID = req.ProjectID
+	} else {
+		label.OwnerID = &userID
+	}
+
+	taken, err := h.labelNameTaken(&label)
+	if err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al verificar la etiqueta.")
+	}
+	if taken {
+		return problem.Respond(c, fiber.StatusConflict, problem.CodeAlreadyExists, fmt.Sprintf("Ya existe una etiqueta llamada %q.", label.Name))
+	}
+
+	if err := h.db.Create(&label).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al crear la etiqueta.")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Etiqueta creada exitosamente.",
+		"data":    label,
+	})
+}
+
+// UpdateLabel godoc
+// @Summary Actualizar una etiqueta
+// @Description Cambia el nombre y el color de una etiqueta. Las personales solo las edita su dueño; las de proyecto quien las creó o un owner del proyecto.
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param id path int true "ID numérico de la etiqueta" Format(uint)
+// @Param request body models.LabelRequest true "Datos de la etiqueta (project_id se ignora)"
+// @Security Bearer
+// @Success 200 {object} models.Label "Etiqueta actualizada exitosamente"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado"
+// @Failure 404 {object} problem.Problem "Etiqueta no encontrada"
+// @Failure 409 {object} problem.Problem "Ya existe una etiqueta con ese nombre"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /labels/{id} [put]
+func (h *Handler) UpdateLabel(c *fiber.Ctx) error {
+	label, ok, err := h.loadManageableLabel(c)
+	if !ok {
+		return err
+	}
+	req, ok, err := parseLabelBody(c)
+	if !ok {
+		return err
+	}
+
+	label.Name = req.Name
+	label.Color = req.Color
+	taken, err := h.labelNameTaken(label)
+	if err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al verificar la etiqueta.")
+	}
+	if taken {
+		return problem.Respond(c, fiber.StatusConflict, problem.CodeAlreadyExists, fmt.Sprintf("Ya existe una etiqueta llamada %q.", label.Name))
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(label).Updates(map[string]interface{}{
+			"name":  label.Name,
+			"color": label.Color,
+		}).Error; err != nil {
+			return err
+		}
+		return touchTasks(tx, "id IN (SELECT task_id FROM task_labels WHERE label_id = ?)", label.ID)
+	}); err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al actualizar la etiqueta.")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Etiqueta actualizada exitosamente.",
+		"data":    label,
+	})
+}
+
+// DeleteLabel godoc
+// @Summary Eliminar una etiqueta
+// @Description Elimina una etiqueta y la quita de todas las tareas. Mismos permisos que para editarla.
+// @Tags labels
+// @Produce json
+// @Param id path int true "ID numérico de la etiqueta" Format(uint)
+// @Security Bearer
+// @Success 200 {object} models.SuccessResponse "Etiqueta eliminada exitosamente"
+// @Failure 400 {object} problem.Problem "ID inválido"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado"
+// @Failure 404 {object} problem.Problem "Etiqueta no encontrada"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /labels/{id} [delete]
+func (h *Handler) DeleteLabel(c *fiber.Ctx) error {
+	label, ok, err := h.loadManageableLabel(c)
+	if !ok {
+		return err
+	}
+
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := touchTasks(tx, "id IN (SELECT task_id FROM task_labels WHERE label_id = ?)", label.ID); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM task_labels WHERE label_id = ?", label.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(label).Error
+	}); err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al eliminar la etiqueta.")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Etiqueta eliminada exitosamente.",
+	})
+}
+
+// BulkApplyLabels godoc
+// @Summary Aplicar etiquetas a varias tareas
+// @Description Agrega y/o quita etiquetas de varias tareas (hasta 100) en una sola transacción. Todas las tareas deben ser visibles para el usuario y todas las etiquetas utilizables por él. Las etiquetas de proyecto solo pueden agregarse a tareas de ese proyecto.
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param request body models.BulkLabelRequest true "Tareas y etiquetas a agregar/quitar"
+// @Security Bearer
+// @Success 200 {object} models.BulkLabelResponse "Etiquetas aplicadas"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada (etiqueta inexistente, etiqueta de otro proyecto)"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Alguna tarea es visible pero el usuario no puede modificarla"
+// @Failure 404 {object} problem.Problem "Alguna tarea no existe o no es visible"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /labels/bulk [post]
+func (h *Handler) BulkApplyLabels(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
+	}
+	role := middleware.CurrentRole(c)
+
+	var req models.BulkLabelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidJSON, "Error al procesar la solicitud: JSON inválido.")
+	}
+	taskIDs := uniqueIDs(req.TaskIDs)
+	if len(taskIDs) == 0 || len(taskIDs) > maxBulkLabelTasks {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeBadRequest, fmt.Sprintf("El campo 'task_ids' debe tener entre 1 y %d tareas.", maxBulkLabelTasks))
+	}
+	add, remove := uniqueIDs(req.Add), uniqueIDs(req.Remove)
+	if len(add) == 0 && len(remove) == 0 {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeBadRequest, "Debe indicarse al menos una etiqueta en 'add' o 'remove'.")
+	}
+
+	// Todas las tareas deben ser visibles
+	var tasks []models.Task
+	if err := h.db.Scopes(visibleTasks(userID, role)).Where("tasks.id IN ?", taskIDs).
+		Select("tasks.id", "tasks.project_id").Find(&tasks).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener las tareas.")
+	}
+	if len(tasks) != len(taskIDs) {
+		found := make(map[uint]bool, len(tasks))
+		for _, task := range tasks {
+			found[task.ID] = true
+		}
+		missing := []uint{}
+		for _, id := range taskIDs {
+			if !found[id] {
+				missing = append(missing, id)
+			}
+		}
+		return problem.New(fiber.StatusNotFound, problem.CodeNotFound, "Alguna tarea no existe o no tienes permiso para verla.").
+			With("missing_tasks", missing).
+			Send(c)
+	}
+
+	// Y modificables por el usuario: cambiar etiquetas requiere el mismo permiso que PUT /tasks/{id}
+	var manageable []uint
+	if err := h.db.Model(&models.Task{}).Scopes(manageableTasks(userID, role)).Where("tasks.id IN ?", taskIDs).
+		Pluck("tasks.id", &manageable).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener las tareas.")
+	}
+	if len(manageable) != len(taskIDs) {
+		allowed := make(map[uint]bool, len(manageable))
+		for _, id := range manageable {
+			allowed[id] = true
+		}
+		forbidden := []uint{}
+		for _, id := range taskIDs {
+			if !allowed[id] {
+				forbidden = append(forbidden, id)
+			}
+		}
+		return problem.New(fiber.StatusForbidden, problem.CodeForbidden, "No tienes permiso para modificar alguna de las tareas.").
+			With("forbidden_tasks", forbidden).
+			Send(c)
+	}
+
+	// Todas las etiquetas deben ser utilizables; las de proyecto solo van a tareas de ese proyecto
+	labelIDs := uniqueIDs(append(append([]uint{}, add...), remove...))
+	var labels []models.Label
+	if err := h.db.Scopes(usableLabels(userID, role)).Where("labels.id IN ?", labelIDs).Find(&labels).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener las etiquetas.")
+	}
+	if len(labels) != len(labelIDs) {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidReference, "Alguna etiqueta no existe o no puedes usarla.")
+	}
+	byID := make(map[uint]models.Label, len(labels))
+	for _, label := range labels {
+		byID[label.ID] = label
+	}
+	for _, labelID := range add {
+		label := byID[labelID]
+		if label.ProjectID == nil {
+			continue
+		}
+		for _, task := range tasks {
+			if task.ProjectID == nil || *task.ProjectID != *label.ProjectID {
+				return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidReference, fmt.Sprintf("La etiqueta %q es del proyecto %d y la tarea %d no pertenece a él.", label.Name, *label.ProjectID, task.ID))
+			}
+		}
+	}
+
+	result := models.BulkLabelResponse{Tasks: len(taskIDs)}
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if len(remove) > 0 {
+			res := tx.Exec("DELETE FROM task_labels WHERE task_id IN ? AND label_id IN ?", taskIDs, remove)
+			if res.Error != nil {
+				return res.Error
+			}
+			result.Removed = res.RowsAffected
+		}
+		if len(add) > 0 {
+			rows := make([]map[string]interface{}, 0, len(taskIDs)*len(add))
+			for _, taskID := range taskIDs {
+				for _, labelID := range add {
+					rows = append(rows, map[string]interface{}{"task_id": taskID, "label_id": labelID})
+				}
+			}
+			// Las asociaciones existentes se ignoran
+			res := tx.Table("task_labels").Clauses(clause.OnConflict{DoNothing: true}).Create(rows)
+			if res.Error != nil {
+				return res.Error
+			}
+			result.Added = res.RowsAffected
+		}
+		if result.Added+result.Removed == 0 {
+			return nil
+		}
+		return touchTasks(tx, "id IN ?", taskIDs)
+	}); err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al aplicar las etiquetas.")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Etiquetas aplicadas exitosamente.",
+		"data":    result,
+	})
+}
+
+// uniqueIDs devuelve los IDs sin repetidos ni ceros, conservando el orden
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
@@ -0,0 +1,233 @@
+package tasks
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"legendaryum/pkg/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Paginación por cursor y ordenamiento del listado de tareas
+
+const (
+	defaultPageLimit  = 20
+	maxPageLimit      = 100
+	defaultTaskSort   = "-created_at"
+	defaultSearchSort = "-rank" // Con ?q= los resultados más relevantes primero
+)
+
+// taskSortColumns define los campos por los que se permite ordenar y su columna en la tabla
+var taskSortColumns = map[string]string{
+	"id":         "tasks.id",
+	"title":      "tasks.title",
+	"status":     "tasks.status",
+	"priority":   "tasks.priority",
+	"due_date":   "tasks.due_date",
+	"created_at": "tasks.created_at",
+	"updated_at": "tasks.updated_at",
+	"rank":       "tasks.rank", // Solo disponible con ?q= (ver searchTasks)
+}
+
+// sortField representa un criterio de ordenamiento ya validado
+type sortField struct {
+	Name string
+	Desc bool
+}
+
+// pageCursor es el contenido del cursor opaco que recibe el cliente.
+// Guarda el orden con el que se generó y los valores de la última fila devuelta.
+type pageCursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+// parseLimit valida el parámetro limit
+func parseLimit(raw string) (int, error) {
+	if raw == "" {
+		return defaultPageLimit, nil
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		return 0, fmt.Errorf("El parámetro 'limit' debe ser un entero entre 1 y %d.", maxPageLimit)
+	}
+	return limit, nil
+}
+
+// parseSort valida el parámetro sort (ej: "due_date,-priority,created_at").
+// Siempre agrega el id como último criterio para que el orden sea estable.
+// El campo rank solo se admite en búsquedas de texto (searching).
+func parseSort(raw string, searching bool) ([]sortField, error) {
+	if strings.TrimSpace(raw) == "" {
+		raw = defaultTaskSort
+		if searching {
+			raw = defaultSearchSort
+		}
+	}
+
+	var fields []sortField
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		desc := strings.HasPrefix(part, "-")
+		name := strings.TrimPrefix(part, "-")
+		if _, ok := taskSortColumns[name]; !ok {
+			return nil, fmt.Errorf("Campo de ordenamiento inválido: '%s'.", name)
+		}
+		if name == "rank" && !searching {
+			return nil, errors.New("El orden por 'rank' solo está disponible junto con el parámetro 'q'.")
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("Campo de ordenamiento repetido: '%s'.", name)
+		}
+		seen[name] = true
+		fields = append(fields, sortField{Name: name, Desc: desc})
+	}
+
+	if !seen["id"] {
+		fields = append(fields, sortField{Name: "id"})
+	}
+	return fields, nil
+}
+
+// sortKey devuelve la representación canónica del orden, usada para validar cursores
+func sortKey(fields []sortField) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		if f.Desc {
+			parts[i] = "-" + f.Name
+		} else {
+			parts[i] = f.Name
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// orderClause construye la cláusula ORDER BY para los campos indicados
+func orderClause(fields []sortField) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		dir := "ASC"
+		if f.Desc {
+			dir = "DESC"
+		}
+		parts[i] = taskSortColumns[f.Name] + " " + dir
+	}
+	return strings.Join(parts, ", ")
+}
+
+// encodeCursor genera el cursor que apunta a la fila siguiente a la tarea indicada
+func encodeCursor(fields []sortField, task models.Task) string {
+	values := make([]string, len(fields))
+	for i, f := range fields {
+		values[i] = sortValue(f.Name, task)
+	}
+	raw, _ := json.Marshal(pageCursor{Sort: sortKey(fields), Values: values})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor valida un cursor recibido y devuelve los valores tipados para la consulta
+func decodeCursor(raw string, fields []sortField) ([]interface{}, error) {
+	invalid := errors.New("El cursor es inválido o no corresponde al orden solicitado.")
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, invalid
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, invalid
+	}
+	if cursor.Sort != sortKey(fields) || len(cursor.Values) != len(fields) {
+		return nil, invalid
+	}
+
+	values := make([]interface{}, len(fields))
+	for i, f := range fields {
+		value, err := parseSortValue(f.Name, cursor.Values[i])
+		if err != nil {
+			return nil, invalid
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// keysetCondition arma la condición "fila posterior al cursor" respetando la dirección de cada campo:
+// (a > ?) OR (a = ? AND b < ?) OR (a = ? AND b = ? AND id > ?) ...
+func keysetCondition(fields []sortField, values []interface{}) (string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+	for i, f := range fields {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, taskSortColumns[fields[j].Name]+" = ?")
+			args = append(args, values[j])
+		}
+		op := ">"
+		if f.Desc {
+			op = "<"
+		}
+		parts = append(parts, taskSortColumns[f.Name]+" "+op+" ?")
+		args = append(args, values[i])
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+	return strings.Join(clauses, " OR "), args
+}
+
+// sortValue obtiene el valor de un campo de ordenamiento como string
+func sortValue(name string, task models.Task) string {
+	switch name {
+	case "id":
+		return strconv.FormatUint(uint64(task.ID), 10)
+	case "title":
+		return task.Title
+	case "status":
+		return task.Status
+	case "priority":
+		return task.Priority
+	case "due_date":
+		return task.DueDate.UTC().Format(time.RFC3339Nano)
+	case "created_at":
+		return task.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "updated_at":
+		return task.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case "rank":
+		if task.Rank == nil {
+			return "0"
+		}
+		return strconv.FormatFloat(*task.Rank, 'g', -1, 64)
+	}
+	return ""
+}
+
+// parseSortValue convierte el valor guardado en el cursor al tipo de la columna
+func parseSortValue(name, value string) (interface{}, error) {
+	switch name {
+	case "id":
+		return strconv.ParseUint(value, 10, 32)
+	case "due_date", "created_at", "updated_at":
+		return time.Parse(time.RFC3339Nano, value)
+	case "rank":
+		return strconv.ParseFloat(value, 64)
+	}
+	return value, nil
+}
+
+// encodeIDCursor genera un cursor opaco para listados ordenados solo por id (ej: comentarios)
+func encodeIDCursor(id uint) string {
+	raw, _ := json.Marshal(pageCursor{Sort: "id", Values: []string{strconv.FormatUint(uint64(id), 10)}})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeIDCursor valida un cursor generado por encodeIDCursor y devuelve el último id visto
+func decodeIDCursor(raw string) (uint, error) {
+	values, err := decodeCursor(raw, []sortField{{Name: "id"}})
+	if err != nil {
+		return 0, err
+	}
+	return uint(values[0].(uint64)), nil
+}
@@ -0,0 +1,252 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"legendaryum/internal/problem"
+	"legendaryum/internal/validation"
+	"legendaryum/pkg/models"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Documento editable de una tarea. PUT lo reemplaza completo y PATCH le aplica un JSON Merge
+// Patch (RFC 7396) o un JSON Patch (RFC 6902). El resultado se valida contra el mismo esquema
+// antes de guardarse.
+
+const (
+	mimeMergePatch = "application/merge-patch+json"
+	mimeJSONPatch  = "application/json-patch+json"
+)
+
+// taskDocument son los campos editables de una tarea, tal como se exponen en JSON
+type taskDocument struct {
+	Title       string    `json:"title" validate:"required,max=200"`
+	Description string    `json:"description"`
+	Status      string    `json:"status" validate:"required,oneof=pending in_progress complete"`
+	Priority    string    `json:"priority" validate:"required,oneof=low medium high"`
+	DueDate     time.Time `json:"due_date" validate:"required"`
+	AssigneeID  string    `json:"assignee_id" validate:"required,uuid"`
+	ProjectID   *uint     `json:"project_id" validate:"omitempty,min=1"` // null: sin proyecto
+	ParentID    *uint     `json:"parent_id" validate:"omitempty,min=1"`  // null: tarea de primer nivel
+}
+
+// documentFromTask devuelve el documento editable con los valores actuales de la tarea
+func documentFromTask(task *models.Task) taskDocument {
+	return taskDocument{
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		Priority:    task.Priority,
+		DueDate:     task.DueDate,
+		AssigneeID:  task.AssigneeID,
+		ProjectID:   task.ProjectID,
+		ParentID:    task.ParentID,
+	}
+}
+
+// replacementDocument interpreta el cuerpo de un PUT como la tarea completa. Los campos omitidos
+// toman los mismos valores por defecto que al crear la tarea.
+func replacementDocument(req models.TaskRequest, creatorID string) taskDocument {
+	doc := taskDocument{
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      req.Status,
+		Priority:    req.Priority,
+		DueDate:     req.DueDate,
+		AssigneeID:  req.AssigneeID,
+		ProjectID:   req.ProjectID,
+		ParentID:    req.ParentID,
+	}
+	if doc.Status == "" {
+		doc.Status = "pending"
+	}
+	if doc.Priority == "" {
+		doc.Priority = "medium"
+	}
+	if doc.AssigneeID == "" {
+		doc.AssigneeID = creatorID
+	}
+	// Un ID 0 equivale a no tener proyecto o tarea padre
+	if doc.ProjectID != nil && *doc.ProjectID == 0 {
+		doc.ProjectID = nil
+	}
+	if doc.ParentID != nil && *doc.ParentID == 0 {
+		doc.ParentID = nil
+	}
+	return doc
+}
+
+// validate verifica el documento contra el esquema de la tarea (tags validate). Devuelve
+// validation.Errors con los campos inválidos.
+func (d taskDocument) validate() error {
+	return validation.Struct(d)
+}
+
+// Errores de un patch según la respuesta HTTP que corresponde: 400 si está mal formado, 409 si no
+// se puede aplicar a la tarea actual y 422 si el resultado no es válido
+
+func malformedPatch(format string, args ...interface{}) error {
+	return problem.New(fiber.StatusBadRequest, problem.CodeInvalidPatch, fmt.Sprintf(format, args...))
+}
+
+func conflictingPatch(format string, args ...interface{}) error {
+	return problem.New(fiber.StatusConflict, problem.CodeConflict, fmt.Sprintf(format, args...))
+}
+
+func invalidPatchResult(format string, args ...interface{}) error {
+	return problem.New(fiber.StatusUnprocessableEntity, problem.CodeValidationFailed, fmt.Sprintf(format, args...))
+}
+
+// applyPatch aplica el patch al documento según su tipo de contenido y devuelve el documento resultante
+func applyPatch(doc taskDocument, contentType string, patch []byte) (taskDocument, error) {
+	raw, _ := json.Marshal(doc)
+	var target interface{}
+	_ = json.Unmarshal(raw, &target)
+
+	var result interface{}
+	switch contentType {
+	case mimeMergePatch:
+		var p interface{}
+		if err := json.Unmarshal(patch, &p); err != nil {
+			return doc, malformedPatch("El merge patch no es un JSON válido.")
+		}
+		if _, ok := p.(map[string]interface{}); !ok {
+			return doc, malformedPatch("El merge patch debe ser un objeto JSON.")
+		}
+		result = mergePatch(target, p)
+	case mimeJSONPatch:
+		var err error
+		if result, err = applyJSONPatch(target.(map[string]interface{}), patch); err != nil {
+			return doc, err
+		}
+	default:
+		return doc, errors.New("tipo de contenido no soportado")
+	}
+
+	patched, _ := json.Marshal(result)
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	var out taskDocument
+	if err := decoder.Decode(&out); err != nil {
+		return doc, invalidPatchResult("La tarea resultante no es válida: %s.", err.Error())
+	}
+	// Los campos eliminados quedan con su valor vacío y los valida validate
+	return out, nil
+}
+
+// mergePatch implementa el algoritmo de RFC 7396: los null eliminan claves y los objetos se combinan recursivamente
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// jsonPatchOperation es una operación de RFC 6902
+type jsonPatchOperation struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// applyJSONPatch aplica las operaciones en orden sobre una copia del documento. Si alguna
+// falla no se aplica ninguna. El documento de una tarea es plano, así que los paths tienen un solo nivel.
+func applyJSONPatch(doc map[string]interface{}, patch []byte) (map[string]interface{}, error) {
+	var ops []jsonPatchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, malformedPatch("El JSON patch debe ser un arreglo de operaciones.")
+	}
+
+	result := make(map[string]interface{}, len(doc))
+	for key, value := range doc {
+		result[key] = value
+	}
+
+	for i, op := range ops {
+		if op.Path == nil {
+			return nil, malformedPatch("Operación %d: falta 'path'.", i)
+		}
+		key, err := pointerKey(*op.Path)
+		if err != nil {
+			return nil, malformedPatch("Operación %d: %s", i, err.Error())
+		}
+		var value interface{}
+		if op.Op == "add" || op.Op == "replace" || op.Op == "test" {
+			if op.Value == nil {
+				return nil, malformedPatch("Operación %d (%s): falta 'value'.", i, op.Op)
+			}
+			if err := json.Unmarshal(*op.Value, &value); err != nil {
+				return nil, malformedPatch("Operación %d (%s): 'value' no es un JSON válido.", i, op.Op)
+			}
+		}
+		var fromKey string
+		if op.Op == "move" || op.Op == "copy" {
+			if op.From == nil {
+				return nil, malformedPatch("Operación %d (%s): falta 'from'.", i, op.Op)
+			}
+			if fromKey, err = pointerKey(*op.From); err != nil {
+				return nil, malformedPatch("Operación %d: %s", i, err.Error())
+			}
+			if _, ok := result[fromKey]; !ok {
+				return nil, conflictingPatch("Operación %d (%s): el campo '%s' no existe.", i, op.Op, fromKey)
+			}
+		}
+		_, exists := result[key]
+
+		switch op.Op {
+		case "add":
+			result[key] = value
+		case "replace":
+			if !exists {
+				return nil, conflictingPatch("Operación %d (replace): el campo '%s' no existe.", i, key)
+			}
+			result[key] = value
+		case "remove":
+			if !exists {
+				return nil, conflictingPatch("Operación %d (remove): el campo '%s' no existe.", i, key)
+			}
+			delete(result, key)
+		case "move":
+			value = result[fromKey]
+			delete(result, fromKey)
+			result[key] = value
+		case "copy":
+			result[key] = result[fromKey]
+		case "test":
+			if !exists || !reflect.DeepEqual(result[key], value) {
+				return nil, conflictingPatch("Operación %d (test): el campo '%s' no tiene el valor esperado.", i, key)
+			}
+		default:
+			return nil, malformedPatch("Operación %d: 'op' inválido %q. Valores permitidos: add, remove, replace, move, copy, test.", i, op.Op)
+		}
+	}
+	return result, nil
+}
+
+// pointerKey convierte un JSON Pointer de un nivel ("/title") en el nombre del campo
+func pointerKey(pointer string) (string, error) {
+	if !strings.HasPrefix(pointer, "/") || strings.Count(pointer, "/") != 1 || pointer == "/" {
+		return "", fmt.Errorf("path inválido %q: debe apuntar a un campo de la tarea, por ejemplo '/title'.", pointer)
+	}
+	key := strings.TrimPrefix(pointer, "/")
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(key), nil
+}
@@ -0,0 +1,512 @@
+package tasks
+
+import (
+	"fmt"
+	"legendaryum/internal/middleware"
+	"legendaryum/internal/problem"
+	"legendaryum/internal/validation"
+	"legendaryum/pkg/models"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Proyectos: agrupan tareas y miembros. Los miembros de un proyecto ven todas sus tareas
+// (ver visibleTasks). Solo los owners del proyecto (o un administrador) lo gestionan.
+
+// projectAccess describe el proyecto cargado y lo que el usuario autenticado puede hacer con él
+type projectAccess struct {
+	project   *models.Project
+	userID    string
+	canManage bool
+}
+
+// loadProject obtiene y valida el ID del proyecto y el usuario autenticado, y verifica que el
+// usuario sea miembro del proyecto (o administrador). Si algo falla, ya escribe la respuesta de
+// error y devuelve ok=false.
+func (h *Handler) loadProject(c *fiber.Ctx) (access projectAccess, ok bool, err error) {
+	id, parseErr := strconv.ParseUint(c.Params("id"), 10, 32)
+	if parseErr != nil {
+		return access, false, problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, "ID de proyecto inválido. Debe ser un número entero.")
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	if userID == "" {
+		return access, false, problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
+	}
+
+	var project models.Project
+	if findErr := h.db.First(&project, id).Error; findErr != nil {
+		if findErr == gorm.ErrRecordNotFound {
+			return access, false, projectNotFound(c)
+		}
+		// Loggear error
+		return access, false, problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener el proyecto.")
+	}
+
+	isAdmin := models.HasPermission(middleware.CurrentRole(c), models.PermTasksManageAll)
+	var membership models.ProjectMember
+	memberErr := h.db.Where("project_id = ? AND user_id = ?", project.ID, userID).First(&membership).Error
+	if memberErr != nil && memberErr != gorm.ErrRecordNotFound {
+		// Loggear error
+		return access, false, problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al verificar la membresía del proyecto.")
+	}
+	isMember := memberErr == nil
+	if !isMember && !isAdmin {
+		// No revelar la existencia de proyectos ajenos
+		return access, false, projectNotFound(c)
+	}
+
+	return projectAccess{
+		project:   &project,
+		userID:    userID,
+		canManage: isAdmin || membership.Role == models.ProjectRoleOwner,
+	}, true, nil
+}
+
+func projectNotFound(c *fiber.Ctx) error {
+	return problem.Respond(c, fiber.StatusNotFound, problem.CodeNotFound, "Proyecto no encontrado o no eres miembro.")
+}
+
+func projectForbidden(c *fiber.Ctx) error {
+	return problem.Respond(c, fiber.StatusForbidden, problem.CodeForbidden, "Solo un owner del proyecto puede gestionarlo.")
+}
+
+// parseProjectBody lee y valida los datos de un proyecto
+func parseProjectBody(c *fiber.Ctx) (models.ProjectRequest, bool, error) {
+	var req models.ProjectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return req, false, problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidJSON, "Error al procesar la solicitud: JSON inválido.")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validation.Struct(&req); err != nil {
+		return req, false, respondValidationError(c, fiber.StatusBadRequest, "Los datos del proyecto no son válidos", err)
+	}
+	return req, true, nil
+}
+
+// CreateProject godoc
+// @Summary Crear un proyecto
+// @Description Crea un proyecto. El usuario autenticado queda como owner y primer miembro.
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param request body models.ProjectRequest true "Datos del proyecto"
+// @Security Bearer
+// @Success 201 {object} models.Project "Proyecto creado exitosamente"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado (los viewers no pueden crear proyectos)"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /projects [post]
+func (h *Handler) CreateProject(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
+	}
+	req, ok, err := parseProjectBody(c)
+	if !ok {
+		return err
+	}
+
+	project := models.Project{
+		Name:        req.Name,
+		Description: req.Description,
+		OwnerID:     userID,
+	}
+	// Crear el proyecto y la membresía del owner en la misma transacción
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&project).Error; err != nil {
+			return err
+		}
+		owner := models.ProjectMember{ProjectID: project.ID, UserID: userID, Role: models.ProjectRoleOwner}
+		if err := tx.Create(&owner).Error; err != nil {
+			return err
+		}
+		project.Members = []models.ProjectMember{owner}
+		return nil
+	}); err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al crear el proyecto.")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Proyecto creado exitosamente.",
+		"data":    project,
+	})
+}
+
+// ListProjects godoc
+// @Summary Listar proyectos
+// @Description Obtiene los proyectos de los que el usuario autenticado es miembro (todos para un administrador), paginados por cursor.
+// @Tags projects
+// @Produce json
+// @Param limit query int false "Cantidad máxima de proyectos por página (1-100, por defecto 20)"
+// @Param cursor query string false "Cursor opaco devuelto en meta.next_cursor por la página anterior"
+// @Security Bearer
+// @Success 200 {object} models.ProjectListResponse "Página de proyectos"
+// @Failure 400 {object} problem.Problem "Parámetros de paginación inválidos"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /projects [get]
+func (h *Handler) ListProjects(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
+	}
+
+	limit, err := parseLimit(c.Query("limit"))
+	if err != nil {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+	}
+
+	query := h.db.Model(&models.Project{})
+	if !models.HasPermission(middleware.CurrentRole(c), models.PermTasksManageAll) {
+		query = query.Where("projects.id IN (SELECT project_id FROM project_members WHERE user_id = ?)", userID)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al contar los proyectos.")
+	}
+
+	page := query
+	if cursor := c.Query("cursor"); cursor != "" {
+		afterID, err := decodeIDCursor(cursor)
+		if err != nil {
+			return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+		}
+		page = page.Where("projects.id > ?", afterID)
+	}
+
+	var projects []models.Project
+	if err := page.Order("projects.id ASC").Limit(limit + 1).Find(&projects).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener los proyectos.")
+	}
+
+	var nextCursor *string
+	if len(projects) > limit {
+		projects = projects[:limit]
+		cursor := encodeIDCursor(projects[len(projects)-1].ID)
+		nextCursor = &cursor
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Proyectos obtenidos exitosamente.",
+		"data":    projects,
+		"meta": models.PageMeta{
+			Total:      total,
+			Limit:      limit,
+			Sort:       "id",
+			NextCursor: nextCursor,
+		},
+	})
+}
+
+// GetProject godoc
+// @Summary Obtener un proyecto
+// @Description Obtiene un proyecto con sus miembros. Solo para miembros del proyecto o administradores.
+// @Tags projects
+// @Produce json
+// @Param id path int true "ID numérico del proyecto" Format(uint)
+// @Security Bearer
+// @Success 200 {object} models.Project "Proyecto con sus miembros"
+// @Failure 400 {object} problem.Problem "ID inválido"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 404 {object} problem.Problem "Proyecto no encontrado"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /projects/{id} [get]
+func (h *Handler) GetProject(c *fiber.Ctx) error {
+	access, ok, err := h.loadProject(c)
+	if !ok {
+		return err
+	}
+
+	if err := h.db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).Preload("Members.User").First(access.project, access.project.ID).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener los miembros del proyecto.")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Proyecto obtenido exitosamente.",
+		"data":    access.project,
+	})
+}
+
+// UpdateProject godoc
+// @Summary Actualizar un proyecto
+// @Description Actualiza el nombre y la descripción de un proyecto. Solo para owners del proyecto o administradores.
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path int true "ID numérico del proyecto" Format(uint)
+// @Param request body models.ProjectRequest true "Datos del proyecto"
+// @Security Bearer
+// @Success 200 {object} models.Project "Proyecto actualizado exitosamente"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado (solo un owner puede gestionar el proyecto)"
+// @Failure 404 {object} problem.Problem "Proyecto no encontrado"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /projects/{id} [put]
+func (h *Handler) UpdateProject(c *fiber.Ctx) error {
+	access, ok, err := h.loadProject(c)
+	if !ok {
+		return err
+	}
+	if !access.canManage {
+		return projectForbidden(c)
+	}
+	req, ok, err := parseProjectBody(c)
+	if !ok {
+		return err
+	}
+
+	if err := h.db.Model(access.project).Updates(map[string]interface{}{
+		"name":        req.Name,
+		"description": req.Description,
+	}).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al actualizar el proyecto.")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Proyecto actualizado exitosamente.",
+		"data":    access.project,
+	})
+}
+
+// DeleteProject godoc
+// @Summary Eliminar un proyecto
+// @Description Elimina un proyecto, sus membresías y sus etiquetas. Sus tareas no se eliminan: quedan sin proyecto. Solo para owners del proyecto o administradores.
+// @Tags projects
+// @Produce json
+// @Param id path int true "ID numérico del proyecto" Format(uint)
+// @Security Bearer
+// @Success 200 {object} models.SuccessResponse "Proyecto eliminado exitosamente"
+// @Failure 400 {object} problem.Problem "ID inválido"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado (solo un owner puede gestionar el proyecto)"
+// @Failure 404 {object} problem.Problem "Proyecto no encontrado"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /projects/{id} [delete]
+func (h *Handler) DeleteProject(c *fiber.Ctx) error {
+	access, ok, err := h.loadProject(c)
+	if !ok {
+		return err
+	}
+	if !access.canManage {
+		return projectForbidden(c)
+	}
+
+	// Las claves foráneas ya desvinculan tareas y borran membresías y etiquetas; se hace explícito
+	// para no depender de ellas cuando el esquema se crea con AutoMigrate.
+	userID, _ := c.Locals("user_id").(string)
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Incluye las tareas en la papelera, para que al restaurarlas no apunten a un proyecto inexistente
+		var projectTasks []models.Task
+		if err := tx.Unscoped().Where("project_id = ?", access.project.ID).Find(&projectTasks).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Task{}).Where("project_id = ?", access.project.ID).
+			Updates(map[string]interface{}{"project_id": nil, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+		// Cada tarea registra en su historial que quedó sin proyecto
+		for _, task := range projectTasks {
+			before := taskSnapshot(task)
+			task.ProjectID = nil
+			if err := recordTaskEvent(tx, task.ID, userID, models.TaskEventUpdated, diffSnapshots(before, taskSnapshot(task))); err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("project_id = ?", access.project.ID).Delete(&models.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM task_labels WHERE label_id IN (SELECT id FROM labels WHERE project_id = ?)", access.project.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", access.project.ID).Delete(&models.Label{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(access.project).Error
+	}); err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al eliminar el proyecto.")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Proyecto eliminado exitosamente.",
+	})
+}
+
+// AddProjectMember godoc
+// @Summary Agregar un miembro a un proyecto
+// @Description Agrega un usuario al proyecto con rol owner o member (por defecto member). Si ya era miembro, se actualiza su rol. Solo para owners del proyecto o administradores.
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param id path int true "ID numérico del proyecto" Format(uint)
+// @Param request body models.ProjectMemberRequest true "Usuario y rol"
+// @Security Bearer
+// @Success 201 {object} models.ProjectMember "Miembro agregado exitosamente"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada (usuario inexistente, rol inválido)"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado (solo un owner puede gestionar el proyecto)"
+// @Failure 404 {object} problem.Problem "Proyecto no encontrado"
+// @Failure 409 {object} problem.Problem "El proyecto debe conservar al menos un owner"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /projects/{id}/members [post]
+func (h *Handler) AddProjectMember(c *fiber.Ctx) error {
+	access, ok, err := h.loadProject(c)
+	if !ok {
+		return err
+	}
+	if !access.canManage {
+		return projectForbidden(c)
+	}
+
+	var req models.ProjectMemberRequest
+	if err := c.BodyParser(&req); err != nil {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidJSON, "Error al procesar la solicitud: JSON inválido.")
+	}
+	if err := validation.Struct(&req); err != nil {
+		return respondValidationError(c, fiber.StatusBadRequest, "Los datos del miembro no son válidos", err)
+	}
+	if req.Role == "" {
+		req.Role = models.ProjectRoleMember
+	}
+
+	var user models.User
+	if err := h.db.First(&user, "id = ?", req.UserID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidReference, fmt.Sprintf("El usuario con ID %s no existe.", req.UserID))
+		}
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al verificar el usuario.")
+	}
+
+	member := models.ProjectMember{ProjectID: access.project.ID, UserID: user.ID, Role: req.Role}
+	var lastOwner bool
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.ProjectMember
+		err := tx.Where("project_id = ? AND user_id = ?", member.ProjectID, member.UserID).First(&existing).Error
+		if err == gorm.ErrRecordNotFound {
+			return tx.Create(&member).Error
+		}
+		if err != nil {
+			return err
+		}
+		if existing.Role == models.ProjectRoleOwner && member.Role != models.ProjectRoleOwner {
+			if lastOwner, err = isLastOwner(tx, member.ProjectID); err != nil || lastOwner {
+				return err
+			}
+		}
+		member.CreatedAt = existing.CreatedAt
+		return tx.Model(&existing).Update("role", member.Role).Error
+	}); err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al agregar el miembro.")
+	}
+	if lastOwner {
+		return problem.Respond(c, fiber.StatusConflict, problem.CodeConflict, "El proyecto debe conservar al menos un owner.")
+	}
+
+	member.User = &user
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Miembro agregado exitosamente.",
+		"data":    member,
+	})
+}
+
+// RemoveProjectMember godoc
+// @Summary Quitar un miembro de un proyecto
+// @Description Quita a un usuario del proyecto. Los owners (o administradores) pueden quitar a cualquiera; un miembro puede quitarse a sí mismo. El proyecto debe conservar al menos un owner.
+// @Tags projects
+// @Produce json
+// @Param id path int true "ID numérico del proyecto" Format(uint)
+// @Param userId path string true "ID (UUID) del usuario"
+// @Security Bearer
+// @Success 200 {object} models.SuccessResponse "Miembro quitado exitosamente"
+// @Failure 400 {object} problem.Problem "ID inválido"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado"
+// @Failure 404 {object} problem.Problem "Proyecto o miembro no encontrado"
+// @Failure 409 {object} problem.Problem "El proyecto debe conservar al menos un owner"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /projects/{id}/members/{userId} [delete]
+func (h *Handler) RemoveProjectMember(c *fiber.Ctx) error {
+	access, ok, err := h.loadProject(c)
+	if !ok {
+		return err
+	}
+	memberID := c.Params("userId")
+	if !access.canManage && memberID != access.userID {
+		return projectForbidden(c)
+	}
+	// Un ID que no es UUID no puede ser miembro (y Postgres rechazaría la consulta)
+	if _, err := uuid.Parse(memberID); err != nil {
+		return problem.Respond(c, fiber.StatusNotFound, problem.CodeNotFound, "El usuario no es miembro del proyecto.")
+	}
+
+	var notFound, lastOwner bool
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		var member models.ProjectMember
+		err := tx.Where("project_id = ? AND user_id = ?", access.project.ID, memberID).First(&member).Error
+		if err == gorm.ErrRecordNotFound {
+			notFound = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if member.Role == models.ProjectRoleOwner {
+			if lastOwner, err = isLastOwner(tx, access.project.ID); err != nil || lastOwner {
+				return err
+			}
+		}
+		return tx.Where("project_id = ? AND user_id = ?", member.ProjectID, member.UserID).
+			Delete(&models.ProjectMember{}).Error
+	}); err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al quitar el miembro.")
+	}
+	if notFound {
+		return problem.Respond(c, fiber.StatusNotFound, problem.CodeNotFound, "El usuario no es miembro del proyecto.")
+	}
+	if lastOwner {
+		return problem.Respond(c, fiber.StatusConflict, problem.CodeConflict, "El proyecto debe conservar al menos un owner.")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Miembro quitado exitosamente.",
+	})
+}
+
+// isLastOwner indica si el proyecto tiene un único owner. Bloquea las membresías de owner
+// para que dos bajas simultáneas no dejen el proyecto sin owners.
+func isLastOwner(tx *gorm.DB, projectID uint) (bool, error) {
+	var owners []models.ProjectMember
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("project_id = ? AND role = ?", projectID, models.ProjectRoleOwner).
+		Find(&owners).Error; err != nil {
+		return false, err
+	}
+	return len(owners) <= 1, nil
+}
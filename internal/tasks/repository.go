@@ -0,0 +1,218 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"legendaryum/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Almacenamiento de tareas. El servicio (ver service.go) depende de TaskRepository y no de GORM:
+// las reglas de permisos, valores por defecto, flujo de estados y cierre se prueban con el
+// repositorio en memoria. Listados, búsqueda, proyectos, etiquetas y el resto de los recursos
+// todavía consultan la base de datos directamente desde el handler.
+
+// ErrTaskNotFound indica que la tarea no existe o está en la papelera
+var ErrTaskNotFound = errors.New("tarea no encontrada")
+
+// TaskRepository guarda y obtiene tareas. Los métodos que escriben registran el evento de
+// historial correspondiente junto con el cambio.
+type TaskRepository interface {
+	// Transaction ejecuta fn con un repositorio cuyos cambios se confirman juntos solo si fn
+	// no devuelve error. Las transacciones pueden anidarse: la interna se deshace sola.
+	Transaction(ctx context.Context, fn func(TaskRepository) error) error
+
+	// FindByID obtiene una tarea con su creador, su asignado y todas sus etiquetas (el servicio
+	// filtra las que puede ver cada usuario).
+	// Devuelve ErrTaskNotFound si no existe o está en la papelera.
+	FindByID(ctx context.Context, id uint) (*models.Task, error)
+	// Create guarda una tarea nueva (completa su ID, versión y relaciones) y registra su creación
+	Create(ctx context.Context, task *models.Task, actorID string) error
+	// Update guarda los campos editables de task si su Version sigue siendo la vigente, aumenta la
+	// versión y registra los cambios. Si la tarea cambió devuelve ErrVersionMismatch y deja en task
+	// su estado vigente; si no, deja en task el estado guardado con sus relaciones.
+	Update(ctx context.Context, task *models.Task, actorID string) error
+	// Trash mueve la tarea y sus subtareas a la papelera si su Version sigue siendo la vigente.
+	// Si la tarea cambió devuelve ErrVersionMismatch y deja en task su estado vigente.
+	Trash(ctx context.Context, task *models.Task, actorID string) error
+
+	// ProjectExists indica si existe el proyecto
+	ProjectExists(ctx context.Context, projectID uint) (bool, error)
+	// IsProjectMember indica si el usuario es miembro del proyecto
+	IsProjectMember(ctx context.Context, projectID uint, userID string) (bool, error)
+	// DescendantIDs devuelve los IDs de todas las subtareas de la tarea, a cualquier profundidad
+	DescendantIDs(ctx context.Context, taskID uint) ([]uint, error)
+	// CountOpenDescendants cuenta las subtareas (a cualquier profundidad) que no están completadas
+	CountOpenDescendants(ctx context.Context, taskID uint) (int64, error)
+	// OpenBlockers devuelve las tareas que bloquean a la tarea y todavía no están completadas
+	OpenBlockers(ctx context.Context, taskID uint) ([]models.TaskBlocker, error)
+	// Progress devuelve el porcentaje de subtareas directas completadas de cada tarea que tiene subtareas
+	Progress(ctx context.Context, ids []uint) (map[uint]int, error)
+}
+
+// postgresTaskRepository implementa TaskRepository con GORM
+type postgresTaskRepository struct {
+	db *gorm.DB
+}
+
+// NewPostgresTaskRepository crea un repositorio de tareas sobre db (que puede ser una transacción en curso)
+func NewPostgresTaskRepository(db *gorm.DB) TaskRepository {
+	return &postgresTaskRepository{db: db}
+}
+
+func (r *postgresTaskRepository) Transaction(ctx context.Context, fn func(TaskRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&postgresTaskRepository{db: tx})
+	})
+}
+
+func (r *postgresTaskRepository) FindByID(ctx context.Context, id uint) (*models.Task, error) {
+	var task models.Task
+	if err := r.db.WithContext(ctx).Preload("Creator").Preload("Assignee").Preload("Labels").
+		First(&task, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *postgresTaskRepository) Create(ctx context.Context, task *models.Task, actorID string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Creator", "Assignee", "Labels").Create(task).Error; err != nil {
+			return err
+		}
+		return recordTaskEvent(tx, task.ID, actorID, models.TaskEventCreated, diffSnapshots(nil, taskSnapshot(*task)))
+	})
+	if err != nil {
+		return err
+	}
+	return r.reload(ctx, task)
+}
+
+func (r *postgresTaskRepository) Update(ctx context.Context, task *models.Task, actorID string) error {
+	// La fila se bloquea para que la verificación de versión y el valor "anterior" del historial sean exactos
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.Task
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, task.ID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrTaskNotFound
+			}
+			return err
+		}
+		// Otra request pudo modificarla después de verificar If-Match
+		if current.Version != task.Version {
+			*task = current
+			return ErrVersionMismatch
+		}
+		before := taskSnapshot(current)
+		if err := tx.Model(&current).Updates(map[string]interface{}{
+			"title":        task.Title,
+			"description":  task.Description,
+			"status":       task.Status,
+			"priority":     task.Priority,
+			"due_date":     task.DueDate,
+			"assignee_id":  task.AssigneeID,
+			"project_id":   task.ProjectID,
+			"parent_id":    task.ParentID,
+			"started_at":   task.StartedAt,
+			"completed_at": task.CompletedAt,
+			"version":      gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&current, task.ID).Error; err != nil {
+			return err
+		}
+		changes := diffSnapshots(before, taskSnapshot(current))
+		if len(changes) == 0 {
+			return nil
+		}
+		return recordTaskEvent(tx, task.ID, actorID, models.TaskEventUpdated, changes)
+	})
+	if err != nil {
+		return err
+	}
+	return r.reload(ctx, task)
+}
+
+// reload vuelve a leer la tarea con sus relaciones
+func (r *postgresTaskRepository) reload(ctx context.Context, task *models.Task) error {
+	saved, err := r.FindByID(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	*task = *saved
+	return nil
+}
+
+func (r *postgresTaskRepository) Trash(ctx context.Context, task *models.Task, actorID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.Task
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, task.ID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrTaskNotFound
+			}
+			return err
+		}
+		// Otra request pudo modificarla después de verificar If-Match
+		if current.Version != task.Version {
+			*task = current
+			return ErrVersionMismatch
+		}
+		ids, err := descendantIDs(tx, task.ID)
+		if err != nil {
+			return err
+		}
+		var subtasks []models.Task
+		if len(ids) > 0 {
+			if err := tx.Where("id IN ?", ids).Find(&subtasks).Error; err != nil {
+				return err
+			}
+		}
+		ids = append(ids, task.ID)
+		// Borrado lógico en una sola sentencia: todas comparten deleted_at, lo que permite restaurarlas
+		// juntas. Dependencias y etiquetas se conservan hasta la purga (ver PurgeTrash).
+		if err := tx.Delete(&models.Task{}, ids).Error; err != nil {
+			return err
+		}
+		for _, deleted := range append(subtasks, current) {
+			if err := recordTaskEvent(tx, deleted.ID, actorID, models.TaskEventDeleted, diffSnapshots(taskSnapshot(deleted), nil)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *postgresTaskRepository) ProjectExists(ctx context.Context, projectID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Project{}).Where("id = ?", projectID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *postgresTaskRepository) IsProjectMember(ctx context.Context, projectID uint, userID string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.ProjectMember{}).
+		Where("project_id = ? AND user_id = ?", projectID, userID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *postgresTaskRepository) DescendantIDs(ctx context.Context, taskID uint) ([]uint, error) {
+	return descendantIDs(r.db.WithContext(ctx), taskID)
+}
+
+func (r *postgresTaskRepository) CountOpenDescendants(ctx context.Context, taskID uint) (int64, error) {
+	return countOpenDescendants(r.db.WithContext(ctx), taskID)
+}
+
+func (r *postgresTaskRepository) OpenBlockers(ctx context.Context, taskID uint) ([]models.TaskBlocker, error) {
+	return openBlockers(r.db.WithContext(ctx), taskID)
+}
+
+func (r *postgresTaskRepository) Progress(ctx context.Context, ids []uint) (map[uint]int, error) {
+	return progressByParent(r.db.WithContext(ctx), ids)
+}
@@ -0,0 +1,325 @@
+package tasks
+
+import (
+	"context"
+	"legendaryum/internal/users"
+	"legendaryum/pkg/models"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MemoryTaskRepository implementa TaskRepository en memoria, para probar el servicio y los
+// handlers sin una base de datos. Los proyectos y las dependencias se cargan con AddProject y
+// AddDependency. Una transacción trabaja sobre una copia del estado que reemplaza al original al
+// confirmarse; no se aísla de otras transacciones simultáneas.
+type MemoryTaskRepository struct {
+	mu    sync.Mutex
+	users users.UserRepository
+	state *memoryTaskState
+}
+
+// memoryTaskState es el contenido del repositorio en memoria
+type memoryTaskState struct {
+	nextTaskID  uint
+	nextEventID uint64
+	tasks       map[uint]models.Task
+	members     map[uint]map[string]bool // proyecto → usuarios miembros
+	blockers    map[uint][]uint          // tarea → tareas que la bloquean
+	events      []models.TaskEvent
+}
+
+// NewMemoryTaskRepository crea un repositorio de tareas vacío en memoria. Si userRepo no es nil,
+// se usa para completar el creador y el asignado de las tareas que devuelve.
+func NewMemoryTaskRepository(userRepo users.UserRepository) *MemoryTaskRepository {
+	return &MemoryTaskRepository{
+		users: userRepo,
+		state: &memoryTaskState{
+			tasks:    make(map[uint]models.Task),
+			members:  make(map[uint]map[string]bool),
+			blockers: make(map[uint][]uint),
+		},
+	}
+}
+
+// clone copia el estado para una transacción
+func (s *memoryTaskState) clone() *memoryTaskState {
+	copied := &memoryTaskState{
+		nextTaskID:  s.nextTaskID,
+		nextEventID: s.nextEventID,
+		tasks:       make(map[uint]models.Task, len(s.tasks)),
+		members:     make(map[uint]map[string]bool, len(s.members)),
+		blockers:    make(map[uint][]uint, len(s.blockers)),
+		events:      append([]models.TaskEvent(nil), s.events...),
+	}
+	for id, task := range s.tasks {
+		copied.tasks[id] = task
+	}
+	for id, members := range s.members {
+		copied.members[id] = make(map[string]bool, len(members))
+		for userID := range members {
+			copied.members[id][userID] = true
+		}
+	}
+	for id, blockers := range s.blockers {
+		copied.blockers[id] = append([]uint(nil), blockers...)
+	}
+	return copied
+}
+
+// AddProject registra un proyecto con sus miembros
+func (r *MemoryTaskRepository) AddProject(projectID uint, memberIDs ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	members := make(map[string]bool, len(memberIDs))
+	for _, userID := range memberIDs {
+		members[userID] = true
+	}
+	r.state.members[projectID] = members
+}
+
+// AddDependency registra que taskID está bloqueada por blockedByID
+func (r *MemoryTaskRepository) AddDependency(taskID, blockedByID uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state.blockers[taskID] = append(r.state.blockers[taskID], blockedByID)
+}
+
+// Events devuelve el historial de la tarea en orden cronológico
+func (r *MemoryTaskRepository) Events(taskID uint) []models.TaskEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []models.TaskEvent
+	for _, event := range r.state.events {
+		if event.TaskID == taskID {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func (r *MemoryTaskRepository) Transaction(ctx context.Context, fn func(TaskRepository) error) error {
+	r.mu.Lock()
+	tx := &MemoryTaskRepository{users: r.users, state: r.state.clone()}
+	r.mu.Unlock()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.state = tx.state
+	r.mu.Unlock()
+	return nil
+}
+
+// active devuelve la tarea si existe y no está en la papelera
+func (r *MemoryTaskRepository) active(id uint) (models.Task, bool) {
+	task, ok := r.state.tasks[id]
+	return task, ok && !task.DeletedAt.Valid
+}
+
+// withRelations devuelve una copia de la tarea con su creador y su asignado
+func (r *MemoryTaskRepository) withRelations(ctx context.Context, task models.Task) *models.Task {
+	task.Labels = append([]models.Label(nil), task.Labels...)
+	if r.users != nil {
+		if creator, err := r.users.FindByID(ctx, task.CreatorID); err == nil {
+			task.Creator = *creator
+		}
+		if assignee, err := r.users.FindByID(ctx, task.AssigneeID); err == nil {
+			task.Assignee = *assignee
+		}
+	}
+	return &task
+}
+
+// record agrega una entrada al historial
+func (r *MemoryTaskRepository) record(taskID uint, actorID, action string, changes models.TaskChanges) {
+	r.state.nextEventID++
+	r.state.events = append(r.state.events, models.TaskEvent{
+		ID:        r.state.nextEventID,
+		TaskID:    taskID,
+		ActorID:   actorID,
+		Action:    action,
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (r *MemoryTaskRepository) FindByID(ctx context.Context, id uint) (*models.Task, error) {
+	r.mu.Lock()
+	task, ok := r.active(id)
+	r.mu.Unlock()
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	return r.withRelations(ctx, task), nil
+}
+
+func (r *MemoryTaskRepository) Create(ctx context.Context, task *models.Task, actorID string) error {
+	r.mu.Lock()
+	r.state.nextTaskID++
+	now := time.Now()
+	task.ID = r.state.nextTaskID
+	task.Version = 1
+	task.CreatedAt = now
+	task.UpdatedAt = now
+	stored := *task
+	stored.Creator, stored.Assignee = models.User{}, models.User{}
+	r.state.tasks[task.ID] = stored
+	r.record(task.ID, actorID, models.TaskEventCreated, diffSnapshots(nil, taskSnapshot(stored)))
+	r.mu.Unlock()
+
+	*task = *r.withRelations(ctx, stored)
+	return nil
+}
+
+func (r *MemoryTaskRepository) Update(ctx context.Context, task *models.Task, actorID string) error {
+	r.mu.Lock()
+	current, ok := r.active(task.ID)
+	if !ok {
+		r.mu.Unlock()
+		return ErrTaskNotFound
+	}
+	if current.Version != task.Version {
+		r.mu.Unlock()
+		*task = *r.withRelations(ctx, current)
+		return ErrVersionMismatch
+	}
+
+	updated := current
+	updated.Title = task.Title
+	updated.Description = task.Description
+	updated.Status = task.Status
+	updated.Priority = task.Priority
+	updated.DueDate = task.DueDate
+	updated.AssigneeID = task.AssigneeID
+	updated.ProjectID = task.ProjectID
+	updated.ParentID = task.ParentID
+	updated.StartedAt = task.StartedAt
+	updated.CompletedAt = task.CompletedAt
+	updated.Version++
+	updated.UpdatedAt = time.Now()
+	r.state.tasks[task.ID] = updated
+	if changes := diffSnapshots(taskSnapshot(current), taskSnapshot(updated)); len(changes) > 0 {
+		r.record(task.ID, actorID, models.TaskEventUpdated, changes)
+	}
+	r.mu.Unlock()
+
+	*task = *r.withRelations(ctx, updated)
+	return nil
+}
+
+func (r *MemoryTaskRepository) Trash(ctx context.Context, task *models.Task, actorID string) error {
+	r.mu.Lock()
+	current, ok := r.active(task.ID)
+	if !ok {
+		r.mu.Unlock()
+		return ErrTaskNotFound
+	}
+	if current.Version != task.Version {
+		r.mu.Unlock()
+		*task = *r.withRelations(ctx, current)
+		return ErrVersionMismatch
+	}
+	deletedAt := gorm.DeletedAt{Time: time.Now(), Valid: true}
+	for _, id := range append(r.descendants(task.ID), task.ID) {
+		deleted := r.state.tasks[id]
+		deleted.DeletedAt = deletedAt
+		r.state.tasks[id] = deleted
+		r.record(id, actorID, models.TaskEventDeleted, diffSnapshots(taskSnapshot(deleted), nil))
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *MemoryTaskRepository) ProjectExists(ctx context.Context, projectID uint) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.state.members[projectID]
+	return ok, nil
+}
+
+func (r *MemoryTaskRepository) IsProjectMember(ctx context.Context, projectID uint, userID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.members[projectID][userID], nil
+}
+
+// descendants devuelve los IDs de las subtareas de la tarea, a cualquier profundidad, sin las de la papelera
+func (r *MemoryTaskRepository) descendants(taskID uint) []uint {
+	var ids []uint
+	level := []uint{taskID}
+	for len(level) > 0 {
+		parents := make(map[uint]bool, len(level))
+		for _, id := range level {
+			parents[id] = true
+		}
+		level = level[:0:0]
+		for id, task := range r.state.tasks {
+			if task.ParentID != nil && parents[*task.ParentID] && !task.DeletedAt.Valid {
+				level = append(level, id)
+			}
+		}
+		sort.Slice(level, func(i, j int) bool { return level[i] < level[j] })
+		ids = append(ids, level...)
+	}
+	return ids
+}
+
+func (r *MemoryTaskRepository) DescendantIDs(ctx context.Context, taskID uint) ([]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.descendants(taskID), nil
+}
+
+func (r *MemoryTaskRepository) CountOpenDescendants(ctx context.Context, taskID uint) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, id := range r.descendants(taskID) {
+		if r.state.tasks[id].Status != "complete" {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MemoryTaskRepository) OpenBlockers(ctx context.Context, taskID uint) ([]models.TaskBlocker, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var blockers []models.TaskBlocker
+	for _, id := range r.state.blockers[taskID] {
+		if blocker, ok := r.active(id); ok && blocker.Status != "complete" {
+			blockers = append(blockers, models.TaskBlocker{ID: blocker.ID, Title: blocker.Title, Status: blocker.Status})
+		}
+	}
+	sort.Slice(blockers, func(i, j int) bool { return blockers[i].ID < blockers[j].ID })
+	return blockers, nil
+}
+
+func (r *MemoryTaskRepository) Progress(ctx context.Context, ids []uint) (map[uint]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	wanted := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	total := make(map[uint]int)
+	done := make(map[uint]int)
+	for _, task := range r.state.tasks {
+		if task.ParentID == nil || !wanted[*task.ParentID] || task.DeletedAt.Valid {
+			continue
+		}
+		total[*task.ParentID]++
+		if task.Status == "complete" {
+			done[*task.ParentID]++
+		}
+	}
+	progress := make(map[uint]int, len(total))
+	for id, count := range total {
+		progress[id] = done[id] * 100 / count
+	}
+	return progress, nil
+}
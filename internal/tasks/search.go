@@ -0,0 +1,70 @@
+package tasks
+
+import (
+	"legendaryum/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// Búsqueda de texto completo sobre el título y la descripción de las tareas, usando la
+// columna generada search_vector (tsvector con configuración 'spanish' e índice GIN).
+
+// searchConfig es la configuración de texto de Postgres usada para indexar y consultar
+const searchConfig = "spanish"
+
+// maxSearchQueryLen limita el largo del parámetro q
+const maxSearchQueryLen = 200
+
+// snippetOptions configura los fragmentos resaltados devueltos con cada resultado
+const snippetOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5"
+
+// snippetSource es el texto del que se extraen los fragmentos, con los caracteres especiales de
+// HTML escapados: el fragmento es HTML seguro cuyas únicas etiquetas son las <mark> del
+// resaltado, aunque el título o la descripción contengan marcado.
+const snippetSource = `replace(replace(replace(replace(replace(title || '. ' || coalesce(description, ''),
+	'&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`
+
+// searchTasks devuelve una tabla derivada con las tareas que coinciden con la búsqueda y su
+// relevancia en la columna rank. Se expone con el alias "tasks" para que los scopes y filtros
+// del listado se apliquen sin cambios.
+func searchTasks(db *gorm.DB, q string) *gorm.DB {
+	matches := db.Model(&models.Task{}).
+		Select("tasks.*, ts_rank(tasks.search_vector, websearch_to_tsquery(?, ?))::float8 AS rank", searchConfig, q).
+		Where("tasks.search_vector @@ websearch_to_tsquery(?, ?)", searchConfig, q)
+	return db.Model(&models.Task{}).Table("(?) AS tasks", matches)
+}
+
+// attachSnippets agrega a cada tarea un fragmento del título y la descripción con los
+// términos buscados resaltados, con una sola consulta. El fragmento es HTML escapado (ver snippetSource).
+func attachSnippets(db *gorm.DB, tasks []models.Task, q string) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(tasks))
+	for i := range tasks {
+		ids[i] = tasks[i].ID
+	}
+
+	var rows []struct {
+		ID      uint
+		Snippet string
+	}
+	if err := db.Model(&models.Task{}).
+		Select("id, ts_headline(?, "+snippetSource+", websearch_to_tsquery(?, ?), ?) AS snippet",
+			searchConfig, searchConfig, q, snippetOptions).
+		Where("id IN ?", ids).
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	snippets := make(map[uint]string, len(rows))
+	for _, row := range rows {
+		snippets[row.ID] = row.Snippet
+	}
+	for i := range tasks {
+		if s, ok := snippets[tasks[i].ID]; ok {
+			tasks[i].Snippet = &s
+		}
+	}
+	return nil
+}
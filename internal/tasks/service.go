@@ -0,0 +1,437 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"legendaryum/internal/problem"
+	"legendaryum/internal/users"
+	"legendaryum/internal/validation"
+	"legendaryum/pkg/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Service contiene las reglas de las tareas independientes del almacenamiento y de HTTP: quién
+// puede ver y modificar cada tarea, los valores por defecto, las referencias válidas, el flujo de
+// estados y las condiciones de cierre. Las violaciones de reglas se devuelven como *problem.Problem;
+// cualquier otro error es un error inesperado del almacenamiento.
+type Service struct {
+	tasks    TaskRepository
+	users    users.UserRepository
+	workflow *Workflow
+}
+
+// NewService crea el servicio de tareas sobre los repositorios indicados
+func NewService(taskRepo TaskRepository, userRepo users.UserRepository, workflow *Workflow) *Service {
+	return &Service{
+		tasks:    taskRepo,
+		users:    userRepo,
+		workflow: workflow,
+	}
+}
+
+// Transaction ejecuta fn con un servicio cuyos cambios de tareas se confirman juntos solo si fn no
+// devuelve error. Las transacciones pueden anidarse: la interna se deshace sola.
+func (s *Service) Transaction(ctx context.Context, fn func(*Service) error) error {
+	return s.tasks.Transaction(ctx, func(repo TaskRepository) error {
+		return fn(&Service{tasks: repo, users: s.users, workflow: s.workflow})
+	})
+}
+
+// canManage indica si el usuario puede modificar o eliminar la tarea: si la creó, o si su rol
+// puede gestionar cualquier tarea (ver manageableTasks)
+func canManage(task *models.Task, userID, role string) bool {
+	return task.CreatorID == userID || models.HasPermission(role, models.PermTasksManageAll)
+}
+
+// canView indica si el usuario puede ver la tarea: si puede modificarla, si la tiene asignada o si
+// es miembro de su proyecto (ver visibleTasks)
+func (s *Service) canView(ctx context.Context, task *models.Task, userID, role string) (bool, error) {
+	if canManage(task, userID, role) || task.AssigneeID == userID {
+		return true, nil
+	}
+	if task.ProjectID == nil {
+		return false, nil
+	}
+	return s.tasks.IsProjectMember(ctx, *task.ProjectID, userID)
+}
+
+// canUseProject indica si el usuario puede crear o mover tareas al proyecto: debe ser miembro,
+// salvo que su rol pueda gestionar cualquier tarea (en ese caso basta con que exista)
+func (s *Service) canUseProject(ctx context.Context, projectID uint, userID, role string) (bool, error) {
+	if models.HasPermission(role, models.PermTasksManageAll) {
+		return s.tasks.ProjectExists(ctx, projectID)
+	}
+	return s.tasks.IsProjectMember(ctx, projectID, userID)
+}
+
+// filterLabels deja en la tarea solo las etiquetas que el usuario puede usar: las personales propias
+// y las de los proyectos de los que es miembro (todas las de proyecto para un administrador). Las
+// etiquetas personales de otros usuarios no se exponen aunque la tarea sea compartida (ver usableLabels).
+func (s *Service) filterLabels(ctx context.Context, task *models.Task, userID, role string) error {
+	if len(task.Labels) == 0 {
+		return nil
+	}
+	members := map[uint]bool{}
+	usable := make([]models.Label, 0, len(task.Labels))
+	for _, label := range task.Labels {
+		ok := label.OwnerID != nil && *label.OwnerID == userID
+		if !ok && label.ProjectID != nil {
+			if models.HasPermission(role, models.PermTasksManageAll) {
+				ok = true
+			} else {
+				member, seen := members[*label.ProjectID]
+				if !seen {
+					var err error
+					if member, err = s.tasks.IsProjectMember(ctx, *label.ProjectID, userID); err != nil {
+						return err
+					}
+					members[*label.ProjectID] = member
+				}
+				ok = member
+			}
+		}
+		if ok {
+			usable = append(usable, label)
+		}
+	}
+	task.Labels = usable
+	return nil
+}
+
+// findVisible obtiene una tarea si es visible para el usuario.
+// Devuelve ErrTaskNotFound tanto si no existe como si no es visible.
+func (s *Service) findVisible(ctx context.Context, id uint, userID, role string) (*models.Task, error) {
+	task, err := s.tasks.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := s.canView(ctx, task, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, ErrTaskNotFound
+	}
+	if err := s.filterLabels(ctx, task, userID, role); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// Create crea una tarea a partir de la solicitud, con el usuario como creador. Los campos omitidos
+// toman sus valores por defecto (status pending, priority medium, el creador como asignado) y una
+// subtarea sin proyecto hereda el de su padre.
+func (s *Service) Create(ctx context.Context, req models.TaskRequest, creatorID, role string) (*models.Task, error) {
+	// Validar la solicitud según los tags validate de TaskRequest
+	if err := validation.Struct(&req); err != nil {
+		return nil, validationError(fiber.StatusBadRequest, "Los datos de la tarea no son válidos", err)
+	}
+
+	// Un ID 0 equivale a no tener proyecto o tarea padre
+	if req.ProjectID != nil && *req.ProjectID == 0 {
+		req.ProjectID = nil
+	}
+	if req.ParentID != nil && *req.ParentID == 0 {
+		req.ParentID = nil
+	}
+
+	// Si no se especifica assignee_id, usar el ID del creador
+	assigneeID := req.AssigneeID
+	if assigneeID == "" {
+		assigneeID = creatorID
+	}
+
+	// Validar que el assignee existe si se especificó uno diferente al creador
+	if assigneeID != creatorID {
+		if _, err := s.users.FindByID(ctx, assigneeID); err != nil {
+			if err == users.ErrUserNotFound {
+				return nil, problem.New(fiber.StatusBadRequest, problem.CodeInvalidReference, fmt.Sprintf("El usuario asignado con ID %s no existe.", assigneeID))
+			}
+			return nil, err
+		}
+	}
+
+	// Validar la tarea padre: debe ser visible para el creador. Si no se indica proyecto,
+	// la subtarea hereda el de su padre.
+	projectInherited := false
+	if req.ParentID != nil {
+		parent, err := s.findVisible(ctx, *req.ParentID, creatorID, role)
+		if err != nil {
+			if err == ErrTaskNotFound {
+				return nil, problem.New(fiber.StatusBadRequest, problem.CodeInvalidReference, fmt.Sprintf("La tarea padre con ID %d no existe o no tienes permiso para verla.", *req.ParentID))
+			}
+			return nil, err
+		}
+		if parent.Status == "complete" && req.Status != "complete" {
+			return nil, problem.New(fiber.StatusConflict, problem.CodeConflict, "No se pueden agregar subtareas abiertas a una tarea completada.")
+		}
+		if req.ProjectID == nil {
+			req.ProjectID = parent.ProjectID
+			projectInherited = true
+		}
+	}
+
+	// Validar que el creador puede agregar tareas al proyecto indicado
+	if req.ProjectID != nil && !projectInherited {
+		allowed, err := s.canUseProject(ctx, *req.ProjectID, creatorID, role)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, problem.New(fiber.StatusBadRequest, problem.CodeInvalidReference, fmt.Sprintf("El proyecto con ID %d no existe o no eres miembro.", *req.ProjectID))
+		}
+	}
+
+	// Establecer valores por defecto si no se especifican (basado en tags validate omitempty)
+	status := req.Status
+	if status == "" {
+		status = "pending"
+	}
+	priority := req.Priority
+	if priority == "" {
+		priority = "medium"
+	}
+
+	task := &models.Task{
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      status,
+		Priority:    priority,
+		DueDate:     req.DueDate,
+		CreatorID:   creatorID,
+		AssigneeID:  assigneeID,
+		ProjectID:   req.ProjectID,
+		ParentID:    req.ParentID,
+	}
+	// Una tarea creada ya iniciada o completada registra el momento de creación
+	now := time.Now()
+	if status != "pending" {
+		task.StartedAt = &now
+	}
+	if status == "complete" {
+		task.CompletedAt = &now
+	}
+
+	if err := s.tasks.Create(ctx, task, creatorID); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// Get obtiene una tarea visible para el usuario, con su progreso. Si no existe o no es visible
+// devuelve un problema 404.
+func (s *Service) Get(ctx context.Context, id uint, userID, role string) (*models.Task, error) {
+	task, err := s.findVisible(ctx, id, userID, role)
+	if err != nil {
+		if err == ErrTaskNotFound {
+			return nil, problem.New(fiber.StatusNotFound, problem.CodeNotFound, "Tarea no encontrada o no tienes permiso para verla.")
+		}
+		return nil, err
+	}
+	progress, err := s.tasks.Progress(ctx, []uint{task.ID})
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := progress[task.ID]; ok {
+		task.Progress = &p
+	}
+	return task, nil
+}
+
+// GetForWrite obtiene una tarea que el usuario puede modificar. Si no existe o no puede
+// modificarla devuelve un problema 403; action describe la operación en el mensaje.
+func (s *Service) GetForWrite(ctx context.Context, id uint, userID, role, action string) (*models.Task, error) {
+	task, err := s.tasks.FindByID(ctx, id)
+	if err != nil && err != ErrTaskNotFound {
+		return nil, err
+	}
+	if err == ErrTaskNotFound || !canManage(task, userID, role) {
+		return nil, problem.New(fiber.StatusForbidden, problem.CodeForbidden, fmt.Sprintf("No tienes permiso para %s esta tarea.", action))
+	}
+	if err := s.filterLabels(ctx, task, userID, role); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// Replace reemplaza los campos editables de la tarea con la solicitud (PUT). Los campos omitidos
+// toman los valores por defecto de la creación. Las referencias inválidas se informan con 400.
+func (s *Service) Replace(ctx context.Context, task *models.Task, req models.TaskRequest, userID, role string) (changed bool, err error) {
+	// Mismas reglas que al crear: PUT reemplaza la tarea completa
+	if err := validation.Struct(&req); err != nil {
+		return false, validationError(fiber.StatusBadRequest, "Los datos de la tarea no son válidos (para cambios parciales usa PATCH)", err)
+	}
+	doc := replacementDocument(req, task.CreatorID)
+	if err := doc.validate(); err != nil {
+		return false, validationError(fiber.StatusBadRequest, "La tarea resultante no es válida", err)
+	}
+	return s.update(ctx, task, doc, userID, role, fiber.StatusBadRequest)
+}
+
+// Patch aplica un merge patch o un JSON patch (según contentType) a la tarea (PATCH). El resultado
+// inválido y las referencias inválidas se informan con 422.
+func (s *Service) Patch(ctx context.Context, task *models.Task, contentType string, patch []byte, userID, role string) (changed bool, err error) {
+	doc, err := applyPatch(documentFromTask(task), contentType, patch)
+	if err != nil {
+		return false, err
+	}
+	if err := doc.validate(); err != nil {
+		return false, validationError(fiber.StatusUnprocessableEntity, "La tarea resultante no es válida", err)
+	}
+	return s.update(ctx, task, doc, userID, role, fiber.StatusUnprocessableEntity)
+}
+
+// Reassign cambia el asignado de la tarea. Un asignado inexistente se informa con 422.
+func (s *Service) Reassign(ctx context.Context, task *models.Task, assigneeID, userID, role string) (changed bool, err error) {
+	doc := documentFromTask(task)
+	doc.AssigneeID = assigneeID
+	if err := doc.validate(); err != nil {
+		return false, validationError(fiber.StatusUnprocessableEntity, "La tarea resultante no es válida", err)
+	}
+	return s.update(ctx, task, doc, userID, role, fiber.StatusUnprocessableEntity)
+}
+
+// Delete mueve la tarea y sus subtareas a la papelera. Si la tarea cambió desde que se leyó
+// devuelve ErrVersionMismatch y deja en task su estado vigente.
+func (s *Service) Delete(ctx context.Context, task *models.Task, userID string) error {
+	return s.tasks.Trash(ctx, task, userID)
+}
+
+// update aplica el documento validado a la tarea. Verifica las referencias que cambiaron
+// (assignee, proyecto, tarea padre), el flujo de estados y las reglas de cierre, y guarda los
+// cambios con su evento de historial. invalidStatus es el código para referencias inválidas: 400 en
+// PUT y 422 en PATCH. Si la tarea cambió desde que se leyó devuelve ErrVersionMismatch y deja en
+// task su estado vigente; si no, deja en task el estado guardado. changed indica si hubo cambios.
+func (s *Service) update(ctx context.Context, task *models.Task, doc taskDocument, userID, role string, invalidStatus int) (changed bool, err error) {
+	next := *task
+	next.Title = doc.Title
+	next.Description = doc.Description
+	next.Priority = doc.Priority
+	next.DueDate = doc.DueDate
+
+	if doc.Status != task.Status {
+		// Solo las transiciones del flujo de estados, por los actores que indica
+		if err := s.workflow.checkTransition(task, doc.Status, userID, role); err != nil {
+			return false, err
+		}
+		next.Status = doc.Status
+		setStatusTimestamps(&next, doc.Status, time.Now())
+	}
+
+	if doc.AssigneeID != task.AssigneeID {
+		// Validar que el nuevo assignee existe
+		if _, err := s.users.FindByID(ctx, doc.AssigneeID); err != nil {
+			if err == users.ErrUserNotFound {
+				return false, problem.New(invalidStatus, problem.CodeInvalidReference, fmt.Sprintf("El nuevo usuario asignado con ID %s no existe.", doc.AssigneeID))
+			}
+			return false, err
+		}
+		next.AssigneeID = doc.AssigneeID
+	}
+
+	if !sameID(doc.ProjectID, task.ProjectID) {
+		if doc.ProjectID != nil {
+			allowed, err := s.canUseProject(ctx, *doc.ProjectID, userID, role)
+			if err != nil {
+				return false, err
+			}
+			if !allowed {
+				return false, problem.New(invalidStatus, problem.CodeInvalidReference, fmt.Sprintf("El proyecto con ID %d no existe o no eres miembro.", *doc.ProjectID))
+			}
+		}
+		next.ProjectID = doc.ProjectID
+	}
+
+	if !sameID(doc.ParentID, task.ParentID) {
+		// Sin tarea padre la subtarea pasa a ser de primer nivel
+		if doc.ParentID != nil {
+			if err := s.checkParent(ctx, task.ID, *doc.ParentID, doc.Status, userID, role, invalidStatus); err != nil {
+				return false, err
+			}
+		}
+		next.ParentID = doc.ParentID
+	}
+
+	if doc.Status == "complete" && task.Status != "complete" {
+		if err := s.checkCanComplete(ctx, task.ID); err != nil {
+			return false, err
+		}
+	}
+
+	// Las marcas de started_at y completed_at solo cambian junto con el estado
+	if len(diffSnapshots(taskSnapshot(*task), taskSnapshot(next))) == 0 {
+		return false, nil
+	}
+	if err := s.tasks.Update(ctx, &next, userID); err != nil {
+		if err == ErrVersionMismatch {
+			*task = next
+		}
+		return false, err
+	}
+	if err := s.filterLabels(ctx, &next, userID, role); err != nil {
+		return false, err
+	}
+	*task = next
+	return true, nil
+}
+
+// checkParent verifica que parentID pueda ser la nueva tarea padre de la tarea: no puede ser ella
+// misma ni una de sus subtareas, debe ser visible para el usuario y no puede estar completada si la
+// tarea queda abierta
+func (s *Service) checkParent(ctx context.Context, taskID, parentID uint, status, userID, role string, invalidStatus int) error {
+	cyclic := taskID == parentID
+	if !cyclic {
+		ids, err := s.tasks.DescendantIDs(ctx, taskID)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if id == parentID {
+				cyclic = true
+				break
+			}
+		}
+	}
+	if cyclic {
+		return problem.New(invalidStatus, problem.CodeInvalidReference, "Una tarea no puede ser subtarea de sí misma ni de sus propias subtareas.")
+	}
+
+	parent, err := s.findVisible(ctx, parentID, userID, role)
+	if err != nil {
+		if err == ErrTaskNotFound {
+			return problem.New(invalidStatus, problem.CodeInvalidReference, fmt.Sprintf("La tarea padre con ID %d no existe o no tienes permiso para verla.", parentID))
+		}
+		return err
+	}
+	if parent.Status == "complete" && status != "complete" {
+		return problem.FromStatus(fiber.StatusConflict, "No se pueden agregar subtareas abiertas a una tarea completada.")
+	}
+	return nil
+}
+
+// checkCanComplete verifica que la tarea pueda cerrarse: no puede tener subtareas abiertas ni
+// tareas que la bloqueen abiertas
+func (s *Service) checkCanComplete(ctx context.Context, taskID uint) error {
+	open, err := s.tasks.CountOpenDescendants(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if open > 0 {
+		return problem.New(fiber.StatusConflict, problem.CodeOpenSubtasks,
+			fmt.Sprintf("No se puede completar la tarea: tiene %d subtarea(s) abierta(s).", open)).
+			With("open_subtasks", open)
+	}
+
+	blockers, err := s.tasks.OpenBlockers(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if len(blockers) > 0 {
+		return problem.New(fiber.StatusConflict, problem.CodeTaskBlocked,
+			fmt.Sprintf("No se puede completar la tarea: está bloqueada por %d tarea(s) abierta(s).", len(blockers))).
+			With("blocked_by", blockers)
+	}
+	return nil
+}
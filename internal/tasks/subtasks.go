@@ -0,0 +1,118 @@
+package tasks
+
+import (
+	"legendaryum/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// Subtareas: una tarea puede tener una tarea padre (parent_id). Un padre no puede cerrarse
+// mientras tenga subtareas abiertas y reporta su progreso según el estado de sus hijas.
+
+// maxSubtaskDepth limita los niveles cargados con ?include=subtasks
+const maxSubtaskDepth = 10
+
+// descendantsQuery selecciona los IDs de todas las subtareas (a cualquier profundidad) de una tarea,
+// sin las que están en la papelera
+const descendantsQuery = `WITH RECURSIVE tree AS (
+	SELECT id, status FROM tasks WHERE parent_id = ? AND deleted_at IS NULL
+	UNION
+	SELECT t.id, t.status FROM tasks t JOIN tree ON t.parent_id = tree.id WHERE t.deleted_at IS NULL
+)`
+
+// descendantIDs devuelve los IDs de todas las subtareas de la tarea, a cualquier profundidad
+func descendantIDs(db *gorm.DB, taskID uint) ([]uint, error) {
+	var ids []uint
+	err := db.Raw(descendantsQuery+" SELECT id FROM tree", taskID).Scan(&ids).Error
+	return ids, err
+}
+
+// countOpenDescendants cuenta las subtareas (a cualquier profundidad) que no están completadas
+func countOpenDescendants(db *gorm.DB, taskID uint) (int64, error) {
+	var count int64
+	err := db.Raw(descendantsQuery+" SELECT COUNT(*) FROM tree WHERE status <> ?", taskID, "complete").Scan(&count).Error
+	return count, err
+}
+
+// attachProgress calcula el porcentaje de subtareas directas completadas de cada tarea
+// con una sola consulta. Las tareas sin subtareas quedan con Progress nil.
+func attachProgress(db *gorm.DB, tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(tasks))
+	for i := range tasks {
+		ids[i] = tasks[i].ID
+	}
+	progress, err := progressByParent(db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		if p, ok := progress[tasks[i].ID]; ok {
+			tasks[i].Progress = &p
+		}
+	}
+	return nil
+}
+
+// progressByParent devuelve el porcentaje de subtareas directas completadas de cada tarea que tiene subtareas
+func progressByParent(db *gorm.DB, ids []uint) (map[uint]int, error) {
+	var rows []struct {
+		ParentID uint
+		Total    int
+		Done     int
+	}
+	if err := db.Model(&models.Task{}).
+		Select("parent_id, COUNT(*) AS total, COUNT(*) FILTER (WHERE status = ?) AS done", "complete").
+		Where("parent_id IN ?", ids).
+		Group("parent_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	progress := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		progress[row.ParentID] = row.Done * 100 / row.Total
+	}
+	return progress, nil
+}
+
+// loadSubtasks carga recursivamente las subtareas visibles para el usuario, con su progreso
+func (h *Handler) loadSubtasks(task *models.Task, userID, role string) error {
+	level := []*models.Task{task}
+	for depth := 0; depth < maxSubtaskDepth && len(level) > 0; depth++ {
+		parents := make(map[uint]*models.Task, len(level))
+		ids := make([]uint, 0, len(level))
+		for _, parent := range level {
+			parents[parent.ID] = parent
+			ids = append(ids, parent.ID)
+		}
+
+		var children []models.Task
+		if err := h.db.Scopes(visibleTasks(userID, role)).
+			Where("tasks.parent_id IN ?", ids).
+			Order("tasks.id ASC").
+			Preload("Creator").Preload("Assignee").Preload("Labels", usableLabels(userID, role)).
+			Find(&children).Error; err != nil {
+			return err
+		}
+		if err := attachProgress(h.db, children); err != nil {
+			return err
+		}
+
+		for _, child := range children {
+			parent := parents[*child.ParentID]
+			parent.Subtasks = append(parent.Subtasks, child)
+		}
+
+		// Los punteros se toman después de completar los slices para que no queden obsoletos
+		level = level[:0]
+		for _, parent := range parents {
+			for i := range parent.Subtasks {
+				level = append(level, &parent.Subtasks[i])
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package tasks
+
+import (
+	"context"
+	"legendaryum/internal/middleware"
+	"legendaryum/internal/problem"
+	"legendaryum/pkg/models"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Papelera de tareas: DELETE /tasks/:id hace un borrado lógico (deleted_at) de la tarea y sus
+// subtareas. Desde la papelera se pueden restaurar hasta que la purga periódica las elimina
+// definitivamente al vencer el período de retención (TRASH_RETENTION).
+
+// trashedTreeQuery selecciona los IDs de las subtareas (a cualquier profundidad) que se enviaron a
+// la papelera junto con la tarea indicada, es decir, con el mismo deleted_at
+const trashedTreeQuery = `WITH RECURSIVE tree AS (
+	SELECT t.id FROM tasks t JOIN tasks root ON root.id = ? WHERE t.parent_id = root.id AND t.deleted_at = root.deleted_at
+	UNION
+	SELECT t.id FROM tasks t JOIN tree ON t.parent_id = tree.id JOIN tasks root ON root.id = ? WHERE t.deleted_at = root.deleted_at
+)`
+
+// trashedTreeIDs devuelve los IDs de las subtareas eliminadas junto con la tarea
+func trashedTreeIDs(db *gorm.DB, taskID uint) ([]uint, error) {
+	var ids []uint
+	err := db.Raw(trashedTreeQuery+" SELECT id FROM tree", taskID, taskID).Scan(&ids).Error
+	return ids, err
+}
+
+// ListTrash godoc
+// @Summary Listar la papelera
+// @Description Lista las tareas eliminadas que el usuario autenticado puede restaurar (las que creó o, para administradores, todas), ordenadas por ID y paginadas por cursor. Permanecen en la papelera hasta que vence el período de retención.
+// @Tags tasks
+// @Produce json
+// @Param limit query int false "Cantidad máxima de tareas por página (1-100, por defecto 20)"
+// @Param cursor query string false "Cursor opaco devuelto en meta.next_cursor por la página anterior"
+// @Security Bearer
+// @Success 200 {object} models.TaskListResponse "Página de tareas en la papelera"
+// @Failure 400 {object} problem.Problem "Parámetros de paginación inválidos"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /tasks/trash [get]
+func (h *Handler) ListTrash(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
+	}
+
+	limit, err := parseLimit(c.Query("limit"))
+	if err != nil {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+	}
+
+	role := middleware.CurrentRole(c)
+	query := h.db.Unscoped().Model(&models.Task{}).
+		Scopes(manageableTasks(userID, role)).
+		Where("tasks.deleted_at IS NOT NULL").
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al contar las tareas de la papelera.")
+	}
+
+	page := query
+	if cursor := c.Query("cursor"); cursor != "" {
+		afterID, err := decodeIDCursor(cursor)
+		if err != nil {
+			return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+		}
+		page = page.Where("tasks.id > ?", afterID)
+	}
+
+	var tasks []models.Task
+	if err := page.Preload("Creator").Preload("Assignee").Preload("Labels", usableLabels(userID, role)).
+		Order("tasks.id ASC").Limit(limit + 1).Find(&tasks).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener la papelera.")
+	}
+
+	var nextCursor *string
+	if len(tasks) > limit {
+		tasks = tasks[:limit]
+		cursor := encodeIDCursor(tasks[len(tasks)-1].ID)
+		nextCursor = &cursor
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Papelera obtenida exitosamente.",
+		"data":    tasks,
+		"meta": models.PageMeta{
+			Total:      total,
+			Limit:      limit,
+			Sort:       "id",
+			NextCursor: nextCursor,
+		},
+	})
+}
+
+// Restore godoc
+// @Summary Restaurar una tarea
+// @Description Saca una tarea de la papelera junto con las subtareas que se eliminaron con ella. Si la tarea padre sigue en la papelera, hay que restaurarla primero. Solo el creador de la tarea (o un administrador) puede restaurarla.
+// @Tags tasks
+// @Produce json
+// @Param id path int true "ID numérico de la tarea a restaurar" Format(uint)
+// @Security Bearer
+// @Success 200 {object} models.Task "Tarea restaurada (con el ETag en la cabecera)"
+// @Failure 400 {object} problem.Problem "ID inválido"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Permiso denegado (los viewers no pueden escribir)"
+// @Failure 404 {object} problem.Problem "Tarea no encontrada"
+// @Failure 409 {object} problem.Problem "La tarea no está en la papelera o su tarea padre sigue eliminada"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /tasks/{id}/restore [post]
+func (h *Handler) Restore(c *fiber.Ctx) error {
+	taskID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, "ID de tarea inválido. Debe ser un número entero.")
+	}
+
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
+	}
+
+	role := middleware.CurrentRole(c)
+	var task models.Task
+	if err := h.db.Unscoped().Scopes(manageableTasks(userID, role)).Where("tasks.id = ?", taskID).First(&task).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return problem.Respond(c, fiber.StatusNotFound, problem.CodeNotFound, "Tarea no encontrada o no tienes permiso para restaurarla.")
+		}
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener la tarea.")
+	}
+
+	if !task.DeletedAt.Valid {
+		return problem.Respond(c, fiber.StatusConflict, problem.CodeConflict, "La tarea no está en la papelera.")
+	}
+
+	// Una subtarea no puede volver mientras su tarea padre siga eliminada
+	if task.ParentID != nil {
+		var trashedParents int64
+		if err := h.db.Unscoped().Model(&models.Task{}).
+			Where("id = ? AND deleted_at IS NOT NULL", *task.ParentID).Count(&trashedParents).Error; err != nil {
+			// Loggear error
+			return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al verificar la tarea padre.")
+		}
+		if trashedParents > 0 {
+			return problem.Respond(c, fiber.StatusConflict, problem.CodeConflict, "La tarea padre está en la papelera. Restáurala primero.")
+		}
+	}
+
+	// Restaurar la tarea y las subtareas eliminadas con ella y registrar los eventos en la misma transacción
+	restoredNow := false
+	if err := h.db.Transaction(func(tx *gorm.DB) error {
+		var current models.Task
+		if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, task.ID).Error; err != nil {
+			return err
+		}
+		// Otra request pudo restaurarla o purgarla mientras tanto
+		if !current.DeletedAt.Valid {
+			return nil
+		}
+		ids, err := trashedTreeIDs(tx, task.ID)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, task.ID)
+		if err := tx.Unscoped().Model(&models.Task{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		changes := models.TaskChanges{"deleted_at": models.FieldChange{
+			From: current.DeletedAt.Time.UTC().Format(time.RFC3339Nano),
+			To:   nil,
+		}}
+		for _, id := range ids {
+			if err := recordTaskEvent(tx, id, userID, models.TaskEventRestored, changes); err != nil {
+				return err
+			}
+		}
+		restoredNow = true
+		return nil
+	}); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return problem.Respond(c, fiber.StatusNotFound, problem.CodeNotFound, "Tarea no encontrada o no tienes permiso para restaurarla.")
+		}
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al restaurar la tarea.")
+	}
+	if !restoredNow {
+		return problem.Respond(c, fiber.StatusConflict, problem.CodeConflict, "La tarea no está en la papelera.")
+	}
+
+	// La tarea restaurada con su progreso, como la devuelve GET
+	restored, err := h.service.Get(c.UserContext(), task.ID, userID, role)
+	if err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener la tarea restaurada.")
+	}
+
+	c.Set(fiber.HeaderETag, representationETag(restored, false))
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Tarea restaurada exitosamente.",
+		"data":    restored,
+	})
+}
+
+// PurgeTrash elimina definitivamente las tareas que están en la papelera desde antes de cutoff,
+// junto con sus dependencias, etiquetas y comentarios. El historial se conserva.
+// Devuelve la cantidad de tareas eliminadas. Si se cancela ctx, la purga en curso se interrumpe
+// y se revierte.
+func PurgeTrash(ctx context.Context, db *gorm.DB, cutoff time.Time) (int64, error) {
+	var purged int64
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		expired := tx.Unscoped().Model(&models.Task{}).Select("id").Where("deleted_at < ?", cutoff)
+		// Se borran explícitamente por si el esquema no tiene las claves foráneas
+		if err := tx.Where("task_id IN (?) OR blocked_by_id IN (?)", expired, expired).Delete(&models.TaskDependency{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM task_labels WHERE task_id IN (?)", expired).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("task_id IN (?)", expired).Delete(&models.TaskComment{}).Error; err != nil {
+			return err
+		}
+		res := tx.Unscoped().Where("deleted_at < ?", cutoff).Delete(&models.Task{})
+		if res.Error != nil {
+			return res.Error
+		}
+		purged = res.RowsAffected
+		return nil
+	})
+	return purged, err
+}
+
+// RunTrashPurger purga la papelera cada interval, eliminando las tareas que llevan más de
+// retention en ella, hasta que se cancela ctx
+func RunTrashPurger(ctx context.Context, db *gorm.DB, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := PurgeTrash(ctx, db, time.Now().Add(-retention)); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error purgando la papelera de tareas: %v", err)
+		} else if purged > 0 {
+			log.Printf("Papelera de tareas: %d tareas purgadas", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
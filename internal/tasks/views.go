@@ -0,0 +1,330 @@
+package tasks
+
+import (
+	"fmt"
+	"legendaryum/internal/problem"
+	"legendaryum/internal/validation"
+	"legendaryum/pkg/database"
+	"legendaryum/pkg/models"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Vistas guardadas: cada usuario puede guardar con un nombre la búsqueda, los filtros y el orden
+// del listado de tareas y ejecutarlos después con GET /views/:id/tasks. Se validan y ejecutan con
+// el mismo código que GET /tasks (parseTaskListSpec y listTasks).
+
+// loadView obtiene una vista del usuario autenticado. Si algo falla, ya escribe la respuesta de
+// error y devuelve ok=false. Las vistas de otros usuarios se informan como inexistentes.
+func (h *Handler) loadView(c *fiber.Ctx) (view *models.SavedView, userID string, ok bool, err error) {
+	id, parseErr := strconv.ParseUint(c.Params("id"), 10, 32)
+	if parseErr != nil {
+		return nil, "", false, problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, "ID de vista inválido. Debe ser un número entero.")
+	}
+
+	userID, _ = c.Locals("user_id").(string)
+	if userID == "" {
+		return nil, "", false, problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
+	}
+
+	view = &models.SavedView{}
+	if findErr := h.db.Where("id = ? AND user_id = ?", id, userID).First(view).Error; findErr != nil {
+		if findErr == gorm.ErrRecordNotFound {
+			return nil, "", false, problem.Respond(c, fiber.StatusNotFound, problem.CodeNotFound, "Vista no encontrada.")
+		}
+		// Loggear error
+		return nil, "", false, problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener la vista.")
+	}
+	return view, userID, true, nil
+}
+
+// viewParams combina los filtros y el orden de la vista en los parámetros del listado de tareas
+func viewParams(filters map[string]string, sort string) map[string]string {
+	params := make(map[string]string, len(filters)+1)
+	for key, value := range filters {
+		params[key] = value
+	}
+	if sort != "" {
+		params["sort"] = sort
+	}
+	return params
+}
+
+// parseViewBody lee y valida los datos de una vista. Los filtros y el orden se validan igual que
+// los query params de GET /tasks.
+func parseViewBody(c *fiber.Ctx, userID string) (models.SavedViewRequest, bool, error) {
+	var req models.SavedViewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return req, false, problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidJSON, "Error al procesar la solicitud: JSON inválido.")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validation.Struct(&req); err != nil {
+		return req, false, respondValidationError(c, fiber.StatusBadRequest, "Los datos de la vista no son válidos", err)
+	}
+
+	if req.Filters == nil {
+		req.Filters = map[string]string{}
+	}
+	// El orden va en su propio campo y la paginación se elige al ejecutar la vista
+	for _, key := range []string{"sort", "limit", "cursor"} {
+		if _, ok := req.Filters[key]; ok {
+			return req, false, problem.Respond(c, fiber.StatusBadRequest, problem.CodeBadRequest, fmt.Sprintf("'%s' no es un filtro válido para una vista.", key))
+		}
+	}
+	req.Sort = strings.TrimSpace(req.Sort)
+	if _, err := parseTaskListSpec(viewParams(req.Filters, req.Sort), userID); err != nil {
+		return req, false, problem.Respond(c, fiber.StatusBadRequest, problem.CodeBadRequest, err.Error())
+	}
+	return req, true, nil
+}
+
+// savedViewsUserName es el índice único que impide dos vistas del mismo usuario con el mismo
+// nombre (sin distinguir mayúsculas); se usa en lugar de consultar antes para no competir con
+// otra request que guarde el mismo nombre
+const savedViewsUserName = "idx_saved_views_user_name"
+
+// viewNameTaken responde el conflicto de nombre de vista repetido
+func viewNameTaken(c *fiber.Ctx, name string) error {
+	return problem.Respond(c, fiber.StatusConflict, problem.CodeAlreadyExists, fmt.Sprintf("Ya existe una vista llamada %q.", name))
+}
+
+// CreateView godoc
+// @Summary Crear una vista guardada
+// @Description Guarda con un nombre una búsqueda, filtros y orden del listado de tareas. Los filtros usan los mismos parámetros que GET /tasks.
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param request body models.SavedViewRequest true "Datos de la vista"
+// @Security Bearer
+// @Success 201 {object} models.SavedView "Vista creada exitosamente"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada (nombre, filtros u orden inválidos)"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 409 {object} problem.Problem "Ya existe una vista con ese nombre"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /views [post]
+func (h *Handler) CreateView(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
+	}
+	req, ok, err := parseViewBody(c, userID)
+	if !ok {
+		return err
+	}
+
+	view := models.SavedView{UserID: userID, Name: req.Name, Filters: req.Filters, Sort: req.Sort}
+	if err := h.db.Create(&view).Error; err != nil {
+		if database.IsUniqueViolation(err, savedViewsUserName) {
+			return viewNameTaken(c, req.Name)
+		}
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al crear la vista.")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Vista creada exitosamente.",
+		"data":    view,
+	})
+}
+
+// ListViews godoc
+// @Summary Listar vistas guardadas
+// @Description Obtiene las vistas guardadas del usuario autenticado, paginadas por cursor.
+// @Tags views
+// @Produce json
+// @Param limit query int false "Cantidad máxima de vistas por página (1-100, por defecto 20)"
+// @Param cursor query string false "Cursor opaco devuelto en meta.next_cursor por la página anterior"
+// @Security Bearer
+// @Success 200 {object} models.SavedViewListResponse "Página de vistas"
+// @Failure 400 {object} problem.Problem "Parámetros de paginación inválidos"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /views [get]
+func (h *Handler) ListViews(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok || userID == "" {
+		return problem.Respond(c, fiber.StatusUnauthorized, problem.CodeUnauthenticated, "Usuario no autenticado.")
+	}
+
+	limit, err := parseLimit(c.Query("limit"))
+	if err != nil {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+	}
+
+	query := h.db.Model(&models.SavedView{}).Where("user_id = ?", userID).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al contar las vistas.")
+	}
+
+	page := query
+	if cursor := c.Query("cursor"); cursor != "" {
+		afterID, err := decodeIDCursor(cursor)
+		if err != nil {
+			return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+		}
+		page = page.Where("id > ?", afterID)
+	}
+
+	var views []models.SavedView
+	if err := page.Order("id ASC").Limit(limit + 1).Find(&views).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener las vistas.")
+	}
+
+	var nextCursor *string
+	if len(views) > limit {
+		views = views[:limit]
+		cursor := encodeIDCursor(views[len(views)-1].ID)
+		nextCursor = &cursor
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Vistas obtenidas exitosamente.",
+		"data":    views,
+		"meta": models.PageMeta{
+			Total:      total,
+			Limit:      limit,
+			Sort:       "id",
+			NextCursor: nextCursor,
+		},
+	})
+}
+
+// GetView godoc
+// @Summary Obtener una vista guardada
+// @Description Obtiene una vista guardada del usuario autenticado.
+// @Tags views
+// @Produce json
+// @Param id path int true "ID numérico de la vista" Format(uint)
+// @Security Bearer
+// @Success 200 {object} models.SavedView "Vista guardada"
+// @Failure 400 {object} problem.Problem "ID inválido"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 404 {object} problem.Problem "Vista no encontrada"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /views/{id} [get]
+func (h *Handler) GetView(c *fiber.Ctx) error {
+	view, _, ok, err := h.loadView(c)
+	if !ok {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Vista obtenida exitosamente.",
+		"data":    view,
+	})
+}
+
+// UpdateView godoc
+// @Summary Actualizar una vista guardada
+// @Description Reemplaza el nombre, los filtros y el orden de una vista guardada del usuario autenticado.
+// @Tags views
+// @Accept json
+// @Produce json
+// @Param id path int true "ID numérico de la vista" Format(uint)
+// @Param request body models.SavedViewRequest true "Datos de la vista"
+// @Security Bearer
+// @Success 200 {object} models.SavedView "Vista actualizada exitosamente"
+// @Failure 400 {object} problem.Problem "Error en los datos de entrada (nombre, filtros u orden inválidos)"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 404 {object} problem.Problem "Vista no encontrada"
+// @Failure 409 {object} problem.Problem "Ya existe una vista con ese nombre"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /views/{id} [put]
+func (h *Handler) UpdateView(c *fiber.Ctx) error {
+	view, userID, ok, err := h.loadView(c)
+	if !ok {
+		return err
+	}
+	req, ok, err := parseViewBody(c, userID)
+	if !ok {
+		return err
+	}
+
+	view.Name = req.Name
+	view.Filters = req.Filters
+	view.Sort = req.Sort
+	if err := h.db.Save(view).Error; err != nil {
+		if database.IsUniqueViolation(err, savedViewsUserName) {
+			return viewNameTaken(c, req.Name)
+		}
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al actualizar la vista.")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Vista actualizada exitosamente.",
+		"data":    view,
+	})
+}
+
+// DeleteView godoc
+// @Summary Eliminar una vista guardada
+// @Description Elimina una vista guardada del usuario autenticado.
+// @Tags views
+// @Produce json
+// @Param id path int true "ID numérico de la vista" Format(uint)
+// @Security Bearer
+// @Success 200 {object} models.SuccessResponse "Vista eliminada exitosamente"
+// @Failure 400 {object} problem.Problem "ID inválido"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 404 {object} problem.Problem "Vista no encontrada"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /views/{id} [delete]
+func (h *Handler) DeleteView(c *fiber.Ctx) error {
+	view, _, ok, err := h.loadView(c)
+	if !ok {
+		return err
+	}
+
+	if err := h.db.Delete(view).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al eliminar la vista.")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Vista eliminada exitosamente.",
+	})
+}
+
+// ViewTasks godoc
+// @Summary Ejecutar una vista guardada
+// @Description Lista las tareas que cumplen la búsqueda, los filtros y el orden de la vista, igual que GET /tasks con esos parámetros. Solo admite los parámetros de paginación.
+// @Tags views
+// @Produce json
+// @Param id path int true "ID numérico de la vista" Format(uint)
+// @Param limit query int false "Cantidad máxima de tareas por página (1-100, por defecto 20)"
+// @Param cursor query string false "Cursor opaco devuelto en meta.next_cursor por la página anterior"
+// @Security Bearer
+// @Success 200 {object} models.TaskListResponse "Página de tareas con metadatos de paginación"
+// @Failure 400 {object} problem.Problem "ID, parámetros de paginación o filtros de la vista inválidos"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 404 {object} problem.Problem "Vista no encontrada"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /views/{id}/tasks [get]
+func (h *Handler) ViewTasks(c *fiber.Ctx) error {
+	view, userID, ok, err := h.loadView(c)
+	if !ok {
+		return err
+	}
+	if extra := queryParams(c); len(extra) > 0 {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidParameter, "Una vista solo admite los parámetros 'limit' y 'cursor'. Para cambiar los filtros actualiza la vista.")
+	}
+
+	spec, err := parseTaskListSpec(viewParams(view.Filters, view.Sort), userID)
+	if err != nil {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeBadRequest, fmt.Sprintf("La vista tiene filtros inválidos: %s", err.Error()))
+	}
+	return h.listTasks(c, userID, spec)
+}
@@ -0,0 +1,134 @@
+package tasks
+
+import (
+	"fmt"
+	"legendaryum/internal/problem"
+	"legendaryum/pkg/models"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Flujo de estados de las tareas. Solo se permiten las transiciones definidas y cada una indica
+// quién puede hacerla. Se configura con TASK_WORKFLOW como una lista de transiciones separadas por
+// coma con el formato "origen->destino" u "origen->destino@actor|actor", donde los actores son
+// creator (el creador de la tarea) y admin. Sin actores, la transición la puede hacer cualquiera
+// que pueda editar la tarea.
+
+// DefaultWorkflow es el flujo por defecto: pending -> in_progress -> complete, con vuelta atrás
+// de in_progress a pending, y reapertura de tareas completadas solo por su creador
+const DefaultWorkflow = "pending->in_progress, in_progress->pending, in_progress->complete, complete->in_progress@creator, complete->pending@creator"
+
+// Actores de las transiciones
+const (
+	actorCreator = "creator"
+	actorAdmin   = "admin"
+)
+
+// Workflow es un flujo de estados validado: estado origen -> estado destino -> actores permitidos
+// (vacío: cualquiera que pueda editar la tarea)
+type Workflow struct {
+	transitions map[string]map[string][]string
+}
+
+// ParseWorkflow valida la definición de un flujo de estados. Una definición vacía usa DefaultWorkflow.
+func ParseWorkflow(spec string) (*Workflow, error) {
+	if strings.TrimSpace(spec) == "" {
+		spec = DefaultWorkflow
+	}
+	w := &Workflow{transitions: make(map[string]map[string][]string)}
+	for _, raw := range strings.Split(spec, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		edge, actorList, hasActors := strings.Cut(raw, "@")
+		from, to, ok := strings.Cut(edge, "->")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || !containsString(validTaskStatuses, from) || !containsString(validTaskStatuses, to) || from == to {
+			return nil, fmt.Errorf("transición inválida %q: debe ser origen->destino con estados distintos entre %s", raw, strings.Join(validTaskStatuses, ", "))
+		}
+		var actors []string
+		if hasActors {
+			for _, actor := range strings.Split(actorList, "|") {
+				actor = strings.TrimSpace(actor)
+				if actor != actorCreator && actor != actorAdmin {
+					return nil, fmt.Errorf("actor inválido %q en %q: debe ser %s o %s", actor, raw, actorCreator, actorAdmin)
+				}
+				actors = append(actors, actor)
+			}
+		}
+		if w.transitions[from] == nil {
+			w.transitions[from] = make(map[string][]string)
+		}
+		if _, dup := w.transitions[from][to]; dup {
+			return nil, fmt.Errorf("transición repetida %q", edge)
+		}
+		w.transitions[from][to] = actors
+	}
+	if len(w.transitions) == 0 {
+		return nil, fmt.Errorf("el flujo de estados no tiene transiciones")
+	}
+	return w, nil
+}
+
+// next devuelve, ordenados, los estados a los que se puede pasar desde from
+func (w *Workflow) next(from string) []string {
+	targets := make([]string, 0, len(w.transitions[from]))
+	for to := range w.transitions[from] {
+		targets = append(targets, to)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// checkTransition verifica que el usuario pueda llevar la tarea a su nuevo estado.
+// Devuelve 409 si el flujo no admite la transición y 403 si el usuario no es uno de sus actores.
+func (w *Workflow) checkTransition(task *models.Task, to, userID, role string) error {
+	actors, ok := w.transitions[task.Status][to]
+	if !ok {
+		allowed := w.next(task.Status)
+		message := fmt.Sprintf("No se puede pasar de '%s' a '%s'.", task.Status, to)
+		if len(allowed) > 0 {
+			message += fmt.Sprintf(" Desde '%s' solo se puede pasar a: %s.", task.Status, strings.Join(allowed, ", "))
+		}
+		return problem.New(fiber.StatusConflict, problem.CodeInvalidTransition, message).With("allowed_transitions", allowed)
+	}
+	if len(actors) == 0 {
+		return nil
+	}
+	for _, actor := range actors {
+		if (actor == actorCreator && task.CreatorID == userID) || (actor == actorAdmin && role == models.RoleAdmin) {
+			return nil
+		}
+	}
+	names := map[string]string{actorCreator: "el creador de la tarea", actorAdmin: "un administrador"}
+	var who []string
+	for _, actor := range actors {
+		who = append(who, names[actor])
+	}
+	return problem.FromStatus(fiber.StatusForbidden, fmt.Sprintf("Solo %s puede pasar la tarea de '%s' a '%s'.", strings.Join(who, " o "), task.Status, to))
+}
+
+// setStatusTimestamps actualiza started_at y completed_at al pasar la tarea de su estado actual
+// a to. started_at marca el primer inicio (se borra si vuelve a pending) y completed_at el cierre
+// (se borra al reabrirla).
+func setStatusTimestamps(task *models.Task, to string, now time.Time) {
+	switch to {
+	case "pending":
+		task.StartedAt = nil
+		task.CompletedAt = nil
+	case "in_progress":
+		if task.StartedAt == nil {
+			task.StartedAt = &now
+		}
+		task.CompletedAt = nil
+	case "complete":
+		if task.StartedAt == nil {
+			task.StartedAt = &now
+		}
+		task.CompletedAt = &now
+	}
+}
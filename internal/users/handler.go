@@ -0,0 +1,93 @@
+package users
+
+import (
+	"legendaryum/internal/problem"
+	"legendaryum/internal/revocation"
+	"legendaryum/internal/validation"
+	"legendaryum/pkg/models"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Handler maneja la administración de usuarios
+type Handler struct {
+	db          *gorm.DB
+	users       UserRepository
+	revocations *revocation.Store
+}
+
+// NewHandler crea una nueva instancia del handler de usuarios
+func NewHandler(db *gorm.DB, revocations *revocation.Store) *Handler {
+	return &Handler{
+		db:          db,
+		users:       NewPostgresUserRepository(db),
+		revocations: revocations,
+	}
+}
+
+// UpdateRole godoc
+// @Summary Cambiar el rol de un usuario
+// @Description Asigna el rol admin, member o viewer a un usuario. Solo disponible para administradores. Los access tokens vigentes del usuario se invalidan para que el nuevo rol se aplique al renovar la sesión.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "ID (UUID) del usuario"
+// @Param role body models.UpdateRoleRequest true "Nuevo rol"
+// @Security Bearer
+// @Success 200 {object} models.SuccessResponse "Rol actualizado"
+// @Failure 400 {object} problem.Problem "Rol inválido"
+// @Failure 401 {object} problem.Problem "No autorizado (token JWT faltante o inválido)"
+// @Failure 403 {object} problem.Problem "Solo un administrador puede cambiar roles"
+// @Failure 404 {object} problem.Problem "Usuario no encontrado"
+// @Failure 500 {object} problem.Problem "Error interno del servidor"
+// @Router /users/{id}/role [put]
+func (h *Handler) UpdateRole(c *fiber.Ctx) error {
+	var req models.UpdateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return problem.Respond(c, fiber.StatusBadRequest, problem.CodeInvalidJSON, "Error al procesar la solicitud: JSON inválido.")
+	}
+	if err := validation.Struct(&req); err != nil {
+		p := problem.New(fiber.StatusBadRequest, problem.CodeValidationFailed, "Datos inválidos: "+err.Error())
+		if errs, ok := err.(validation.Errors); ok {
+			p.With("errors", errs)
+		}
+		return p.Send(c)
+	}
+
+	// El repositorio responde ErrUserNotFound también para un ID que no es UUID
+	user, err := h.users.FindByID(c.UserContext(), c.Params("id"))
+	if err != nil {
+		if err == ErrUserNotFound {
+			return problem.Respond(c, fiber.StatusNotFound, problem.CodeNotFound, "Usuario no encontrado.")
+		}
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al obtener el usuario.")
+	}
+
+	if err := h.db.Model(user).Update("role", req.Role).Error; err != nil {
+		// Loggear error
+		return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al actualizar el rol.")
+	}
+	user.Role = req.Role
+
+	// El rol viaja en el access token: invalidar los emitidos para que el cambio
+	// se aplique en la próxima renovación y no recién al expirar el token.
+	if h.revocations != nil {
+		if err := h.revocations.RevokeAllForUser(user.ID, time.Now()); err != nil {
+			// Loggear error
+			return problem.Respond(c, fiber.StatusInternalServerError, problem.CodeInternal, "Error interno al invalidar las sesiones del usuario.")
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "success",
+		"message": "Rol actualizado exitosamente.",
+		"data": fiber.Map{
+			"id":    user.ID,
+			"email": user.Email,
+			"role":  user.Role,
+		},
+	})
+}
@@ -0,0 +1,134 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"legendaryum/pkg/database"
+	"legendaryum/pkg/models"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Almacenamiento de usuarios. Los servicios dependen de UserRepository y no de GORM, para poder
+// probar sus reglas con el repositorio en memoria sin una base de datos.
+
+// ErrUserNotFound indica que el usuario no existe
+var ErrUserNotFound = errors.New("usuario no encontrado")
+
+// ErrEmailTaken indica que ya existe un usuario con ese email
+var ErrEmailTaken = errors.New("el email ya está registrado")
+
+// UserRepository guarda y obtiene usuarios
+type UserRepository interface {
+	// FindByID obtiene un usuario por su ID. Devuelve ErrUserNotFound si no existe.
+	FindByID(ctx context.Context, id string) (*models.User, error)
+	// FindByEmail obtiene un usuario por su email. Devuelve ErrUserNotFound si no existe.
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	// Create guarda un usuario nuevo y completa su ID. Devuelve ErrEmailTaken si el email ya existe.
+	Create(ctx context.Context, user *models.User) error
+}
+
+// postgresUserRepository implementa UserRepository con GORM
+type postgresUserRepository struct {
+	db *gorm.DB
+}
+
+// NewPostgresUserRepository crea un repositorio de usuarios sobre la base de datos
+func NewPostgresUserRepository(db *gorm.DB) UserRepository {
+	return &postgresUserRepository{db: db}
+}
+
+func (r *postgresUserRepository) FindByID(ctx context.Context, id string) (*models.User, error) {
+	// Un ID que no es UUID no puede existir (y Postgres rechazaría la consulta)
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrUserNotFound
+	}
+	return r.first(ctx, "id = ?", id)
+}
+
+func (r *postgresUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.first(ctx, "email = ?", email)
+}
+
+func (r *postgresUserRepository) first(ctx context.Context, query string, args ...interface{}) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where(query, args...).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// usersEmailKey es la restricción única del email de los usuarios
+const usersEmailKey = "users_email_key"
+
+func (r *postgresUserRepository) Create(ctx context.Context, user *models.User) error {
+	// La restricción única resuelve también los registros simultáneos con el mismo email
+	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
+		if database.IsUniqueViolation(err, usersEmailKey) {
+			return ErrEmailTaken
+		}
+		return err
+	}
+	return nil
+}
+
+// MemoryUserRepository implementa UserRepository en memoria, para pruebas sin base de datos
+type MemoryUserRepository struct {
+	mu    sync.Mutex
+	users map[string]models.User
+}
+
+// NewMemoryUserRepository crea un repositorio de usuarios vacío en memoria
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: make(map[string]models.User)}
+}
+
+func (r *MemoryUserRepository) FindByID(ctx context.Context, id string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return ErrEmailTaken
+		}
+	}
+	if user.ID == "" {
+		user.ID = uuid.NewString()
+	}
+	if user.Role == "" {
+		user.Role = models.RoleMember
+	}
+	now := time.Now().UTC()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	user.UpdatedAt = now
+	r.users[user.ID] = *user
+	return nil
+}
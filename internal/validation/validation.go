@@ -0,0 +1,226 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Validación de las solicitudes según los tags `validate` de sus structs. Soporta las reglas:
+//
+//	required   el campo no puede estar vacío (en strings, tampoco solo espacios)
+//	omitempty  si el campo está vacío no se aplican las demás reglas
+//	oneof=a b  el valor debe ser uno de los indicados
+//	min=n      largo mínimo (strings en caracteres, slices en elementos) o valor mínimo (números)
+//	max=n      largo o valor máximo
+//	email      dirección de email válida
+//	uuid       UUID válido
+//	hexcolor   color hexadecimal (#RGB o #RRGGBB)
+//	letters    solo letras (incluidas las acentuadas) y espacios
+//
+// Los punteros nil solo fallan con required; si no son nil se valida el valor apuntado.
+// El nombre del campo en los errores es el de su tag json.
+
+// FieldError describe un campo que no cumple sus reglas de validación
+type FieldError struct {
+	Field string `json:"field" example:"priority"`
+	Error string `json:"error" example:"debe ser uno de: low, medium, high"`
+}
+
+// Errors es la lista de errores de validación de una solicitud
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fmt.Sprintf("'%s' %s", fe.Field, fe.Error)
+	}
+	return strings.Join(parts, "; ")
+}
+
+var (
+	hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+	lettersPattern  = regexp.MustCompile(`^[a-zA-ZáéíóúÁÉÍÓÚüÜñÑ\s]+$`)
+)
+
+// Struct valida un struct (o un puntero a struct) y devuelve Errors con todos los campos
+// inválidos, en el orden en que están declarados, o nil si es válido
+func Struct(s interface{}) error {
+	v := reflect.ValueOf(s)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation.Struct: se esperaba un struct y se recibió %s", v.Kind()))
+	}
+
+	var errs Errors
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" || tag == "-" || !field.IsExported() {
+			continue
+		}
+		if msg := checkField(v.Field(i), strings.Split(tag, ",")); msg != "" {
+			errs = append(errs, FieldError{Field: jsonName(field), Error: msg})
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// jsonName devuelve el nombre con el que el campo se expone en JSON
+func jsonName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+// checkField aplica las reglas al valor y devuelve el mensaje de la primera que falla
+func checkField(v reflect.Value, rules []string) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if containsRule(rules, "required") {
+				return "es requerido"
+			}
+			return ""
+		}
+		v = v.Elem()
+	} else if isEmpty(v) {
+		if containsRule(rules, "required") {
+			return "es requerido"
+		}
+		if containsRule(rules, "omitempty") {
+			return ""
+		}
+	}
+
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if msg := checkRule(v, name, param); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+// checkRule aplica una regla y devuelve el mensaje de error si no se cumple
+func checkRule(v reflect.Value, name, param string) string {
+	switch name {
+	case "", "required", "omitempty":
+		return ""
+	case "oneof":
+		options := strings.Fields(param)
+		value := fmt.Sprint(v.Interface())
+		for _, option := range options {
+			if value == option {
+				return ""
+			}
+		}
+		return "debe ser uno de: " + strings.Join(options, ", ")
+	case "min", "max":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validation: parámetro inválido en %s=%s", name, param))
+		}
+		// El mínimo no cuenta los espacios de los extremos; el máximo sí, porque se guardan
+		size, unit, ok := measure(v, name == "min")
+		if !ok {
+			return ""
+		}
+		switch {
+		case name == "min" && size < limit && unit == "":
+			return "debe ser como mínimo " + param
+		case name == "min" && size < limit:
+			return fmt.Sprintf("debe tener al menos %s%s", param, unit)
+		case name == "max" && size > limit && unit == "":
+			return "debe ser como máximo " + param
+		case name == "max" && size > limit:
+			return fmt.Sprintf("no puede superar los %s%s", param, unit)
+		}
+		return ""
+	case "email":
+		if addr, err := mail.ParseAddress(v.String()); err != nil || addr.Address != v.String() {
+			return "debe ser un email válido"
+		}
+		return ""
+	case "uuid":
+		if _, err := uuid.Parse(v.String()); err != nil {
+			return "debe ser un UUID válido"
+		}
+		return ""
+	case "hexcolor":
+		if !hexColorPattern.MatchString(v.String()) {
+			return "debe ser un color hexadecimal (#RGB o #RRGGBB)"
+		}
+		return ""
+	case "letters":
+		if !lettersPattern.MatchString(v.String()) {
+			return "solo puede contener letras y espacios"
+		}
+		return ""
+	default:
+		panic(fmt.Sprintf("validation: regla desconocida %q", name))
+	}
+}
+
+// measure devuelve el tamaño que comparan min y max y la unidad para el mensaje
+func measure(v reflect.Value, trim bool) (float64, string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		value := v.String()
+		if trim {
+			value = strings.TrimSpace(value)
+		}
+		return float64(utf8.RuneCountInString(value)), " caracteres", true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), " elementos", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), "", true
+	}
+	return 0, "", false
+}
+
+// isEmpty indica si el valor es el valor cero de su tipo (los strings con solo espacios cuentan como
+// vacíos y los tipos con IsZero, como time.Time, usan ese método)
+func isEmpty(v reflect.Value) bool {
+	if v.CanInterface() {
+		if z, ok := v.Interface().(interface{ IsZero() bool }); ok {
+			return z.IsZero()
+		}
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+func containsRule(rules []string, rule string) bool {
+	for _, r := range rules {
+		if strings.TrimSpace(r) == rule {
+			return true
+		}
+	}
+	return false
+}
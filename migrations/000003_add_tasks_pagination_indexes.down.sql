@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_tasks_assignee_due_date;
+DROP INDEX IF EXISTS idx_tasks_creator_due_date;
+DROP INDEX IF EXISTS idx_tasks_assignee_created_at;
+DROP INDEX IF EXISTS idx_tasks_creator_created_at;
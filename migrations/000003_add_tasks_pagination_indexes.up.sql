@@ -0,0 +1,7 @@
+-- Índices compuestos para la paginación por cursor del listado de tareas.
+-- Cubren el filtro de visibilidad (creador/asignado) junto con los órdenes más usados;
+-- el id final actúa como desempate estable.
+CREATE INDEX IF NOT EXISTS idx_tasks_creator_created_at ON tasks(creator_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_tasks_assignee_created_at ON tasks(assignee_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_tasks_creator_due_date ON tasks(creator_id, due_date, id);
+CREATE INDEX IF NOT EXISTS idx_tasks_assignee_due_date ON tasks(assignee_id, due_date, id);
@@ -0,0 +1,23 @@
+-- Historial de cambios de tareas. No tiene FK a tasks ni a users para que
+-- el historial sobreviva al borrado de la tarea o del usuario.
+CREATE TABLE task_events (
+    id BIGSERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL,
+    actor_id UUID NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('created', 'updated', 'deleted')),
+    changes JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_events_task_id ON task_events(task_id, id);
+
+-- La tabla es de solo inserción: se rechaza cualquier UPDATE o DELETE
+CREATE OR REPLACE FUNCTION task_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'task_events es de solo inserción';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_task_events_append_only
+    BEFORE UPDATE OR DELETE ON task_events
+    FOR EACH ROW EXECUTE FUNCTION task_events_append_only();
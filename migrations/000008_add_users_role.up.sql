@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'member'
+    CONSTRAINT chk_users_role CHECK (role IN ('admin', 'member', 'viewer'));
@@ -0,0 +1,4 @@
+-- Subtareas: referencia a la tarea padre. Borrar el padre borra sus subtareas.
+ALTER TABLE tasks ADD COLUMN parent_id INTEGER REFERENCES tasks(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_tasks_parent_id ON tasks(parent_id, id);
@@ -0,0 +1,25 @@
+-- Etiquetas personales (owner_id) o de proyecto (project_id), nunca ambas
+CREATE TABLE labels (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#808080'
+        CONSTRAINT chk_labels_color CHECK (color ~ '^#[0-9a-fA-F]{6}$'),
+    owner_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    project_id INTEGER REFERENCES projects(id) ON DELETE CASCADE,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_labels_scope CHECK ((owner_id IS NULL) <> (project_id IS NULL))
+);
+
+-- Nombres únicos (sin distinguir mayúsculas) dentro de cada ámbito
+CREATE UNIQUE INDEX idx_labels_owner_name ON labels(owner_id, lower(name)) WHERE owner_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_labels_project_name ON labels(project_id, lower(name)) WHERE project_id IS NOT NULL;
+
+CREATE TABLE task_labels (
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    label_id INTEGER NOT NULL REFERENCES labels(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, label_id)
+);
+
+CREATE INDEX idx_task_labels_label_id ON task_labels(label_id, task_id);
@@ -0,0 +1,13 @@
+-- Vistas guardadas: filtros y orden del listado de tareas con nombre, por usuario
+CREATE TABLE saved_views (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    filters JSONB NOT NULL DEFAULT '{}',
+    sort VARCHAR(200) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Nombres únicos (sin distinguir mayúsculas) por usuario
+CREATE UNIQUE INDEX idx_saved_views_user_name ON saved_views(user_id, lower(name));
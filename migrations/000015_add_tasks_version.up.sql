@@ -0,0 +1,2 @@
+-- Control de concurrencia optimista: la versión aumenta con cada cambio y se expone como ETag
+ALTER TABLE tasks ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,13 @@
+-- El historial es de solo inserción: los eventos 'restored' existentes se conservan (NOT VALID)
+ALTER TABLE task_events DROP CONSTRAINT IF EXISTS task_events_action_check;
+ALTER TABLE task_events ADD CONSTRAINT task_events_action_check CHECK (action IN ('created', 'updated', 'deleted')) NOT VALID;
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_assignee_id_fkey;
+ALTER TABLE tasks ADD CONSTRAINT tasks_assignee_id_fkey FOREIGN KEY (assignee_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_creator_id_fkey;
+ALTER TABLE tasks ADD CONSTRAINT tasks_creator_id_fkey FOREIGN KEY (creator_id) REFERENCES users(id) ON DELETE CASCADE;
+
+-- Sin la columna, las tareas en la papelera volverían a estar visibles
+DELETE FROM tasks WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS idx_tasks_deleted_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,13 @@
+-- Borrado lógico: las tareas eliminadas quedan en la papelera hasta que se purgan
+ALTER TABLE tasks ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX idx_tasks_deleted_at ON tasks(deleted_at);
+
+-- Borrar un usuario ya no elimina sus tareas en cascada
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_creator_id_fkey;
+ALTER TABLE tasks ADD CONSTRAINT tasks_creator_id_fkey FOREIGN KEY (creator_id) REFERENCES users(id) ON DELETE RESTRICT;
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_assignee_id_fkey;
+ALTER TABLE tasks ADD CONSTRAINT tasks_assignee_id_fkey FOREIGN KEY (assignee_id) REFERENCES users(id) ON DELETE RESTRICT;
+
+-- Nueva acción del historial al restaurar una tarea de la papelera
+ALTER TABLE task_events DROP CONSTRAINT IF EXISTS task_events_action_check;
+ALTER TABLE task_events ADD CONSTRAINT task_events_action_check CHECK (action IN ('created', 'updated', 'deleted', 'restored'));
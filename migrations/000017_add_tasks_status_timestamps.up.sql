@@ -0,0 +1,7 @@
+-- Momentos en que la tarea se inició y se completó, mantenidos por el flujo de estados
+ALTER TABLE tasks ADD COLUMN started_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE tasks ADD COLUMN completed_at TIMESTAMP WITH TIME ZONE;
+
+-- Las tareas existentes no tienen el dato exacto: se usa su última actualización
+UPDATE tasks SET started_at = updated_at WHERE status IN ('in_progress', 'complete');
+UPDATE tasks SET completed_at = updated_at WHERE status = 'complete';
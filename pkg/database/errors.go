@@ -0,0 +1,18 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolation es el código de Postgres de una violación de restricción única
+const uniqueViolation = "23505"
+
+// IsUniqueViolation indica si err es una violación de la restricción única (o del índice único)
+// indicado. Permite traducir a un error de dominio los duplicados que se crean entre la
+// verificación previa y la escritura, que solo detecta la base.
+func IsUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == constraint
+}
@@ -1,20 +1,33 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"os"
 
 	"legendaryum/internal/config"
 
 	migrate "github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"gorm.io/gorm"
 )
 
 // Gestión de migracionesok, continuemos con lo recomendado
 
 // RunMigrations ejecuta las migraciones de la carpeta ./migrations
 func RunMigrations(cfg *config.Config) {
+	if err := Migrate(cfg, "./migrations"); err != nil {
+		log.Fatalf("%v", err)
+	}
+	log.Println("Migraciones aplicadas correctamente")
+}
+
+// Migrate aplica las migraciones pendientes de la carpeta dir
+func Migrate(cfg *config.Config, dir string) error {
 	url := fmt.Sprintf(
 		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
 		cfg.DBUser,
@@ -25,14 +38,53 @@ func RunMigrations(cfg *config.Config) {
 	)
 
 	m, err := migrate.New(
-		"file://./migrations",
+		"file://"+dir,
 		url,
 	)
 	if err != nil {
-		log.Fatalf("No se pudo inicializar migrate: %v", err)
+		return fmt.Errorf("no se pudo inicializar migrate: %w", err)
 	}
+	defer m.Close()
 	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatalf("Error al aplicar migraciones: %v", err)
+		return fmt.Errorf("error al aplicar migraciones: %w", err)
 	}
-	log.Println("Migraciones aplicadas correctamente")
+	return nil
+}
+
+// LatestMigration devuelve la versión de la última migración de la carpeta dir
+func LatestMigration(dir string) (uint, error) {
+	src, err := source.Open("file://" + dir)
+	if err != nil {
+		return 0, fmt.Errorf("no se pudieron leer las migraciones: %w", err)
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("no se pudieron leer las migraciones: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("no se pudieron leer las migraciones: %w", err)
+		}
+		version = next
+	}
+}
+
+// MigrationVersion devuelve la versión del esquema registrada por golang-migrate (0 si no se aplicó
+// ninguna) y si su última migración quedó a medio aplicar
+func MigrationVersion(ctx context.Context, db *gorm.DB) (version uint, dirty bool, err error) {
+	var state struct {
+		Version uint
+		Dirty   bool
+	}
+	// schema_migrations es la tabla de control de golang-migrate: tiene una sola fila
+	if err := db.WithContext(ctx).Raw("SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&state).Error; err != nil {
+		return 0, false, err
+	}
+	return state.Version, state.Dirty, nil
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"legendaryum/internal/config"
+	"legendaryum/pkg/models"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -13,6 +14,15 @@ import (
 
 // NewPostgres crea una nueva conexión a PostgreSQL usando GORM
 func NewPostgres(cfg *config.Config) *gorm.DB {
+	db, err := Open(cfg)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	return db
+}
+
+// Open crea una nueva conexión a PostgreSQL usando GORM y configura el pool de conexiones
+func Open(cfg *config.Config) (*gorm.DB, error) {
 	dsn := fmt.Sprintf(
 		"host=%s port=%s user=%s dbname=%s password=%s sslmode=disable",
 		cfg.DBHost,
@@ -23,12 +33,12 @@ func NewPostgres(cfg *config.Config) *gorm.DB {
 	)
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
-		log.Fatalf("No se pudo conectar a la base de datos: %v", err)
+		return nil, fmt.Errorf("no se pudo conectar a la base de datos: %w", err)
 	}
 
 	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Error al obtener el pool de conexiones: %v", err)
+		return nil, fmt.Errorf("error al obtener el pool de conexiones: %w", err)
 	}
 
 	// Configuración del pool de conexiones
@@ -36,5 +46,10 @@ func NewPostgres(cfg *config.Config) *gorm.DB {
 	sqlDB.SetMaxIdleConns(25)
 	sqlDB.SetConnMaxLifetime(5 * time.Minute)
 
-	return db
+	return db, nil
+}
+
+// AutoMigrate crea o actualiza las tablas de los modelos que no cubren las migraciones
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(&models.User{}, &models.Task{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.TaskComment{}, &models.TaskEvent{}, &models.Project{}, &models.ProjectMember{}, &models.TaskDependency{}, &models.Label{}, &models.SavedView{})
 }
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// TaskComment representa un comentario sobre una tarea
+type TaskComment struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	TaskID    uint      `json:"task_id" gorm:"not null"`
+	AuthorID  string    `json:"author_id" gorm:"type:uuid;not null;index"`
+	Author    User      `json:"author" gorm:"foreignKey:AuthorID"`
+	Body      string    `json:"body" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaskCommentRequest representa la estructura para crear/editar un comentario
+type TaskCommentRequest struct {
+	Body string `json:"body" validate:"required"`
+}
+
+// TaskCommentListResponse representa la respuesta paginada del listado de comentarios
+type TaskCommentListResponse struct {
+	Status  string        `json:"status" example:"success"`
+	Message string        `json:"message" example:"Comentarios obtenidos exitosamente."`
+	Data    []TaskComment `json:"data"`
+	Meta    PageMeta      `json:"meta"`
+}
@@ -1,9 +1,6 @@
 package models
 
-type ErrorResponse struct {
-	Status  string `json:"status" example:"error"`               // Estado de la respuesta (generalmente "error")
-	Message string `json:"message" example:"Detalles del error"` // Mensaje descriptivo del error
-}
+// Las respuestas de error usan problem.Problem (application/problem+json)
 
 type SuccessResponse struct {
 	Status  string `json:"status" example:"success"`            // Estado de la respuesta (generalmente "success")
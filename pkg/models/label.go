@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// Label es una etiqueta personal (OwnerID) o de proyecto (ProjectID) que se asocia a tareas
+type Label struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name      string    `json:"name" gorm:"size:50;not null"`
+	Color     string    `json:"color" gorm:"size:7;not null;default:'#808080'"`
+	OwnerID   *string   `json:"owner_id" gorm:"type:uuid;index"`
+	ProjectID *uint     `json:"project_id" gorm:"index"`
+	CreatedBy string    `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LabelRequest representa la estructura para crear/actualizar una etiqueta.
+// Si project_id se indica (solo al crear) la etiqueta pertenece al proyecto; si no, es personal.
+type LabelRequest struct {
+	Name      string `json:"name" validate:"required,max=50"`
+	Color     string `json:"color" validate:"omitempty,hexcolor"`
+	ProjectID *uint  `json:"project_id"`
+}
+
+// BulkLabelRequest representa la estructura para agregar o quitar etiquetas de varias tareas a la vez
+type BulkLabelRequest struct {
+	TaskIDs []uint `json:"task_ids" validate:"required"`
+	Add     []uint `json:"add"`
+	Remove  []uint `json:"remove"`
+}
+
+// BulkLabelResponse resume el resultado de una aplicación masiva de etiquetas
+type BulkLabelResponse struct {
+	Tasks   int   `json:"tasks"`
+	Added   int64 `json:"added"`
+	Removed int64 `json:"removed"`
+}
@@ -0,0 +1,17 @@
+package models
+
+// PageMeta contiene los metadatos de un listado paginado por cursor
+type PageMeta struct {
+	Total      int64   `json:"total" example:"135"`                  // Total de elementos que cumplen los filtros
+	Limit      int     `json:"limit" example:"20"`                   // Cantidad máxima de elementos por página
+	Sort       string  `json:"sort" example:"due_date,-priority,id"` // Orden aplicado (siempre termina en id)
+	NextCursor *string `json:"next_cursor"`                          // Cursor de la página siguiente (null si no hay más)
+}
+
+// TaskListResponse representa la respuesta paginada del listado de tareas
+type TaskListResponse struct {
+	Status  string   `json:"status" example:"success"`
+	Message string   `json:"message" example:"Tareas obtenidas exitosamente."`
+	Data    []Task   `json:"data"`
+	Meta    PageMeta `json:"meta"`
+}
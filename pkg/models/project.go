@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// Roles dentro de un proyecto
+const (
+	ProjectRoleOwner  = "owner"  // Gestiona el proyecto y sus miembros
+	ProjectRoleMember = "member" // Ve todas las tareas del proyecto y puede crear tareas en él
+)
+
+// Project agrupa tareas y miembros
+type Project struct {
+	ID          uint            `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name        string          `json:"name" gorm:"size:100;not null"`
+	Description string          `json:"description" gorm:"not null;default:''"`
+	OwnerID     string          `json:"owner_id" gorm:"type:uuid;not null;index"`
+	Members     []ProjectMember `json:"members,omitempty" gorm:"foreignKey:ProjectID"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// ProjectMember representa la membresía de un usuario en un proyecto
+type ProjectMember struct {
+	ProjectID uint      `json:"project_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID    string    `json:"user_id" gorm:"type:uuid;primaryKey"`
+	User      *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Role      string    `json:"role" gorm:"size:20;not null;default:'member'"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ProjectRequest representa la estructura para crear/actualizar un proyecto
+type ProjectRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description"`
+}
+
+// ProjectMemberRequest representa la estructura para agregar un miembro a un proyecto
+type ProjectMemberRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+	Role   string `json:"role" validate:"omitempty,oneof=owner member"`
+}
+
+// ProjectListResponse representa la respuesta paginada del listado de proyectos
+type ProjectListResponse struct {
+	Status  string    `json:"status" example:"success"`
+	Message string    `json:"message" example:"Proyectos obtenidos exitosamente."`
+	Data    []Project `json:"data"`
+	Meta    PageMeta  `json:"meta"`
+}
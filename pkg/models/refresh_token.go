@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken representa un refresh token opaco persistido (solo se guarda su hash).
+// Todos los tokens obtenidos por rotación a partir del mismo login comparten FamilyID.
+type RefreshToken struct {
+	ID         string     `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID     string     `gorm:"type:uuid;not null;index" json:"user_id"`
+	FamilyID   string     `gorm:"type:uuid;not null;index" json:"family_id"`
+	TokenHash  string     `gorm:"size:64;unique;not null" json:"-"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	ReplacedBy *string    `gorm:"type:uuid" json:"replaced_by,omitempty"` // Token emitido al rotar este
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// RevokedToken representa un access token (identificado por su jti) revocado antes de expirar
+type RevokedToken struct {
+	JTI       string    `gorm:"column:jti;type:uuid;primaryKey" json:"jti"`
+	UserID    string    `gorm:"type:uuid;not null" json:"user_id"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	RevokedAt time.Time `gorm:"autoCreateTime" json:"revoked_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+// Roles de usuario y permisos asociados
+
+const (
+	RoleAdmin  = "admin"  // Gestiona cualquier tarea y los roles de los usuarios
+	RoleMember = "member" // Gestiona sus propias tareas (rol por defecto)
+	RoleViewer = "viewer" // Solo lectura
+)
+
+// Permission identifica una acción protegida
+type Permission string
+
+const (
+	PermTasksRead      Permission = "tasks:read"       // Ver tareas visibles para el usuario
+	PermTasksWrite     Permission = "tasks:write"      // Crear, actualizar y eliminar tareas propias
+	PermTasksManageAll Permission = "tasks:manage_all" // Ver y gestionar cualquier tarea
+	PermCommentsWrite  Permission = "comments:write"   // Comentar tareas
+	PermUsersManage    Permission = "users:manage"     // Cambiar el rol de otros usuarios
+)
+
+// RolePermissions define los permisos de cada rol
+var RolePermissions = map[string][]Permission{
+	RoleAdmin:  {PermTasksRead, PermTasksWrite, PermTasksManageAll, PermCommentsWrite, PermUsersManage},
+	RoleMember: {PermTasksRead, PermTasksWrite, PermCommentsWrite},
+	RoleViewer: {PermTasksRead},
+}
+
+// HasPermission indica si el rol tiene el permiso indicado
+func HasPermission(role string, perm Permission) bool {
+	for _, p := range RolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateRoleRequest representa la estructura para cambiar el rol de un usuario
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=admin member viewer"`
+}
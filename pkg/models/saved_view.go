@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// SavedView es un listado de tareas guardado por un usuario: búsqueda, filtros y orden con un nombre.
+// Los filtros usan los mismos parámetros que GET /tasks (por ejemplo {"status": "in:pending,in_progress", "assignee": "me"}).
+type SavedView struct {
+	ID        uint              `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    string            `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name      string            `json:"name" gorm:"size:100;not null"`
+	Filters   map[string]string `json:"filters" gorm:"type:jsonb;not null;serializer:json"`
+	Sort      string            `json:"sort" gorm:"size:200;not null;default:''"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// SavedViewRequest representa la estructura para crear/actualizar una vista guardada
+type SavedViewRequest struct {
+	Name    string            `json:"name" validate:"required,max=100"`
+	Filters map[string]string `json:"filters"`
+	Sort    string            `json:"sort"`
+}
+
+// SavedViewListResponse representa la respuesta paginada del listado de vistas guardadas
+type SavedViewListResponse struct {
+	Status  string      `json:"status" example:"success"`
+	Message string      `json:"message" example:"Vistas obtenidas exitosamente."`
+	Data    []SavedView `json:"data"`
+	Meta    PageMeta    `json:"meta"`
+}
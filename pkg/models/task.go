@@ -2,44 +2,67 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Task representa una tarea en el sistema
 type Task struct {
-	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
-	Title       string    `json:"title" gorm:"not null"`
-	Description string    `json:"description" gorm:"not null"`
-	Status      string    `json:"status" gorm:"not null;default:'pending'"`
-	Priority    string    `json:"priority" gorm:"not null;default:'medium'"`
-	DueDate     time.Time `json:"due_date" gorm:"not null"`
-	CreatorID   string    `json:"creator_id" gorm:"not null"` // ID del creador
-	AssigneeID  string    `json:"assignee_id" gorm:"not null"`
-	Creator     User      `json:"creator" gorm:"foreignKey:CreatorID"`
-	Assignee    User      `json:"assignee" gorm:"foreignKey:AssigneeID"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	Title       string         `json:"title" gorm:"not null"`
+	Description string         `json:"description" gorm:"not null"`
+	Status      string         `json:"status" gorm:"not null;default:'pending'"`
+	Priority    string         `json:"priority" gorm:"not null;default:'medium'"`
+	DueDate     time.Time      `json:"due_date" gorm:"not null"`
+	CreatorID   string         `json:"creator_id" gorm:"not null"` // ID del creador
+	AssigneeID  string         `json:"assignee_id" gorm:"not null"`
+	ProjectID   *uint          `json:"project_id"`                        // Proyecto al que pertenece (opcional)
+	ParentID    *uint          `json:"parent_id"`                         // Tarea padre si es una subtarea
+	Version     int            `json:"version" gorm:"not null;default:1"` // Aumenta con cada cambio; se expone como ETag
+	StartedAt   *time.Time     `json:"started_at"`                        // Primera vez que pasó a in_progress
+	CompletedAt *time.Time     `json:"completed_at"`                      // Cuándo se completó (null si está abierta)
+	Creator     User           `json:"creator" gorm:"foreignKey:CreatorID"`
+	Assignee    User           `json:"assignee" gorm:"foreignKey:AssigneeID"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index" swaggertype:"string" format:"date-time"` // En la papelera si no es null
+	Labels      []Label        `json:"labels" gorm:"many2many:task_labels"`
+	Subtasks    []Task         `json:"subtasks,omitempty" gorm:"foreignKey:ParentID"` // Solo con ?include=subtasks
+	Progress    *int           `json:"progress,omitempty" gorm:"-"`                   // % de subtareas directas completadas
+	Rank        *float64       `json:"rank,omitempty" gorm:"->;-:migration"`          // Relevancia, solo en búsquedas (?q=)
+	Snippet     *string        `json:"snippet,omitempty" gorm:"-"`                    // Fragmento resaltado (HTML escapado con <mark>), solo en búsquedas (?q=)
+	// Columna generada para la búsqueda de texto completo (ver migración 000013). No se lee ni se escribe.
+	SearchVector string `json:"-" gorm:"->:false;<-:false;type:tsvector GENERATED ALWAYS AS (setweight(to_tsvector('spanish', coalesce(title, '')), 'A') || setweight(to_tsvector('spanish', coalesce(description, '')), 'B')) STORED"`
 }
 
 // TaskRequest representa la estructura para crear/actualizar una tarea
 type TaskRequest struct {
-	Title       string    `json:"title" validate:"required"`
+	Title       string    `json:"title" validate:"required,max=200"`
 	Description string    `json:"description" validate:"required"`
 	Status      string    `json:"status" validate:"omitempty,oneof=pending in_progress complete"`
 	Priority    string    `json:"priority" validate:"omitempty,oneof=low medium high"`
 	DueDate     time.Time `json:"due_date" validate:"required"`
-	AssigneeID  string    `json:"assignee_id"`
+	AssigneeID  string    `json:"assignee_id" validate:"omitempty,uuid"`
+	ProjectID   *uint     `json:"project_id"` // Opcional; 0 o ausente: sin proyecto
+	ParentID    *uint     `json:"parent_id"`  // Opcional; 0 o ausente: tarea de primer nivel
 }
 
 // TaskResponse representa la estructura de respuesta para una tarea
 type TaskResponse struct {
-	ID          uint      `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Status      string    `json:"status"`
-	Priority    string    `json:"priority"`
-	DueDate     time.Time `json:"due_date"`
-	CreatorID   string    `json:"creator_id"`
-	AssigneeID  string    `json:"assignee_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint       `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	Priority    string     `json:"priority"`
+	DueDate     time.Time  `json:"due_date"`
+	CreatorID   string     `json:"creator_id"`
+	AssigneeID  string     `json:"assignee_id"`
+	ProjectID   *uint      `json:"project_id"`
+	ParentID    *uint      `json:"parent_id"`
+	Progress    *int       `json:"progress,omitempty"`
+	Version     int        `json:"version"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 }
@@ -0,0 +1,63 @@
+package models
+
+import "encoding/json"
+
+// Modos de ejecución de las operaciones masivas sobre tareas
+const (
+	BulkModeAtomic     = "atomic"      // Todo o nada: si una operación falla no se aplica ninguna
+	BulkModeBestEffort = "best_effort" // Se aplican las operaciones válidas y se informan las que fallan
+)
+
+// Operaciones admitidas por POST /tasks/bulk
+const (
+	BulkOpUpdate   = "update"
+	BulkOpDelete   = "delete"
+	BulkOpReassign = "reassign"
+)
+
+// Resultado de cada operación en el reporte de POST /tasks/bulk
+const (
+	BulkResultOK         = "ok"
+	BulkResultFailed     = "failed"
+	BulkResultRolledBack = "rolled_back" // Se aplicó pero se deshizo porque otra operación falló (modo atomic)
+	BulkResultSkipped    = "skipped"     // No se intentó porque una operación anterior falló (modo atomic)
+)
+
+// BulkTaskRequest representa una lista de operaciones sobre tareas, o un filtro más un patch
+// que se aplica a todas las tareas que lo cumplen
+type BulkTaskRequest struct {
+	Mode       string              `json:"mode" example:"atomic"` // atomic (por defecto) o best_effort
+	Operations []BulkTaskOperation `json:"operations"`
+	Filter     map[string]string   `json:"filter"`                     // Mismos filtros que GET /tasks (sin sort, limit ni cursor)
+	Patch      json.RawMessage     `json:"patch" swaggertype:"object"` // Merge patch para las tareas del filtro
+}
+
+// BulkTaskOperation representa una operación sobre una tarea dentro de POST /tasks/bulk
+type BulkTaskOperation struct {
+	Op         string          `json:"op" example:"update"` // update, delete o reassign
+	ID         uint            `json:"id" example:"1"`
+	Patch      json.RawMessage `json:"patch,omitempty" swaggertype:"object"` // Merge patch (solo update)
+	AssigneeID string          `json:"assignee_id,omitempty"`                // Nuevo asignado (solo reassign)
+	Version    *int            `json:"version,omitempty"`                    // Versión esperada (opcional, equivale a If-Match)
+}
+
+// BulkTaskResult informa el resultado de una operación de POST /tasks/bulk
+type BulkTaskResult struct {
+	Index   int                    `json:"index"`
+	ID      uint                   `json:"id"`
+	Op      string                 `json:"op"`
+	Result  string                 `json:"result" example:"ok"` // ok, failed, rolled_back o skipped
+	Status  int                    `json:"status,omitempty"`    // Código HTTP que tendría la operación individual
+	Code    string                 `json:"code,omitempty"`      // Código de error estable (el mismo que en application/problem+json)
+	Message string                 `json:"message,omitempty"`   // Motivo del fallo
+	Version *int                   `json:"version,omitempty"`   // Versión de la tarea tras la operación
+	Details map[string]interface{} `json:"details,omitempty"`   // Información extra del fallo (ej: blocked_by)
+}
+
+// BulkTaskResponse resume el resultado de POST /tasks/bulk
+type BulkTaskResponse struct {
+	Mode      string           `json:"mode"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkTaskResult `json:"results"`
+}
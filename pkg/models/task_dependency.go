@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// TaskDependency indica que TaskID está bloqueada por BlockedByID
+type TaskDependency struct {
+	TaskID      uint      `json:"task_id" gorm:"primaryKey;autoIncrement:false"`
+	BlockedByID uint      `json:"blocked_by_id" gorm:"primaryKey;autoIncrement:false;index"`
+	CreatedBy   string    `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TaskDependencyRequest representa la estructura para agregar un bloqueante a una tarea
+type TaskDependencyRequest struct {
+	BlockedByID uint `json:"blocked_by_id" validate:"required"`
+}
+
+// TaskBlocker resume una tarea relacionada por una dependencia
+type TaskBlocker struct {
+	ID     uint   `json:"id"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+}
+
+// TaskDependenciesResponse lista las tareas que bloquean a una tarea y las que ella bloquea
+type TaskDependenciesResponse struct {
+	BlockedBy []TaskBlocker `json:"blocked_by"`
+	Blocking  []TaskBlocker `json:"blocking"`
+}
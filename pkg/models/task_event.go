@@ -0,0 +1,69 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Acciones registradas en el historial de tareas
+const (
+	TaskEventCreated  = "created"
+	TaskEventUpdated  = "updated"
+	TaskEventDeleted  = "deleted"
+	TaskEventRestored = "restored"
+)
+
+// FieldChange representa el valor anterior y el nuevo de un campo
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// TaskChanges agrupa los cambios de un evento por nombre de campo (columna JSONB)
+type TaskChanges map[string]FieldChange
+
+// Value serializa los cambios a JSON para guardarlos en la base de datos
+func (c TaskChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	raw, err := json.Marshal(c)
+	return string(raw), err
+}
+
+// Scan lee los cambios desde la columna JSONB
+func (c *TaskChanges) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	case nil:
+		*c = TaskChanges{}
+		return nil
+	default:
+		return errors.New("tipo no soportado para TaskChanges")
+	}
+	return json.Unmarshal(raw, c)
+}
+
+// TaskEvent representa una entrada del historial (solo inserción) de una tarea
+type TaskEvent struct {
+	ID        uint64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	TaskID    uint        `json:"task_id" gorm:"not null"`
+	ActorID   string      `json:"actor_id" gorm:"type:uuid;not null"`
+	Action    string      `json:"action" gorm:"size:20;not null"`
+	Changes   TaskChanges `json:"changes" gorm:"type:jsonb;not null;default:'{}'"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// TaskEventListResponse representa la respuesta paginada del historial de una tarea
+type TaskEventListResponse struct {
+	Status  string      `json:"status" example:"success"`
+	Message string      `json:"message" example:"Historial obtenido exitosamente."`
+	Data    []TaskEvent `json:"data"`
+	Meta    PageMeta    `json:"meta"`
+}
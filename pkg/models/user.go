@@ -11,6 +11,9 @@ type User struct {
 	LastName     string    `gorm:"size:50;not null" json:"last_name"`
 	Email        string    `gorm:"size:255;unique;not null" json:"email"`
 	PasswordHash string    `gorm:"size:255;not null" json:"-"`
+	Role         string    `gorm:"size:20;not null;default:'member'" json:"role"`
 	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	// Los access tokens emitidos antes de este instante son inválidos (logout-all)
+	TokensValidAfter *time.Time `json:"-"`
 }
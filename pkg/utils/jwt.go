@@ -5,18 +5,26 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // Utilidades para manejo de JWT
 
+func init() {
+	// Emitir iat/exp con precisión de milisegundos para poder compararlos con la
+	// marca de "tokens válidos después de" que se fija en el logout-all
+	jwt.TimePrecision = time.Millisecond
+}
+
 // Claims representa la estructura de datos del token JWT
 type Claims struct {
 	UserID string `json:"user_id"`
+	Role   string `json:"role"`
 	jwt.RegisteredClaims
 }
 
 // GenerateJWT genera un nuevo token JWT
-func GenerateJWT(userID string, secret string, expiry string) (string, error) {
+func GenerateJWT(userID string, role string, secret string, expiry string) (string, error) {
 	// Parsear la duración del token
 	duration, err := time.ParseDuration(expiry)
 	if err != nil {
@@ -26,7 +34,9 @@ func GenerateJWT(userID string, secret string, expiry string) (string, error) {
 	// Crear los claims
 	claims := Claims{
 		UserID: userID,
+		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(), // jti, usado para revocar el token
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
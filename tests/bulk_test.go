@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/pkg/models"
+)
+
+func TestBulkTaskOperations(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
+
+	owner := &models.User{
+		FirstName:    "Test",
+		LastName:     "Masivo",
+		Email:        fmt.Sprintf("bulk_owner_%d@example.com", time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Role:         models.RoleMember,
+	}
+	other := &models.User{
+		FirstName:    "Test",
+		LastName:     "Ajeno",
+		Email:        fmt.Sprintf("bulk_other_%d@example.com", time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Role:         models.RoleMember,
+	}
+	for _, u := range []*models.User{owner, other} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+		}
+	}
+
+	newTask := func(creator *models.User, title, priority string) models.Task {
+		task := models.Task{
+			Title:       title,
+			Description: "Tarea para probar operaciones masivas",
+			Status:      "pending",
+			Priority:    priority,
+			DueDate:     time.Now().Add(24 * time.Hour),
+			CreatorID:   creator.ID,
+			AssigneeID:  creator.ID,
+			Version:     1,
+		}
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatalf("❌ No se pudo crear la tarea: %v", err)
+		}
+		return task
+	}
+	first := newTask(owner, "Primera", "low")
+	second := newTask(owner, "Segunda", "low")
+	third := newTask(owner, "Tercera", "medium")
+	foreign := newTask(other, "Ajena", "low")
+
+	token := env.token(t, owner)
+	send := func(payload interface{}) (int, map[string]interface{}) {
+		resp, decoded := env.request(t, http.MethodPost, "/tasks/bulk", token, payload, nil)
+		return resp.StatusCode, decoded
+	}
+
+	// El reporte va en data si la operación se aplicó y en report (extensión del problem+json) si no
+	results := func(body map[string]interface{}) []map[string]interface{} {
+		report, ok := body["data"].(map[string]interface{})
+		if !ok {
+			report = body["report"].(map[string]interface{})
+		}
+		var out []map[string]interface{}
+		for _, item := range report["results"].([]interface{}) {
+			out = append(out, item.(map[string]interface{}))
+		}
+		return out
+	}
+
+	reload := func(id uint) models.Task {
+		var task models.Task
+		db.Unscoped().First(&task, id)
+		return task
+	}
+
+	//  CASO 1: SOLICITUDES INVÁLIDAS
+	t.Log("🧪 Probando caso 1: Solicitudes inválidas")
+	status, _ := send(map[string]interface{}{"operations": []interface{}{}})
+	assert.Equal(t, http.StatusBadRequest, status, "Sin operaciones debería responder 400")
+	status, _ = send(map[string]interface{}{"operations": []map[string]interface{}{{"op": "archive", "id": first.ID}}})
+	assert.Equal(t, http.StatusBadRequest, status, "Una operación desconocida debería responder 400")
+	status, _ = send(map[string]interface{}{"mode": "eventual", "operations": []map[string]interface{}{{"op": "delete", "id": first.ID}}})
+	assert.Equal(t, http.StatusBadRequest, status, "Un modo desconocido debería responder 400")
+	status, _ = send(map[string]interface{}{"filter": map[string]string{"sort": "id"}, "patch": map[string]string{"status": "complete"}})
+	assert.Equal(t, http.StatusBadRequest, status, "Un filtro con sort debería responder 400")
+
+	//  CASO 2: ATOMIC CON UNA OPERACIÓN FALLIDA
+	t.Log("🧪 Probando caso 2: Modo atomic, todo o nada")
+	ops := []map[string]interface{}{
+		{"op": "update", "id": first.ID, "patch": map[string]string{"status": "in_progress"}},
+		{"op": "update", "id": foreign.ID, "patch": map[string]string{"status": "in_progress"}},
+		{"op": "reassign", "id": second.ID, "assignee_id": other.ID},
+	}
+	status, body := send(map[string]interface{}{"operations": ops})
+	assert.Equal(t, http.StatusConflict, status, "Si una operación falla en modo atomic debería responder 409")
+	assert.Equal(t, "bulk_operation_failed", body["code"], "Debería informar el código del error")
+	items := results(body)
+	if assert.Len(t, items, 3, "Debería informar todas las operaciones") {
+		assert.Equal(t, models.BulkResultRolledBack, items[0]["result"], "La primera operación debería deshacerse")
+		assert.Equal(t, models.BulkResultFailed, items[1]["result"], "La tarea ajena debería fallar")
+		assert.Equal(t, float64(http.StatusForbidden), items[1]["status"], "La tarea ajena debería fallar por permisos")
+		assert.Equal(t, "forbidden", items[1]["code"], "La tarea ajena debería informar el código del error")
+		assert.Equal(t, models.BulkResultSkipped, items[2]["result"], "La última operación no debería intentarse")
+	}
+	assert.Equal(t, "pending", reload(first.ID).Status, "No debería aplicarse ningún cambio")
+
+	//  CASO 3: BEST EFFORT
+	t.Log("🧪 Probando caso 3: Modo best_effort")
+	status, body = send(map[string]interface{}{"mode": "best_effort", "operations": ops})
+	assert.Equal(t, http.StatusOK, status, "En modo best_effort debería responder 200")
+	data := body["data"].(map[string]interface{})
+	assert.Equal(t, float64(2), data["succeeded"], "Deberían aplicarse las dos operaciones válidas")
+	assert.Equal(t, float64(1), data["failed"], "Debería informar la operación fallida")
+	items = results(body)
+	assert.Equal(t, float64(2), items[0]["version"], "Debería informar la nueva versión")
+	assert.Equal(t, "in_progress", reload(first.ID).Status, "La actualización debería aplicarse")
+	assert.Equal(t, other.ID, reload(second.ID).AssigneeID, "La reasignación debería aplicarse")
+	assert.Equal(t, "pending", reload(foreign.ID).Status, "La tarea ajena no debería cambiar")
+
+	//  CASO 4: VERSIÓN Y BORRADO
+	t.Log("🧪 Probando caso 4: Versión esperada y borrado")
+	status, body = send(map[string]interface{}{"mode": "best_effort", "operations": []map[string]interface{}{
+		{"op": "update", "id": first.ID, "version": 1, "patch": map[string]string{"title": "Con versión vieja"}},
+		{"op": "delete", "id": third.ID},
+	}})
+	assert.Equal(t, http.StatusOK, status, "En modo best_effort debería responder 200")
+	items = results(body)
+	assert.Equal(t, float64(http.StatusPreconditionFailed), items[0]["status"], "Una versión vieja debería fallar con 412")
+	assert.Equal(t, models.BulkResultOK, items[1]["result"], "El borrado debería aplicarse")
+	assert.True(t, reload(third.ID).DeletedAt.Valid, "La tarea borrada debería ir a la papelera")
+
+	//  CASO 5: FILTRO MÁS PATCH
+	t.Log("🧪 Probando caso 5: Filtro más patch")
+	status, body = send(map[string]interface{}{
+		"filter": map[string]string{"priority": "low", "creator": "me"},
+		"patch":  map[string]string{"priority": "high"},
+	})
+	assert.Equal(t, http.StatusOK, status, "El filtro con patch debería aplicarse")
+	items = results(body)
+	ids := []interface{}{}
+	for _, item := range items {
+		ids = append(ids, item["id"])
+	}
+	assert.ElementsMatch(t, []interface{}{float64(first.ID), float64(second.ID)}, ids, "Debería aplicarse solo a las tareas del filtro")
+	assert.Equal(t, "high", reload(first.ID).Priority, "La prioridad debería cambiar")
+	assert.Equal(t, "high", reload(second.ID).Priority, "La prioridad debería cambiar")
+	assert.Equal(t, "low", reload(foreign.ID).Priority, "La tarea ajena no debería cambiar")
+
+	t.Log("✅ Todos los casos de operaciones masivas pasaron correctamente")
+}
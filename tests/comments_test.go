@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/pkg/models"
+)
+
+func TestTaskComments(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
+
+	// Crear creador, asignado y un usuario ajeno a la tarea
+	timestamp := time.Now().UnixNano()
+	newUser := func(name string) *models.User {
+		user := &models.User{
+			FirstName:    "Test",
+			LastName:     name,
+			Email:        fmt.Sprintf("comments_%s_%d@example.com", name, timestamp),
+			PasswordHash: "hash",
+			Role:         models.RoleMember,
+		}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("❌ No se pudo crear el usuario %s: %v", name, err)
+		}
+		return user
+	}
+	creator, assignee, outsider := newUser("creator"), newUser("assignee"), newUser("outsider")
+
+	task := models.Task{
+		Title:       "Tarea con comentarios",
+		Description: "Tarea para probar comentarios",
+		Status:      "pending",
+		Priority:    "medium",
+		DueDate:     time.Now().Add(24 * time.Hour),
+		CreatorID:   creator.ID,
+		AssigneeID:  assignee.ID,
+	}
+	if err := db.Create(&task).Error; err != nil {
+		t.Fatalf("❌ No se pudo crear la tarea: %v", err)
+	}
+
+	// Las requests se autentican con el token de currentUser
+	currentUser := creator
+	send := func(method, path string, payload interface{}) (int, map[string]interface{}) {
+		resp, decoded := env.request(t, method, path, env.token(t, currentUser), payload, nil)
+		return resp.StatusCode, decoded
+	}
+	commentsPath := fmt.Sprintf("/tasks/%d/comments", task.ID)
+
+	//  CASO 1: EL CREADOR COMENTA
+	t.Log("🧪 Probando caso 1: Crear comentario")
+	status, created := send(http.MethodPost, commentsPath, map[string]string{"body": "Primer comentario"})
+	assert.Equal(t, http.StatusCreated, status, "La creación del comentario debería ser exitosa")
+	commentID := created["data"].(map[string]interface{})["id"]
+	commentPath := fmt.Sprintf("%s/%v", commentsPath, commentID)
+
+	status, _ = send(http.MethodPost, commentsPath, map[string]string{"body": "   "})
+	assert.Equal(t, http.StatusBadRequest, status, "Un comentario vacío debería retornar 400")
+
+	//  CASO 2: EL ASIGNADO COMENTA Y LISTA CON PAGINACIÓN
+	t.Log("🧪 Probando caso 2: Listado paginado")
+	currentUser = assignee
+	send(http.MethodPost, commentsPath, map[string]string{"body": "Segundo comentario"})
+	send(http.MethodPost, commentsPath, map[string]string{"body": "Tercer comentario"})
+
+	status, page := send(http.MethodGet, commentsPath+"?limit=2", nil)
+	assert.Equal(t, http.StatusOK, status, "El listado debería ser exitoso")
+	assert.Len(t, page["data"], 2, "La primera página debería tener 2 comentarios")
+	meta := page["meta"].(map[string]interface{})
+	assert.Equal(t, float64(3), meta["total"], "El total debería ser 3")
+	if assert.NotNil(t, meta["next_cursor"], "Debería existir una página siguiente") {
+		status, page = send(http.MethodGet, fmt.Sprintf("%s?limit=2&cursor=%v", commentsPath, meta["next_cursor"]), nil)
+		assert.Equal(t, http.StatusOK, status, "La segunda página debería ser exitosa")
+		assert.Len(t, page["data"], 1, "La segunda página debería tener 1 comentario")
+	}
+
+	//  CASO 3: SOLO EL AUTOR PUEDE EDITAR O ELIMINAR
+	t.Log("🧪 Probando caso 3: Permisos de edición")
+	status, _ = send(http.MethodPut, commentPath, map[string]string{"body": "Editado por otro"})
+	assert.Equal(t, http.StatusForbidden, status, "Otro usuario no debería poder editar el comentario")
+	status, _ = send(http.MethodDelete, commentPath, nil)
+	assert.Equal(t, http.StatusForbidden, status, "Otro usuario no debería poder eliminar el comentario")
+
+	currentUser = creator
+	status, updated := send(http.MethodPut, commentPath, map[string]string{"body": "Comentario editado"})
+	assert.Equal(t, http.StatusOK, status, "El autor debería poder editar su comentario")
+	assert.Equal(t, "Comentario editado", updated["data"].(map[string]interface{})["body"], "El contenido debería actualizarse")
+
+	//  CASO 4: UN USUARIO AJENO NO VE LOS COMENTARIOS
+	t.Log("🧪 Probando caso 4: Visibilidad")
+	currentUser = outsider
+	status, _ = send(http.MethodGet, commentsPath, nil)
+	assert.Equal(t, http.StatusNotFound, status, "Un usuario ajeno no debería ver los comentarios")
+	status, _ = send(http.MethodPost, commentsPath, map[string]string{"body": "Intruso"})
+	assert.Equal(t, http.StatusNotFound, status, "Un usuario ajeno no debería poder comentar")
+
+	//  CASO 5: EL AUTOR ELIMINA SU COMENTARIO
+	t.Log("🧪 Probando caso 5: Eliminar comentario")
+	currentUser = creator
+	status, _ = send(http.MethodDelete, commentPath, nil)
+	assert.Equal(t, http.StatusOK, status, "El autor debería poder eliminar su comentario")
+	status, _ = send(http.MethodGet, commentPath, nil)
+	assert.Equal(t, http.StatusNotFound, status, "El comentario eliminado no debería existir")
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/pkg/models"
+)
+
+func TestTaskDependencies(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
+
+	user := &models.User{
+		FirstName:    "Test",
+		LastName:     "Dependencias",
+		Email:        fmt.Sprintf("dependencies_%d@example.com", time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Role:         models.RoleMember,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+	}
+
+	token := env.token(t, user)
+	send := func(method, path string, payload interface{}) (int, map[string]interface{}) {
+		// Estos casos no prueban la concurrencia: If-Match * acepta cualquier versión de la tarea
+		headers := map[string]string{"If-Match": "*"}
+		if method == http.MethodPatch {
+			headers["Content-Type"] = "application/merge-patch+json"
+		}
+		resp, decoded := env.request(t, method, path, token, payload, headers)
+		return resp.StatusCode, decoded
+	}
+	createTask := func(title string, parentID interface{}) interface{} {
+		payload := map[string]interface{}{
+			"title":       title,
+			"description": "Tarea para probar dependencias",
+			"due_date":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+		}
+		if parentID != nil {
+			payload["parent_id"] = parentID
+		}
+		status, created := send(http.MethodPost, "/tasks", payload)
+		if !assert.Equal(t, http.StatusCreated, status, "La creación de %q debería ser exitosa", title) {
+			t.FailNow()
+		}
+		return created["data"].(map[string]interface{})["id"]
+	}
+
+	designID := createTask("Diseño", nil)
+	backendID := createTask("Backend", nil)
+	releaseID := createTask("Release", nil)
+	addDependency := func(taskID, blockerID interface{}) int {
+		status, _ := send(http.MethodPost, fmt.Sprintf("/tasks/%v/dependencies", taskID), map[string]interface{}{"blocked_by_id": blockerID})
+		return status
+	}
+
+	//  CASO 1: AGREGAR DEPENDENCIAS
+	t.Log("🧪 Probando caso 1: Agregar dependencias")
+	assert.Equal(t, http.StatusCreated, addDependency(backendID, designID), "Backend debería poder depender de Diseño")
+	assert.Equal(t, http.StatusCreated, addDependency(releaseID, backendID), "Release debería poder depender de Backend")
+
+	status, body := send(http.MethodGet, fmt.Sprintf("/tasks/%v/dependencies", backendID), nil)
+	assert.Equal(t, http.StatusOK, status, "El listado de dependencias debería ser exitoso")
+	deps := body["data"].(map[string]interface{})
+	assert.Len(t, deps["blocked_by"], 1, "Backend debería estar bloqueada por Diseño")
+	assert.Len(t, deps["blocking"], 1, "Backend debería bloquear a Release")
+
+	//  CASO 2: LOS CICLOS SE RECHAZAN
+	t.Log("🧪 Probando caso 2: Ciclos")
+	assert.Equal(t, http.StatusConflict, addDependency(designID, releaseID), "Un ciclo indirecto debería retornar 409")
+	assert.Equal(t, http.StatusConflict, addDependency(designID, designID), "Una tarea no debería depender de sí misma")
+
+	//  CASO 3: NO SE COMPLETA UNA TAREA CON BLOQUEANTES ABIERTOS
+	t.Log("🧪 Probando caso 3: Completar con bloqueantes abiertos")
+	// El flujo de estados exige pasar por in_progress antes de completar
+	send(http.MethodPatch, fmt.Sprintf("/tasks/%v", backendID), map[string]string{"status": "in_progress"})
+	send(http.MethodPatch, fmt.Sprintf("/tasks/%v", designID), map[string]string{"status": "in_progress"})
+	status, body = send(http.MethodPatch, fmt.Sprintf("/tasks/%v", backendID), map[string]string{"status": "complete"})
+	assert.Equal(t, http.StatusConflict, status, "Completar con un bloqueante abierto debería retornar 409")
+	if blockers, ok := body["blocked_by"].([]interface{}); assert.True(t, ok, "Deberían informarse los bloqueantes") && assert.Len(t, blockers, 1) {
+		assert.Equal(t, designID, blockers[0].(map[string]interface{})["id"], "El bloqueante informado debería ser Diseño")
+	}
+
+	send(http.MethodPatch, fmt.Sprintf("/tasks/%v", designID), map[string]string{"status": "complete"})
+	status, _ = send(http.MethodPatch, fmt.Sprintf("/tasks/%v", backendID), map[string]string{"status": "complete"})
+	assert.Equal(t, http.StatusOK, status, "Con los bloqueantes completados la tarea debería poder completarse")
+
+	//  CASO 4: QUITAR UNA DEPENDENCIA
+	t.Log("🧪 Probando caso 4: Quitar dependencias")
+	path := fmt.Sprintf("/tasks/%v/dependencies/%v", releaseID, backendID)
+	status, _ = send(http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusOK, status, "La dependencia debería eliminarse")
+	status, _ = send(http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusNotFound, status, "Una dependencia inexistente debería retornar 404")
+}
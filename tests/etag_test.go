@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/pkg/models"
+)
+
+func TestTaskOptimisticConcurrency(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	user := env.createUser(t, "Concurrencia", models.RoleMember)
+	token := env.token(t, user)
+
+	send := func(method, path string, payload interface{}, headers map[string]string) (int, string, map[string]interface{}) {
+		if method == http.MethodPatch {
+			if headers == nil {
+				headers = map[string]string{}
+			}
+			headers["Content-Type"] = "application/merge-patch+json"
+		}
+		resp, decoded := env.request(t, method, path, token, payload, headers)
+		return resp.StatusCode, resp.Header.Get("ETag"), decoded
+	}
+
+	//  CASO 1: ETAG AL CREAR Y OBTENER
+	t.Log("🧪 Probando caso 1: ETag en Create y Get")
+	status, createdETag, body := send(http.MethodPost, "/tasks", map[string]interface{}{
+		"title":       "Tarea con versión",
+		"description": "Tarea para probar ETag e If-Match",
+		"due_date":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+	}, nil)
+	if !assert.Equal(t, http.StatusCreated, status, "La creación debería ser exitosa") {
+		t.FailNow()
+	}
+	data := body["data"].(map[string]interface{})
+	path := fmt.Sprintf("/tasks/%v", data["id"])
+	assert.Equal(t, float64(1), data["version"], "Una tarea nueva debería tener la versión 1")
+	assert.NotEmpty(t, createdETag, "La creación debería devolver el ETag")
+	status, etag, _ := send(http.MethodGet, path, nil, nil)
+	assert.Equal(t, http.StatusOK, status, "La obtención debería ser exitosa")
+	assert.Equal(t, createdETag, etag, "El ETag no debería cambiar si la tarea no cambió")
+
+	//  CASO 2: IF-NONE-MATCH
+	t.Log("🧪 Probando caso 2: If-None-Match")
+	status, _, _ = send(http.MethodGet, path, nil, map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, status, "Con el ETag vigente debería responder 304")
+	status, _, _ = send(http.MethodGet, path, nil, map[string]string{"If-None-Match": "W/" + etag})
+	assert.Equal(t, http.StatusNotModified, status, "If-None-Match usa comparación débil")
+	status, _, _ = send(http.MethodGet, path, nil, map[string]string{"If-None-Match": `"otro"`})
+	assert.Equal(t, http.StatusOK, status, "Con otro ETag debería devolver la tarea")
+
+	//  CASO 3: IF-MATCH REQUERIDO
+	t.Log("🧪 Probando caso 3: If-Match requerido")
+	update := map[string]string{"status": "in_progress"}
+	status, _, _ = send(http.MethodPatch, path, update, nil)
+	assert.Equal(t, http.StatusPreconditionRequired, status, "Sin If-Match debería responder 428")
+	status, _, _ = send(http.MethodDelete, path, nil, nil)
+	assert.Equal(t, http.StatusPreconditionRequired, status, "Sin If-Match debería responder 428")
+
+	//  CASO 4: ACTUALIZACIÓN CON ETAG VIGENTE Y CON ETAG VIEJO
+	t.Log("🧪 Probando caso 4: Escritura concurrente")
+	status, newETag, body := send(http.MethodPatch, path, update, map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusOK, status, "Con el ETag vigente la actualización debería ser exitosa")
+	assert.NotEqual(t, etag, newETag, "La actualización debería cambiar el ETag")
+	assert.Equal(t, float64(2), body["data"].(map[string]interface{})["version"], "La versión debería aumentar")
+	status, currentETag, _ := send(http.MethodPatch, path, map[string]string{"title": "Pisando cambios"}, map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusPreconditionFailed, status, "Con un ETag viejo debería responder 412")
+	assert.Equal(t, newETag, currentETag, "El 412 debería informar el ETag vigente")
+	status, _, _ = send(http.MethodDelete, path, nil, map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusPreconditionFailed, status, "Eliminar con un ETag viejo debería responder 412")
+
+	//  CASO 5: CAMBIOS DE ETIQUETAS INVALIDAN EL ETAG
+	t.Log("🧪 Probando caso 5: Etiquetas y versión")
+	_, _, label := send(http.MethodPost, "/labels", map[string]string{"name": "etag"}, nil)
+	status, _, _ = send(http.MethodPost, "/labels/bulk", map[string]interface{}{
+		"task_ids": []interface{}{data["id"]},
+		"add":      []interface{}{label["data"].(map[string]interface{})["id"]},
+	}, nil)
+	assert.Equal(t, http.StatusOK, status, "La aplicación de etiquetas debería ser exitosa")
+	status, _, _ = send(http.MethodGet, path, nil, map[string]string{"If-None-Match": newETag})
+	assert.Equal(t, http.StatusOK, status, "Agregar una etiqueta debería invalidar el ETag")
+
+	//  CASO 6: ELIMINACIÓN CON ETAG VIGENTE
+	t.Log("🧪 Probando caso 6: Eliminar con ETag vigente")
+	_, etag, _ = send(http.MethodGet, path, nil, nil)
+	status, _, _ = send(http.MethodDelete, path, nil, map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusOK, status, "Con el ETag vigente la eliminación debería ser exitosa")
+
+	//  CASO 7: EL ETAG REFLEJA EL PROGRESO Y LAS SUBTAREAS
+	t.Log("🧪 Probando caso 7: ETag con progreso y subtareas")
+	dueDate := time.Now().Add(24 * time.Hour).Format(time.RFC3339)
+	_, _, body = send(http.MethodPost, "/tasks", map[string]interface{}{
+		"title": "Tarea padre", "description": "Padre con progreso", "due_date": dueDate,
+	}, nil)
+	parentID := body["data"].(map[string]interface{})["id"]
+	status, childETag, body := send(http.MethodPost, "/tasks", map[string]interface{}{
+		"title": "Subtarea", "description": "Hija que cambia de estado", "due_date": dueDate, "parent_id": parentID,
+	}, nil)
+	if !assert.Equal(t, http.StatusCreated, status, "La creación de la subtarea debería ser exitosa") {
+		t.FailNow()
+	}
+	childPath := fmt.Sprintf("/tasks/%v", body["data"].(map[string]interface{})["id"])
+	parentPath := fmt.Sprintf("/tasks/%v", parentID)
+
+	_, plainETag, _ := send(http.MethodGet, parentPath, nil, nil)
+	_, treeETag, _ := send(http.MethodGet, parentPath+"?include=subtasks", nil, nil)
+	assert.NotEqual(t, plainETag, treeETag, "Con y sin include la respuesta debería tener ETags distintos")
+	status, _, _ = send(http.MethodGet, parentPath+"?include=subtasks", nil, map[string]string{"If-None-Match": plainETag})
+	assert.Equal(t, http.StatusOK, status, "El ETag sin include no debería validar la respuesta con subtareas")
+
+	status, _, _ = send(http.MethodPatch, childPath, map[string]string{"status": "complete"}, map[string]string{"If-Match": childETag})
+	assert.Equal(t, http.StatusOK, status, "Completar la subtarea debería ser exitoso")
+	status, _, body = send(http.MethodGet, parentPath, nil, map[string]string{"If-None-Match": plainETag})
+	assert.Equal(t, http.StatusOK, status, "Un cambio en la subtarea debería invalidar el ETag del padre")
+	assert.Equal(t, float64(100), body["data"].(map[string]interface{})["progress"], "El padre debería informar el progreso actualizado")
+	status, treeETag, _ = send(http.MethodGet, parentPath+"?include=subtasks", nil, map[string]string{"If-None-Match": treeETag})
+	assert.Equal(t, http.StatusOK, status, "Un cambio en la subtarea debería invalidar el ETag del árbol")
+
+	status, _, _ = send(http.MethodPatch, parentPath, map[string]string{"title": "Tarea padre renombrada"}, map[string]string{"If-Match": treeETag})
+	assert.Equal(t, http.StatusOK, status, "El ETag de GET con subtareas debería servir para If-Match")
+
+	t.Log("✅ Todos los casos de concurrencia optimista pasaron correctamente")
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/pkg/models"
+)
+
+func TestTaskFilters(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
+
+	newUser := func(name string) *models.User {
+		user := &models.User{
+			FirstName:    "Test",
+			LastName:     name,
+			Email:        fmt.Sprintf("filters_%s_%d@example.com", strings.ToLower(name), time.Now().UnixNano()),
+			PasswordHash: "hash",
+			Role:         models.RoleMember,
+		}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+		}
+		return user
+	}
+	user := newUser("Filtros")
+	other := newUser("Colega")
+
+	token := env.token(t, user)
+
+	now := time.Now().UTC()
+	createTask := func(title, status string, due time.Time, creatorID, assigneeID string) uint {
+		task := &models.Task{
+			Title:       title,
+			Description: "Tarea para probar filtros",
+			Status:      status,
+			Priority:    "medium",
+			DueDate:     due,
+			CreatorID:   creatorID,
+			AssigneeID:  assigneeID,
+		}
+		if err := db.Create(task).Error; err != nil {
+			t.Fatalf("❌ No se pudo crear la tarea: %v", err)
+		}
+		return task.ID
+	}
+	overdueID := createTask("Vencida", "pending", now.Add(-48*time.Hour), user.ID, other.ID)
+	doneLateID := createTask("Completada tarde", "complete", now.Add(-24*time.Hour), user.ID, other.ID)
+	inProgressID := createTask("En curso", "in_progress", now.Add(72*time.Hour), user.ID, other.ID)
+	assignedID := createTask("Asignada por un colega", "pending", now.Add(10*24*time.Hour), other.ID, user.ID)
+
+	list := func(query url.Values) (int, []uint, string) {
+		resp, decoded := env.request(t, http.MethodGet, "/tasks?"+query.Encode(), token, nil, nil)
+		var ids []uint
+		if data, ok := decoded["data"].([]interface{}); ok {
+			for _, item := range data {
+				ids = append(ids, uint(item.(map[string]interface{})["id"].(float64)))
+			}
+		}
+		message, _ := decoded["detail"].(string)
+		return resp.StatusCode, ids, message
+	}
+
+	//  CASO 1: FILTRO DE CONJUNTO
+	t.Log("🧪 Probando caso 1: status=in:")
+	status, ids, _ := list(url.Values{"status": {"in:pending,in_progress"}})
+	assert.Equal(t, http.StatusOK, status, "El filtro in: debería ser exitoso")
+	assert.ElementsMatch(t, []uint{overdueID, inProgressID, assignedID}, ids, "Deberían listarse las tareas pendientes y en curso")
+
+	//  CASO 2: VENCIDAS
+	t.Log("🧪 Probando caso 2: overdue")
+	_, ids, _ = list(url.Values{"overdue": {"true"}})
+	assert.ElementsMatch(t, []uint{overdueID}, ids, "Solo la tarea pendiente con fecha pasada está vencida")
+	_, ids, _ = list(url.Values{"overdue": {"false"}})
+	assert.ElementsMatch(t, []uint{doneLateID, inProgressID, assignedID}, ids, "Las demás tareas no están vencidas")
+
+	//  CASO 3: RANGOS DE FECHAS
+	t.Log("🧪 Probando caso 3: due_before / due_after / created_after")
+	_, ids, _ = list(url.Values{"due_after": {now.Format(time.RFC3339)}, "due_before": {now.Add(5 * 24 * time.Hour).Format("2006-01-02")}})
+	assert.ElementsMatch(t, []uint{inProgressID}, ids, "Solo la tarea en curso vence dentro del rango")
+	_, ids, _ = list(url.Values{"created_after": {now.Add(time.Hour).Format(time.RFC3339)}})
+	assert.Empty(t, ids, "Ninguna tarea fue creada en el futuro")
+
+	//  CASO 4: ASIGNADO Y CREADOR
+	t.Log("🧪 Probando caso 4: assignee=me / creator=me")
+	_, ids, _ = list(url.Values{"assignee": {"me"}})
+	assert.ElementsMatch(t, []uint{assignedID}, ids, "Solo una tarea está asignada al usuario")
+	_, ids, _ = list(url.Values{"creator": {"me"}, "status": {"complete"}})
+	assert.ElementsMatch(t, []uint{doneLateID}, ids, "Los filtros deberían combinarse")
+	_, ids, _ = list(url.Values{"creator": {other.ID}})
+	assert.ElementsMatch(t, []uint{assignedID}, ids, "El filtro por creador debería aceptar un UUID")
+
+	//  CASO 5: ERRORES DE VALIDACIÓN
+	t.Log("🧪 Probando caso 5: Parámetros inválidos")
+	status, _, message := list(url.Values{"colour": {"red"}})
+	assert.Equal(t, http.StatusBadRequest, status, "Un parámetro desconocido debería retornar 400")
+	assert.Contains(t, message, "colour", "El mensaje debería indicar el parámetro desconocido")
+	for _, query := range []url.Values{
+		{"status": {"in:pending,archived"}},
+		{"status": {"in:"}},
+		{"priority": {"urgent"}},
+		{"due_before": {"mañana"}},
+		{"due_after": {"2030-01-02"}, "due_before": {"2030-01-01"}},
+		{"assignee": {"alguien"}},
+		{"overdue": {"yes"}},
+	} {
+		status, _, _ = list(query)
+		assert.Equal(t, http.StatusBadRequest, status, "El filtro %v debería retornar 400", query)
+	}
+
+	t.Log("✅ Todos los casos de filtros pasaron correctamente")
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package tests
+
+// dropPrivileges no hace nada fuera de Unix: Postgres se ejecuta con el usuario actual
+func (h *postgresHarness) dropPrivileges() error {
+	return nil
+}
@@ -0,0 +1,347 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/internal/config"
+	"legendaryum/internal/server"
+	"legendaryum/pkg/database"
+	"legendaryum/pkg/models"
+	"legendaryum/pkg/utils"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Entorno de integración hermético: un Postgres local (binarios initdb y postgres, sin Docker ni
+// red) en un puerto aleatorio, con las migraciones aplicadas una sola vez en una base plantilla.
+// Cada test recibe su propia base copiada de la plantilla (se elimina al terminar) y la aplicación
+// Fiber armada con server.New, igual que en cmd/api. Los binarios se buscan en POSTGRES_BIN_DIR, en el
+// PATH y en las rutas de instalación habituales; si no están, los tests que usan el entorno fallan,
+// salvo que SKIP_DB_TESTS=1 pida omitirlos explícitamente. Como root, Postgres se ejecuta con un
+// usuario sin privilegios (POSTGRES_TEST_USER, por defecto nobody).
+
+// harnessTemplateDB es la base con las migraciones aplicadas que se copia para cada test
+const harnessTemplateDB = "legendaryum_template"
+
+// skipDBTestsEnv es la variable que omite los tests que usan Postgres en lugar de fallar
+const skipDBTestsEnv = "SKIP_DB_TESTS"
+
+// postgresHarness es el servidor Postgres compartido por los tests del paquete. Se inicia con el
+// primer test que lo pide y se detiene en TestMain.
+type postgresHarness struct {
+	once   sync.Once
+	skip   string // Motivo para omitir los tests (SKIP_DB_TESTS=1)
+	err    error  // Error al iniciar el servidor
+	dir    string
+	port   string
+	cmd    *exec.Cmd
+	admin  *gorm.DB // Conexión a la base postgres para crear y eliminar las bases de los tests
+	nextDB int64
+
+	sysProcAttr *syscall.SysProcAttr // Usuario con el que se ejecutan initdb y postgres (nil: el actual)
+}
+
+var harness postgresHarness
+
+// testEnv es el entorno de un test: su propia base migrada y la aplicación completa sobre ella
+type testEnv struct {
+	Config *config.Config
+	DB     *gorm.DB
+	App    *fiber.App
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	harness.stop()
+	os.Exit(code)
+}
+
+// newTestEnv crea una base aislada para el test y la aplicación sobre ella. Falla si Postgres no
+// está disponible, salvo con SKIP_DB_TESTS=1, que omite el test.
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	harness.once.Do(harness.start)
+	if harness.skip != "" {
+		t.Skipf("⏭️  %s", harness.skip)
+	}
+	if harness.err != nil {
+		t.Fatalf("❌ No se pudo iniciar Postgres: %v", harness.err)
+	}
+
+	name := fmt.Sprintf("legendaryum_test_%d", atomic.AddInt64(&harness.nextDB, 1))
+	if err := harness.admin.Exec(fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s", name, harnessTemplateDB)).Error; err != nil {
+		t.Fatalf("❌ No se pudo crear la base del test: %v", err)
+	}
+	cfg := harness.config(name)
+	db, err := gorm.Open(postgres.Open(harness.dsn(name)), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("❌ No se pudo conectar a la base del test: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		harness.admin.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %s WITH (FORCE)", name))
+	})
+
+	migrations, err := filepath.Abs("../migrations")
+	if err != nil {
+		t.Fatalf("❌ No se encontró la carpeta de migraciones: %v", err)
+	}
+	return &testEnv{Config: cfg, DB: db, App: server.New(cfg, server.Deps{DB: db, MigrationsDir: migrations})}
+}
+
+// createUser crea directamente en la base del test un usuario con el rol indicado. name distingue
+// a los usuarios del test (se usa como apellido y en el email).
+func (e *testEnv) createUser(t *testing.T, name, role string) *models.User {
+	t.Helper()
+	user := &models.User{
+		FirstName:    "Test",
+		LastName:     name,
+		Email:        fmt.Sprintf("%s_%d@example.com", strings.ToLower(strings.ReplaceAll(name, " ", "_")), time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Role:         role,
+	}
+	if err := e.DB.Create(user).Error; err != nil {
+		t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+	}
+	return user
+}
+
+// token devuelve un access token del usuario firmado con la configuración del entorno, igual al que
+// emite el login
+func (e *testEnv) token(t *testing.T, user *models.User) string {
+	t.Helper()
+	token, err := utils.GenerateJWT(user.ID, user.Role, e.Config.JWTSecret, e.Config.JWTExpiry)
+	if err != nil {
+		t.Fatalf("❌ No se pudo generar el token: %v", err)
+	}
+	return token
+}
+
+// request envía una request a la aplicación con el token indicado (vacío: sin autenticación) y
+// devuelve la respuesta y su cuerpo JSON decodificado. payload se envía tal cual si es []byte o
+// string y como JSON si no; el Content-Type por defecto es application/json.
+func (e *testEnv) request(t *testing.T, method, path, token string, payload interface{}, headers map[string]string) (*http.Response, map[string]interface{}) {
+	t.Helper()
+	var body []byte
+	switch p := payload.(type) {
+	case nil:
+	case []byte:
+		body = p
+	case string:
+		body = []byte(p)
+	default:
+		body, _ = json.Marshal(p)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := e.App.Test(req, -1)
+	assert.NoError(t, err, "No debería haber error en la request")
+	var decoded map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&decoded)
+	return resp, decoded
+}
+
+// config devuelve la configuración de la aplicación apuntando a la base indicada. Es fija y no se
+// lee del entorno, para que las variables del desarrollador (TASK_WORKFLOW, TRASH_*, JWT_*...) no
+// cambien el resultado de los tests.
+func (h *postgresHarness) config(dbName string) *config.Config {
+	return &config.Config{
+		Port:               "8080",
+		JWTSecret:          "test-secret",
+		JWTExpiry:          "15m",
+		RefreshTokenExpiry: "720h",
+		DBHost:             "127.0.0.1",
+		DBPort:             h.port,
+		DBUser:             "postgres",
+		DBName:             dbName,
+		TrashRetention:     "720h",
+		TrashPurgeInterval: "1h",
+		ShutdownTimeout:    "10s",
+	}
+}
+
+// dsn devuelve la cadena de conexión a la base indicada
+func (h *postgresHarness) dsn(dbName string) string {
+	return fmt.Sprintf("host=127.0.0.1 port=%s user=postgres dbname=%s sslmode=disable", h.port, dbName)
+}
+
+// start inicializa un cluster en un directorio temporal, arranca Postgres y prepara la base plantilla
+func (h *postgresHarness) start() {
+	if os.Getenv(skipDBTestsEnv) == "1" {
+		h.skip = "tests con PostgreSQL omitidos (" + skipDBTestsEnv + "=1)"
+		return
+	}
+	bin, err := findPostgresBin()
+	if err != nil {
+		h.err = fmt.Errorf("%v; define %s=1 para omitir los tests que usan la base", err, skipDBTestsEnv)
+		return
+	}
+
+	if h.dir, h.err = os.MkdirTemp("", "legendaryum-pg-"); h.err != nil {
+		return
+	}
+	// Postgres no se ejecuta como root: el cluster pertenece a un usuario sin privilegios
+	if h.err = h.dropPrivileges(); h.err != nil {
+		return
+	}
+	data := filepath.Join(h.dir, "data")
+	initdb := exec.Command(filepath.Join(bin, "initdb"), "-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--no-sync")
+	initdb.SysProcAttr = h.sysProcAttr
+	if out, err := initdb.CombinedOutput(); err != nil {
+		h.err = fmt.Errorf("initdb: %v: %s", err, out)
+		return
+	}
+	if h.port, h.err = freePort(); h.err != nil {
+		return
+	}
+
+	logFile, err := os.Create(filepath.Join(h.dir, "postgres.log"))
+	if err != nil {
+		h.err = err
+		return
+	}
+	// Sin durabilidad: los datos de los tests se descartan
+	h.cmd = exec.Command(filepath.Join(bin, "postgres"), "-D", data, "-p", h.port, "-k", h.dir,
+		"-c", "listen_addresses=127.0.0.1", "-c", "fsync=off", "-c", "synchronous_commit=off", "-c", "full_page_writes=off")
+	h.cmd.Stdout, h.cmd.Stderr = logFile, logFile
+	h.cmd.SysProcAttr = h.sysProcAttr
+	if h.err = h.cmd.Start(); h.err != nil {
+		return
+	}
+
+	// Esperar a que acepte conexiones
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		h.admin, err = gorm.Open(postgres.Open(h.dsn("postgres")), &gorm.Config{})
+		if err == nil {
+			if sqlDB, dbErr := h.admin.DB(); dbErr == nil && sqlDB.Ping() == nil {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			h.err = fmt.Errorf("postgres no respondió a tiempo (ver %s)", logFile.Name())
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	h.err = h.prepareTemplate()
+}
+
+// prepareTemplate crea la base plantilla con las migraciones y los modelos, igual que al iniciar la API
+func (h *postgresHarness) prepareTemplate() error {
+	if err := h.admin.Exec("CREATE DATABASE " + harnessTemplateDB).Error; err != nil {
+		return err
+	}
+	cfg := h.config(harnessTemplateDB)
+	migrations, err := filepath.Abs("../migrations")
+	if err != nil {
+		return err
+	}
+	if err := database.Migrate(cfg, migrations); err != nil {
+		return err
+	}
+
+	db, err := gorm.Open(postgres.Open(h.dsn(harnessTemplateDB)), &gorm.Config{})
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	// La plantilla no puede tener conexiones abiertas al copiarla
+	defer sqlDB.Close()
+	return database.AutoMigrate(db)
+}
+
+// stop detiene Postgres y elimina el directorio temporal
+func (h *postgresHarness) stop() {
+	if h.admin != nil {
+		if sqlDB, err := h.admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+	if h.cmd != nil && h.cmd.Process != nil {
+		// SIGINT: apagado rápido, sin esperar a que se cierren las conexiones
+		_ = h.cmd.Process.Signal(os.Interrupt)
+		done := make(chan struct{})
+		go func() {
+			_ = h.cmd.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			_ = h.cmd.Process.Kill()
+		}
+	}
+	if h.dir != "" {
+		os.RemoveAll(h.dir)
+	}
+}
+
+// findPostgresBin devuelve el directorio con los binarios initdb y postgres
+func findPostgresBin() (string, error) {
+	var candidates []string
+	if dir := os.Getenv("POSTGRES_BIN_DIR"); dir != "" {
+		candidates = append(candidates, dir)
+	}
+	if path, err := exec.LookPath("initdb"); err == nil {
+		candidates = append(candidates, filepath.Dir(path))
+	}
+	for _, pattern := range []string{"/usr/lib/postgresql/*/bin", "/usr/local/pgsql/bin", "/opt/homebrew/opt/postgresql*/bin", "/usr/local/opt/postgresql*/bin"} {
+		matches, _ := filepath.Glob(pattern)
+		candidates = append(candidates, matches...)
+	}
+	for _, dir := range candidates {
+		if isExecutable(filepath.Join(dir, "initdb")) && isExecutable(filepath.Join(dir, "postgres")) {
+			return dir, nil
+		}
+	}
+	return "", errors.New("no se encontraron los binarios initdb y postgres (define POSTGRES_BIN_DIR)")
+}
+
+// isExecutable indica si path es un archivo ejecutable
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0o111 != 0
+}
+
+// freePort devuelve un puerto TCP libre en 127.0.0.1
+func freePort() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port), nil
+}
@@ -0,0 +1,43 @@
+//go:build unix
+
+package tests
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// dropPrivileges prepara la ejecución de initdb y postgres con un usuario sin privilegios cuando
+// los tests corren como root (habitual en contenedores de CI), ya que Postgres se niega a
+// ejecutarse como root. El usuario es POSTGRES_TEST_USER, o nobody si no se indica.
+func (h *postgresHarness) dropPrivileges() error {
+	if os.Geteuid() != 0 {
+		return nil
+	}
+	name := os.Getenv("POSTGRES_TEST_USER")
+	if name == "" {
+		name = "nobody"
+	}
+	account, err := user.Lookup(name)
+	if err != nil {
+		return fmt.Errorf("no se encontró el usuario %q para ejecutar Postgres (define POSTGRES_TEST_USER): %w", name, err)
+	}
+	uid, err := strconv.ParseUint(account.Uid, 10, 32)
+	if err != nil {
+		return err
+	}
+	gid, err := strconv.ParseUint(account.Gid, 10, 32)
+	if err != nil {
+		return err
+	}
+
+	// El cluster y el socket se crean dentro del directorio temporal, que pasa a ser del usuario
+	if err := os.Chown(h.dir, int(uid), int(gid)); err != nil {
+		return err
+	}
+	h.sysProcAttr = &syscall.SysProcAttr{Credential: &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}}
+	return nil
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/pkg/models"
+)
+
+func TestTaskHistory(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
+
+	user := &models.User{
+		FirstName:    "Test",
+		LastName:     "Historial",
+		Email:        fmt.Sprintf("history_%d@example.com", time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Role:         models.RoleMember,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+	}
+
+	token := env.token(t, user)
+	send := func(method, path string, payload interface{}) (int, map[string]interface{}) {
+		// Estos casos no prueban la concurrencia: If-Match * acepta cualquier versión de la tarea
+		headers := map[string]string{"If-Match": "*"}
+		if method == http.MethodPatch {
+			headers["Content-Type"] = "application/merge-patch+json"
+		}
+		resp, decoded := env.request(t, method, path, token, payload, headers)
+		return resp.StatusCode, decoded
+	}
+
+	//  CASO 1: CREAR Y ACTUALIZAR GENERA EVENTOS
+	t.Log("🧪 Probando caso 1: Eventos de creación y actualización")
+	status, created := send(http.MethodPost, "/tasks", map[string]interface{}{
+		"title":       "Tarea auditada",
+		"description": "Tarea para probar el historial",
+		"due_date":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+	})
+	assert.Equal(t, http.StatusCreated, status, "La creación debería ser exitosa")
+	taskID := created["data"].(map[string]interface{})["id"]
+
+	status, _ = send(http.MethodPatch, fmt.Sprintf("/tasks/%v", taskID), map[string]interface{}{"status": "in_progress"})
+	assert.Equal(t, http.StatusOK, status, "La actualización debería ser exitosa")
+
+	status, history := send(http.MethodGet, fmt.Sprintf("/tasks/%v/history", taskID), nil)
+	assert.Equal(t, http.StatusOK, status, "El historial debería obtenerse")
+	events := history["data"].([]interface{})
+	if assert.Len(t, events, 2, "Debería haber un evento de creación y uno de actualización") {
+		assert.Equal(t, models.TaskEventCreated, events[0].(map[string]interface{})["action"])
+
+		update := events[1].(map[string]interface{})
+		assert.Equal(t, models.TaskEventUpdated, update["action"])
+		assert.Equal(t, user.ID, update["actor_id"], "El evento debería registrar quién hizo el cambio")
+		changes := update["changes"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"from": "pending", "to": "in_progress"}, changes["status"], "Debería registrarse el valor anterior y el nuevo")
+		assert.NotContains(t, changes, "title", "Solo deberían registrarse los campos modificados")
+	}
+
+	//  CASO 2: EL BORRADO TAMBIÉN QUEDA REGISTRADO
+	t.Log("🧪 Probando caso 2: Evento de borrado")
+	status, _ = send(http.MethodDelete, fmt.Sprintf("/tasks/%v", taskID), nil)
+	assert.Equal(t, http.StatusOK, status, "La eliminación debería ser exitosa")
+
+	var deleted int64
+	db.Model(&models.TaskEvent{}).Where("task_id = ? AND action = ?", taskID, models.TaskEventDeleted).Count(&deleted)
+	assert.Equal(t, int64(1), deleted, "El borrado debería quedar en el historial")
+
+	//  CASO 3: EL HISTORIAL DE UNA TAREA EN LA PAPELERA
+	t.Log("🧪 Probando caso 3: Historial de una tarea en la papelera")
+	status, history = send(http.MethodGet, fmt.Sprintf("/tasks/%v/history", taskID), nil)
+	assert.Equal(t, http.StatusOK, status, "El historial de una tarea en la papelera debería obtenerse")
+	events = history["data"].([]interface{})
+	if assert.Len(t, events, 3, "Debería incluir la creación, la actualización y el borrado") {
+		assert.Equal(t, models.TaskEventDeleted, events[2].(map[string]interface{})["action"])
+	}
+	outsider := env.createUser(t, "Ajeno", models.RoleMember)
+	resp, _ := env.request(t, http.MethodGet, fmt.Sprintf("/tasks/%v/history", taskID), env.token(t, outsider), nil, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Quien no podía ver la tarea tampoco debería ver su historial en la papelera")
+}
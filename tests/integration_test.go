@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntegrationTaskLifecycle(t *testing.T) {
+	env := newTestEnv(t)
+
+	send := func(method, path, token string, headers map[string]string, payload interface{}) (*http.Response, map[string]interface{}) {
+		return env.request(t, method, path, token, payload, headers)
+	}
+
+	//  CASO 1: REGISTRO Y AUTENTICACIÓN CON LA APLICACIÓN COMPLETA
+	t.Log("🧪 Probando caso 1: Registro")
+	resp, body := send(http.MethodPost, "/auth/register", "", nil, map[string]interface{}{
+		"first_name": "Ana",
+		"last_name":  "Integración",
+		"email":      "ana@example.com",
+		"password":   "secreto",
+	})
+	if !assert.Equal(t, http.StatusCreated, resp.StatusCode, "El registro debería ser exitoso") {
+		t.FailNow()
+	}
+	token := body["data"].(map[string]interface{})["token"].(string)
+
+	resp, _ = send(http.MethodGet, "/tasks", "", nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "Sin token debería retornar 401")
+
+	//  CASO 2: CREAR, MODIFICAR Y LISTAR
+	t.Log("🧪 Probando caso 2: Ciclo de vida de una tarea")
+	resp, body = send(http.MethodPost, "/tasks", token, nil, map[string]interface{}{
+		"title":       "Tarea de integración",
+		"description": "Creada contra el Postgres del entorno de pruebas",
+		"due_date":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+	})
+	if !assert.Equal(t, http.StatusCreated, resp.StatusCode, "La creación debería ser exitosa") {
+		t.FailNow()
+	}
+	path := fmt.Sprintf("/tasks/%v", body["data"].(map[string]interface{})["id"])
+
+	resp, _ = send(http.MethodPatch, path, token, map[string]string{
+		"Content-Type": "application/merge-patch+json",
+		"If-Match":     resp.Header.Get("ETag"),
+	}, map[string]interface{}{"status": "in_progress"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "El patch debería aplicarse")
+
+	resp, body = send(http.MethodGet, "/tasks?status=in_progress", token, nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "El listado debería ser exitoso")
+	assert.Equal(t, float64(1), body["meta"].(map[string]interface{})["total"], "Debería listar la tarea modificada")
+
+	resp, body = send(http.MethodGet, path+"/history", token, nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "El historial debería obtenerse")
+	assert.Len(t, body["data"], 2, "Debería registrar la creación y la actualización")
+
+	t.Log("✅ Todos los casos de integración pasaron correctamente")
+}
+
+func TestIntegrationDatabaseIsolation(t *testing.T) {
+	//  CADA TEST RECIBE SU PROPIA BASE, SIN DATOS DE OTROS TESTS
+	first, second := newTestEnv(t), newTestEnv(t)
+	assert.NotEqual(t, first.Config.DBName, second.Config.DBName, "Cada entorno debería usar su propia base")
+
+	if err := first.DB.Exec("INSERT INTO users (first_name, last_name, email, password_hash) VALUES ('A', 'B', 'aislado@example.com', 'hash')").Error; err != nil {
+		t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+	}
+	var count int64
+	second.DB.Table("users").Count(&count)
+	assert.Equal(t, int64(0), count, "Los datos de un entorno no deberían verse en otro")
+}
+
+func TestIntegrationReadiness(t *testing.T) {
+	env := newTestEnv(t)
+
+	readyz := func() (*http.Response, map[string]interface{}) {
+		resp, err := env.App.Test(httptest.NewRequest(http.MethodGet, "/readyz", nil), -1)
+		assert.NoError(t, err, "No debería haber error en la request")
+		var decoded map[string]interface{}
+		_ = json.NewDecoder(resp.Body).Decode(&decoded)
+		return resp, decoded
+	}
+
+	//  CASO 1: BASE DISPONIBLE Y MIGRADA
+	t.Log("🧪 Probando caso 1: API lista")
+	resp, body := readyz()
+	if assert.Equal(t, http.StatusOK, resp.StatusCode, "Con la base migrada debería estar lista") {
+		checks := body["data"].(map[string]interface{})["checks"].(map[string]interface{})
+		assert.Equal(t, "ok", checks["database"].(map[string]interface{})["status"], "La base debería responder")
+		assert.Equal(t, "ok", checks["migrations"].(map[string]interface{})["status"], "El esquema debería estar en la última migración")
+	}
+
+	//  CASO 2: ESQUEMA DESACTUALIZADO
+	t.Log("🧪 Probando caso 2: Migraciones pendientes")
+	if err := env.DB.Exec("UPDATE schema_migrations SET version = version - 1").Error; err != nil {
+		t.Fatalf("❌ No se pudo modificar la versión del esquema: %v", err)
+	}
+	resp, body = readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "Con migraciones pendientes no debería estar lista")
+	assert.Equal(t, "not_ready", body["code"], "Debería devolver el código not_ready")
+	checks := body["checks"].(map[string]interface{})
+	assert.Equal(t, "ok", checks["database"].(map[string]interface{})["status"], "La base debería seguir respondiendo")
+	assert.Equal(t, "error", checks["migrations"].(map[string]interface{})["status"], "Debería informar el esquema desactualizado")
+
+	t.Log("✅ Todos los casos de disponibilidad pasaron correctamente")
+}
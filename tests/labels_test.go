@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/pkg/models"
+)
+
+func TestLabels(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
+
+	user := &models.User{
+		FirstName:    "Test",
+		LastName:     "Etiquetas",
+		Email:        fmt.Sprintf("labels_%d@example.com", time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Role:         models.RoleMember,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+	}
+
+	other := &models.User{
+		FirstName:    "Test",
+		LastName:     "Asignada",
+		Email:        fmt.Sprintf("labels_other_%d@example.com", time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Role:         models.RoleMember,
+	}
+	if err := db.Create(other).Error; err != nil {
+		t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+	}
+
+	// Las requests se autentican con el token de currentUser
+	currentUser := user
+	send := func(method, path string, payload interface{}) (int, map[string]interface{}) {
+		resp, decoded := env.request(t, method, path, env.token(t, currentUser), payload, nil)
+		return resp.StatusCode, decoded
+	}
+	createTask := func(title string, parentID interface{}) interface{} {
+		payload := map[string]interface{}{
+			"title":       title,
+			"description": "Tarea para probar etiquetas",
+			"due_date":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+		}
+		if parentID != nil {
+			payload["parent_id"] = parentID
+		}
+		status, created := send(http.MethodPost, "/tasks", payload)
+		if !assert.Equal(t, http.StatusCreated, status, "La creación de %q debería ser exitosa", title) {
+			t.FailNow()
+		}
+		return created["data"].(map[string]interface{})["id"]
+	}
+
+	bothID := createTask("Bug en el frontend", nil)
+	bugID := createTask("Bug en el backend", nil)
+	plainID := createTask("Tarea sin etiquetas", nil)
+	createLabel := func(name, color string) (int, interface{}) {
+		status, created := send(http.MethodPost, "/labels", map[string]string{"name": name, "color": color})
+		if status != http.StatusCreated {
+			return status, nil
+		}
+		return status, created["data"].(map[string]interface{})["id"]
+	}
+
+	//  CASO 1: CREAR ETIQUETAS
+	t.Log("🧪 Probando caso 1: Crear etiquetas")
+	status, bugLabel := createLabel("bug", "#ff0000")
+	assert.Equal(t, http.StatusCreated, status, "La creación de la etiqueta debería ser exitosa")
+	status, frontendLabel := createLabel("frontend", "#00aaff")
+	assert.Equal(t, http.StatusCreated, status, "La creación de la etiqueta debería ser exitosa")
+	status, _ = createLabel("BUG", "#ff0000")
+	assert.Equal(t, http.StatusConflict, status, "Un nombre repetido (sin distinguir mayúsculas) debería retornar 409")
+	status, _ = createLabel("invalida", "rojo")
+	assert.Equal(t, http.StatusBadRequest, status, "Un color inválido debería retornar 400")
+
+	//  CASO 2: APLICAR ETIQUETAS EN MASA
+	t.Log("🧪 Probando caso 2: Aplicación masiva")
+	status, body := send(http.MethodPost, "/labels/bulk", map[string]interface{}{
+		"task_ids": []interface{}{bothID, bugID},
+		"add":      []interface{}{bugLabel},
+	})
+	assert.Equal(t, http.StatusOK, status, "La aplicación masiva debería ser exitosa")
+	assert.Equal(t, float64(2), body["data"].(map[string]interface{})["added"], "Deberían agregarse 2 asociaciones")
+	status, _ = send(http.MethodPost, "/labels/bulk", map[string]interface{}{
+		"task_ids": []interface{}{bothID},
+		"add":      []interface{}{frontendLabel},
+	})
+	assert.Equal(t, http.StatusOK, status, "La aplicación masiva debería ser exitosa")
+	status, _ = send(http.MethodPost, "/labels/bulk", map[string]interface{}{
+		"task_ids": []interface{}{bothID, 999999999},
+		"add":      []interface{}{bugLabel},
+	})
+	assert.Equal(t, http.StatusNotFound, status, "Una tarea inexistente debería retornar 404")
+
+	//  CASO 3: FILTRAR CON SEMÁNTICA ANY / ALL
+	t.Log("🧪 Probando caso 3: Filtro por etiquetas")
+	status, body = send(http.MethodGet, "/tasks?labels=bug,frontend", nil)
+	assert.Equal(t, http.StatusOK, status, "El filtro any debería ser exitoso")
+	assert.Len(t, body["data"], 2, "Con any deberían listarse las tareas con alguna de las etiquetas")
+	status, body = send(http.MethodGet, "/tasks?labels=BUG,frontend&labels_mode=all", nil)
+	assert.Equal(t, http.StatusOK, status, "El filtro all debería ser exitoso")
+	if assert.Len(t, body["data"], 1, "Con all solo debería listarse la tarea con ambas etiquetas") {
+		task := body["data"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, bothID, task["id"], "La tarea listada debería ser la que tiene ambas etiquetas")
+		assert.Len(t, task["labels"], 2, "La tarea debería incluir sus etiquetas")
+	}
+	status, _ = send(http.MethodGet, "/tasks?labels=bug&labels_mode=some", nil)
+	assert.Equal(t, http.StatusBadRequest, status, "Un labels_mode inválido debería retornar 400")
+
+	//  CASO 4: QUITAR Y ELIMINAR ETIQUETAS
+	t.Log("🧪 Probando caso 4: Quitar y eliminar")
+	status, body = send(http.MethodPost, "/labels/bulk", map[string]interface{}{
+		"task_ids": []interface{}{bothID, bugID, plainID},
+		"remove":   []interface{}{bugLabel},
+	})
+	assert.Equal(t, http.StatusOK, status, "Quitar etiquetas debería ser exitoso")
+	assert.Equal(t, float64(2), body["data"].(map[string]interface{})["removed"], "Deberían quitarse 2 asociaciones")
+
+	status, _ = send(http.MethodDelete, fmt.Sprintf("/labels/%v", frontendLabel), nil)
+	assert.Equal(t, http.StatusOK, status, "La eliminación de la etiqueta debería ser exitosa")
+	status, body = send(http.MethodGet, "/tasks?labels=frontend", nil)
+	assert.Equal(t, http.StatusOK, status, "El listado debería ser exitoso")
+	assert.Len(t, body["data"], 0, "La etiqueta eliminada no debería quedar asociada a tareas")
+
+	//  CASO 5: SOLO QUIEN PUEDE MODIFICAR LA TAREA CAMBIA SUS ETIQUETAS
+	t.Log("🧪 Probando caso 5: Asignado sin permiso de modificación")
+	status, created := send(http.MethodPost, "/tasks", map[string]interface{}{
+		"title":       "Tarea asignada",
+		"description": "La ve el asignado pero no puede modificarla",
+		"due_date":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+		"assignee_id": other.ID,
+	})
+	if !assert.Equal(t, http.StatusCreated, status, "La creación debería ser exitosa") {
+		t.FailNow()
+	}
+	assignedID := created["data"].(map[string]interface{})["id"]
+
+	currentUser = other
+	status, _ = send(http.MethodGet, fmt.Sprintf("/tasks/%v", assignedID), nil)
+	assert.Equal(t, http.StatusOK, status, "El asignado debería ver la tarea")
+	status, otherLabel := createLabel("mia", "#00ff00")
+	assert.Equal(t, http.StatusCreated, status, "El asignado debería poder crear sus etiquetas")
+	status, body = send(http.MethodPost, "/labels/bulk", map[string]interface{}{
+		"task_ids": []interface{}{assignedID},
+		"add":      []interface{}{otherLabel},
+	})
+	assert.Equal(t, http.StatusForbidden, status, "El asignado no debería cambiar las etiquetas de una tarea que no puede modificar")
+	assert.Equal(t, []interface{}{assignedID}, body["forbidden_tasks"], "Debería informar la tarea no modificable")
+
+	//  CASO 6: LAS ETIQUETAS PERSONALES NO SE VEN EN TAREAS COMPARTIDAS
+	t.Log("🧪 Probando caso 6: Etiquetas personales en una tarea de proyecto")
+	project := &models.Project{Name: "Compartido", OwnerID: other.ID}
+	if err := db.Create(project).Error; err != nil {
+		t.Fatalf("❌ No se pudo crear el proyecto: %v", err)
+	}
+	for _, member := range []models.ProjectMember{
+		{ProjectID: project.ID, UserID: other.ID, Role: "owner"},
+		{ProjectID: project.ID, UserID: user.ID, Role: "member"},
+	} {
+		if err := db.Create(&member).Error; err != nil {
+			t.Fatalf("❌ No se pudo agregar el miembro: %v", err)
+		}
+	}
+	status, created = send(http.MethodPost, "/tasks", map[string]interface{}{
+		"title":       "Tarea del proyecto",
+		"description": "La etiqueta el otro miembro con una etiqueta personal",
+		"due_date":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+		"project_id":  project.ID,
+	})
+	if !assert.Equal(t, http.StatusCreated, status, "La creación en el proyecto debería ser exitosa") {
+		t.FailNow()
+	}
+	sharedID := created["data"].(map[string]interface{})["id"]
+	status, privateBug := createLabel("bug", "#000000")
+	assert.Equal(t, http.StatusCreated, status, "Otro usuario debería poder tener su propia etiqueta 'bug'")
+	status, _ = send(http.MethodPost, "/labels/bulk", map[string]interface{}{
+		"task_ids": []interface{}{sharedID},
+		"add":      []interface{}{privateBug},
+	})
+	assert.Equal(t, http.StatusOK, status, "El creador debería poder etiquetar su tarea")
+	status, body = send(http.MethodGet, fmt.Sprintf("/tasks/%v", sharedID), nil)
+	assert.Equal(t, http.StatusOK, status, "El creador debería ver la tarea")
+	assert.Len(t, body["data"].(map[string]interface{})["labels"], 1, "El dueño de la etiqueta debería verla en la tarea")
+
+	currentUser = user
+	status, body = send(http.MethodGet, fmt.Sprintf("/tasks/%v", sharedID), nil)
+	assert.Equal(t, http.StatusOK, status, "El miembro del proyecto debería ver la tarea")
+	assert.Len(t, body["data"].(map[string]interface{})["labels"], 0, "No debería ver la etiqueta personal de otro usuario")
+	status, body = send(http.MethodGet, "/tasks?project_id="+fmt.Sprint(project.ID), nil)
+	assert.Equal(t, http.StatusOK, status, "El listado debería ser exitoso")
+	if assert.Len(t, body["data"], 1, "El miembro debería listar la tarea del proyecto") {
+		assert.Len(t, body["data"].([]interface{})[0].(map[string]interface{})["labels"], 0, "El listado no debería incluir la etiqueta personal de otro usuario")
+	}
+	status, body = send(http.MethodGet, "/tasks?labels=bug", nil)
+	assert.Equal(t, http.StatusOK, status, "El filtro debería ser exitoso")
+	assert.Len(t, body["data"], 0, "El filtro no debería coincidir con la etiqueta personal de otro usuario")
+}
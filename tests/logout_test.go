@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/pkg/models"
+	"legendaryum/pkg/utils"
+)
+
+func TestAuthLogout(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go). GET /tasks sirve
+	// como ruta protegida para comprobar si un access token sigue siendo válido.
+	env := newTestEnv(t)
+	db := env.DB
+
+	testEmail := fmt.Sprintf("logout_%d@example.com", time.Now().UnixNano())
+	testPassword := "testlogout123"
+	hash, err := utils.HashPassword(testPassword)
+	if err != nil {
+		t.Fatalf("❌ No se pudo hashear la contraseña: %v", err)
+	}
+	if err := db.Create(&models.User{FirstName: "Test", LastName: "Logout", Email: testEmail, PasswordHash: hash}).Error; err != nil {
+		t.Fatalf("❌ No se pudo crear el usuario de prueba: %v", err)
+	}
+
+	send := func(method, path, token string, payload interface{}) int {
+		resp, _ := env.request(t, method, path, token, payload, nil)
+		return resp.StatusCode
+	}
+	login := func() (string, string) {
+		body, _ := json.Marshal(map[string]string{"email": testEmail, "password": testPassword})
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := env.App.Test(req, -1)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("❌ El login debería ser exitoso: %v", err)
+		}
+		var decoded struct {
+			Data models.TokenResponse `json:"data"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&decoded)
+		return decoded.Data.Token, decoded.Data.RefreshToken
+	}
+
+	//  CASO 1: LOGOUT REVOCA EL ACCESS TOKEN Y SU REFRESH TOKEN
+	t.Log("🧪 Probando caso 1: Logout de la sesión actual")
+	access, refresh := login()
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, "/tasks", access, nil), "El token recién emitido debería ser válido")
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/auth/logout", access, map[string]string{"refresh_token": refresh}), "El logout debería ser exitoso")
+	assert.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "/tasks", access, nil), "El token debería estar revocado tras el logout")
+	assert.Equal(t, http.StatusUnauthorized, send(http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": refresh}), "El refresh token debería estar revocado tras el logout")
+
+	//  CASO 2: LOGOUT-ALL INVALIDA TODAS LAS SESIONES ANTERIORES
+	t.Log("🧪 Probando caso 2: Logout de todas las sesiones")
+	first, _ := login()
+	second, secondRefresh := login()
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/auth/logout-all", first, nil), "El logout-all debería ser exitoso")
+	assert.Equal(t, http.StatusUnauthorized, send(http.MethodGet, "/tasks", second, nil), "Los demás tokens deberían quedar inválidos")
+	assert.Equal(t, http.StatusUnauthorized, send(http.MethodPost, "/auth/refresh", "", map[string]string{"refresh_token": secondRefresh}), "Los refresh tokens deberían quedar revocados")
+
+	//  CASO 3: UN LOGIN POSTERIOR FUNCIONA NORMALMENTE
+	t.Log("🧪 Probando caso 3: Nuevo login tras logout-all")
+	time.Sleep(5 * time.Millisecond)
+	fresh, _ := login()
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, "/tasks", fresh, nil), "Un token emitido después del logout-all debería ser válido")
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/pkg/models"
+)
+
+func TestTasksPagination(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
+
+	user := &models.User{
+		FirstName:    "Test",
+		LastName:     "Paginacion",
+		Email:        fmt.Sprintf("pagination_%d@example.com", time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Role:         models.RoleMember,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+	}
+
+	token := env.token(t, user)
+
+	// Crear 5 tareas con fechas de vencimiento distintas; dos comparten fecha para probar el desempate por id
+	base := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	dueDates := []time.Time{base.Add(3 * time.Hour), base, base.Add(time.Hour), base, base.Add(2 * time.Hour)}
+	for i, due := range dueDates {
+		task := models.Task{
+			Title:       fmt.Sprintf("Tarea paginada %d", i),
+			Description: "Tarea para probar la paginación",
+			Status:      "pending",
+			Priority:    "medium",
+			DueDate:     due,
+			CreatorID:   user.ID,
+			AssigneeID:  user.ID,
+		}
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatalf("❌ No se pudo crear la tarea: %v", err)
+		}
+	}
+
+	type listResponse struct {
+		Status string          `json:"status"`
+		Data   []models.Task   `json:"data"`
+		Meta   models.PageMeta `json:"meta"`
+	}
+	fetch := func(query url.Values) (int, listResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/tasks?"+query.Encode(), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := env.App.Test(req, -1)
+		assert.NoError(t, err, "No debería haber error en el listado")
+		var body listResponse
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body
+	}
+
+	//  CASO 1: RECORRER TODAS LAS PÁGINAS SIGUIENDO next_cursor
+	t.Log("🧪 Probando caso 1: Recorrer páginas con cursor")
+	var collected []models.Task
+	query := url.Values{"limit": {"2"}, "sort": {"due_date"}}
+	for pages := 0; pages < 10; pages++ {
+		status, body := fetch(query)
+		assert.Equal(t, http.StatusOK, status, "El listado debería ser exitoso")
+		assert.Equal(t, int64(len(dueDates)), body.Meta.Total, "El total debería contar todas las tareas")
+		assert.Equal(t, "due_date,id", body.Meta.Sort, "El orden debería incluir el id como desempate")
+		assert.LessOrEqual(t, len(body.Data), 2, "Cada página debería respetar el límite")
+		collected = append(collected, body.Data...)
+		if body.Meta.NextCursor == nil {
+			break
+		}
+		query.Set("cursor", *body.Meta.NextCursor)
+	}
+
+	assert.Len(t, collected, len(dueDates), "Deberían obtenerse todas las tareas sin duplicados")
+	for i := 1; i < len(collected); i++ {
+		prev, curr := collected[i-1], collected[i]
+		ordered := prev.DueDate.Before(curr.DueDate) || (prev.DueDate.Equal(curr.DueDate) && prev.ID < curr.ID)
+		assert.True(t, ordered, "Las tareas deberían estar ordenadas por due_date e id")
+	}
+	t.Log("✅ Paginación por cursor correcta")
+
+	//  CASO 2: ORDEN INVÁLIDO
+	t.Log("🧪 Probando caso 2: Campo de ordenamiento inválido")
+	status, _ := fetch(url.Values{"sort": {"password_hash"}})
+	assert.Equal(t, http.StatusBadRequest, status, "Un campo de orden desconocido debería retornar 400")
+
+	//  CASO 3: CURSOR DE OTRO ORDEN
+	t.Log("🧪 Probando caso 3: Cursor generado con otro orden")
+	_, first := fetch(url.Values{"limit": {"1"}, "sort": {"due_date"}})
+	if assert.NotNil(t, first.Meta.NextCursor, "Debería existir una página siguiente") {
+		status, _ = fetch(url.Values{"limit": {"1"}, "sort": {"-priority"}, "cursor": {*first.Meta.NextCursor}})
+		assert.Equal(t, http.StatusBadRequest, status, "Un cursor de otro orden debería retornar 400")
+	}
+
+	//  CASO 4: LÍMITE FUERA DE RANGO
+	t.Log("🧪 Probando caso 4: Límite fuera de rango")
+	status, _ = fetch(url.Values{"limit": {"1000"}})
+	assert.Equal(t, http.StatusBadRequest, status, "Un límite mayor al máximo debería retornar 400")
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/pkg/models"
+)
+
+func TestTaskPatch(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
+
+	user := &models.User{
+		FirstName:    "Test",
+		LastName:     "Patch",
+		Email:        fmt.Sprintf("patch_%d@example.com", time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Role:         models.RoleMember,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+	}
+
+	token := env.token(t, user)
+	var etag string
+	send := func(method, path, contentType string, payload interface{}) (int, map[string]interface{}) {
+		resp, decoded := env.request(t, method, path, token, payload, map[string]string{"Content-Type": contentType, "If-Match": etag})
+		if newETag := resp.Header.Get("ETag"); newETag != "" {
+			etag = newETag
+		}
+		return resp.StatusCode, decoded
+	}
+	const (
+		jsonType  = "application/json"
+		mergeType = "application/merge-patch+json"
+		patchType = "application/json-patch+json"
+	)
+
+	_, body := send(http.MethodPost, "/projects", jsonType, map[string]string{"name": "Proyecto patch"})
+	projectID := body["data"].(map[string]interface{})["id"]
+	status, body := send(http.MethodPost, "/tasks", jsonType, map[string]interface{}{
+		"title":       "Tarea para patch",
+		"description": "Descripción que se va a borrar",
+		"due_date":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+		"priority":    "high",
+		"project_id":  projectID,
+	})
+	if !assert.Equal(t, http.StatusCreated, status, "La creación debería ser exitosa") {
+		t.FailNow()
+	}
+	path := fmt.Sprintf("/tasks/%v", body["data"].(map[string]interface{})["id"])
+
+	//  CASO 1: MERGE PATCH
+	t.Log("🧪 Probando caso 1: JSON Merge Patch")
+	status, body = send(http.MethodPatch, path, mergeType, map[string]interface{}{
+		"description": "",
+		"project_id":  nil,
+		"status":      "in_progress",
+	})
+	assert.Equal(t, http.StatusOK, status, "El merge patch debería ser exitoso")
+	data := body["data"].(map[string]interface{})
+	assert.Equal(t, "", data["description"], "El merge patch debería poder vaciar la descripción")
+	assert.Nil(t, data["project_id"], "null debería quitar la tarea del proyecto")
+	assert.Equal(t, "in_progress", data["status"], "El estado debería actualizarse")
+	assert.Equal(t, "high", data["priority"], "Los campos omitidos no deberían cambiar")
+
+	//  CASO 2: JSON PATCH
+	t.Log("🧪 Probando caso 2: JSON Patch")
+	status, body = send(http.MethodPatch, path, patchType, []map[string]interface{}{
+		{"op": "test", "path": "/status", "value": "in_progress"},
+		{"op": "replace", "path": "/title", "value": "Título con JSON Patch"},
+		{"op": "copy", "from": "/title", "path": "/description"},
+	})
+	assert.Equal(t, http.StatusOK, status, "El JSON patch debería ser exitoso")
+	data = body["data"].(map[string]interface{})
+	assert.Equal(t, "Título con JSON Patch", data["title"], "replace debería cambiar el título")
+	assert.Equal(t, "Título con JSON Patch", data["description"], "copy debería copiar el título a la descripción")
+
+	//  CASO 3: EL PATCH ES ATÓMICO
+	t.Log("🧪 Probando caso 3: Atomicidad")
+	status, _ = send(http.MethodPatch, path, patchType, []map[string]interface{}{
+		{"op": "replace", "path": "/title", "value": "No debería guardarse"},
+		{"op": "test", "path": "/status", "value": "complete"},
+	})
+	assert.Equal(t, http.StatusConflict, status, "Un test fallido debería retornar 409")
+	var stored models.Task
+	db.First(&stored, data["id"])
+	assert.Equal(t, "Título con JSON Patch", stored.Title, "Ninguna operación debería aplicarse si una falla")
+
+	//  CASO 4: ERRORES DE VALIDACIÓN
+	t.Log("🧪 Probando caso 4: Patches inválidos")
+	cases := []struct {
+		contentType string
+		payload     interface{}
+		expected    int
+		reason      string
+	}{
+		{jsonType, map[string]string{"title": "x"}, http.StatusUnsupportedMediaType, "application/json no es un tipo de patch"},
+		{mergeType, "{no es json", http.StatusBadRequest, "un merge patch mal formado"},
+		{patchType, map[string]string{"op": "replace"}, http.StatusBadRequest, "un JSON patch que no es un arreglo"},
+		{patchType, []map[string]interface{}{{"op": "rename", "path": "/title"}}, http.StatusBadRequest, "una operación desconocida"},
+		{patchType, []map[string]interface{}{{"op": "replace", "path": "/color", "value": "rojo"}}, http.StatusConflict, "reemplazar un campo inexistente"},
+		{mergeType, map[string]interface{}{"color": "rojo"}, http.StatusUnprocessableEntity, "un campo desconocido"},
+		{mergeType, map[string]interface{}{"status": "archived"}, http.StatusUnprocessableEntity, "un estado inválido"},
+		{mergeType, map[string]interface{}{"title": nil}, http.StatusUnprocessableEntity, "borrar un campo requerido"},
+		{mergeType, map[string]interface{}{"due_date": "mañana"}, http.StatusUnprocessableEntity, "una fecha inválida"},
+		{patchType, []map[string]interface{}{{"op": "replace", "path": "/priority", "value": 3}}, http.StatusUnprocessableEntity, "un tipo inválido"},
+	}
+	for _, tc := range cases {
+		status, _ = send(http.MethodPatch, path, tc.contentType, tc.payload)
+		assert.Equal(t, tc.expected, status, "Debería rechazarse %s", tc.reason)
+	}
+
+	//  CASO 5: PUT REEMPLAZA LA TAREA COMPLETA
+	t.Log("🧪 Probando caso 5: PUT como reemplazo completo")
+	status, _ = send(http.MethodPut, path, jsonType, map[string]interface{}{"title": "Solo el título"})
+	assert.Equal(t, http.StatusBadRequest, status, "PUT sin los campos requeridos debería retornar 400")
+	status, body = send(http.MethodPut, path, jsonType, map[string]interface{}{
+		"title":       "Tarea reemplazada",
+		"description": "Reemplazo completo",
+		"due_date":    time.Now().Add(48 * time.Hour).Format(time.RFC3339),
+	})
+	assert.Equal(t, http.StatusOK, status, "El PUT completo debería ser exitoso")
+	data = body["data"].(map[string]interface{})
+	assert.Equal(t, "pending", data["status"], "Los campos omitidos en PUT deberían volver a su valor por defecto")
+	assert.Equal(t, "medium", data["priority"], "Los campos omitidos en PUT deberían volver a su valor por defecto")
+
+	t.Log("✅ Todos los casos de PATCH y PUT pasaron correctamente")
+}
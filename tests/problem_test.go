@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/internal/problem"
+)
+
+// TestProblemResponses prueba problem.ErrorHandler sobre rutas sintéticas que devuelven cada tipo de
+// error, sin base de datos ni rutas de la API
+func TestProblemResponses(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: problem.ErrorHandler})
+	app.Get("/conflict", func(c *fiber.Ctx) error {
+		return problem.New(fiber.StatusConflict, problem.CodeInvalidTransition, "No se puede pasar de 'pending' a 'complete'.").
+			With("allowed_transitions", []string{"in_progress"}).
+			Send(c)
+	})
+	app.Get("/returned", func(c *fiber.Ctx) error {
+		return problem.New(fiber.StatusForbidden, problem.CodeForbidden, "Sin permiso.")
+	})
+	app.Get("/unexpected", func(c *fiber.Ctx) error {
+		return errors.New("detalle interno que no debe exponerse")
+	})
+
+	get := func(path string) (*http.Response, map[string]interface{}) {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil), -1)
+		assert.NoError(t, err, "No debería haber error en la request")
+		var decoded map[string]interface{}
+		_ = json.NewDecoder(resp.Body).Decode(&decoded)
+		return resp, decoded
+	}
+
+	//  CASO 1: MIEMBROS ESTÁNDAR Y EXTENSIONES
+	t.Log("🧪 Probando caso 1: Problema escrito por un handler")
+	resp, body := get("/conflict")
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "Debería responder con el estado del problema")
+	assert.Equal(t, problem.ContentType, resp.Header.Get("Content-Type"), "Debería responder application/problem+json")
+	assert.Equal(t, "urn:legendaryum:problem:invalid_transition", body["type"], "El tipo debería derivarse del código")
+	assert.Equal(t, "Transición de estado no permitida", body["title"], "Debería incluir el título del código")
+	assert.Equal(t, float64(http.StatusConflict), body["status"], "Debería incluir el estado HTTP")
+	assert.Equal(t, "invalid_transition", body["code"], "Debería incluir el código estable")
+	assert.Equal(t, "/conflict", body["instance"], "La instancia debería ser la ruta de la solicitud")
+	assert.Equal(t, []interface{}{"in_progress"}, body["allowed_transitions"], "Las extensiones deberían ir al mismo nivel")
+
+	//  CASO 2: ERRORES DEVUELTOS AL MANEJADOR DE FIBER
+	t.Log("🧪 Probando caso 2: ErrorHandler")
+	resp, body = get("/returned")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "Un *Problem devuelto debería escribirse tal cual")
+	assert.Equal(t, "forbidden", body["code"], "Debería conservar su código")
+
+	resp, body = get("/no-existe")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Una ruta inexistente debería responder 404")
+	assert.Equal(t, problem.ContentType, resp.Header.Get("Content-Type"), "Debería responder application/problem+json")
+	assert.Equal(t, "not_found", body["code"], "Debería usar el código del estado HTTP")
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	resp, body = get("/unexpected")
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode, "Un error inesperado debería responder 500")
+	assert.Equal(t, "internal_error", body["code"], "Debería usar el código de error interno")
+	assert.NotContains(t, body["detail"], "detalle interno", "No debería exponer el error interno")
+	assert.Contains(t, logs.String(), "GET /unexpected", "Debería registrar el método y la ruta")
+	assert.Contains(t, logs.String(), "detalle interno que no debe exponerse", "Debería registrar el error interno")
+
+	t.Log("✅ Todos los casos de problem+json pasaron correctamente")
+}
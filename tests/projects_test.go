@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/pkg/models"
+)
+
+func TestProjects(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
+
+	timestamp := time.Now().UnixNano()
+	newUser := func(name string) *models.User {
+		user := &models.User{
+			FirstName:    "Test",
+			LastName:     name,
+			Email:        fmt.Sprintf("projects_%s_%d@example.com", name, timestamp),
+			PasswordHash: "hash",
+			Role:         models.RoleMember,
+		}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("❌ No se pudo crear el usuario %s: %v", name, err)
+		}
+		return user
+	}
+	owner, member, outsider := newUser("owner"), newUser("member"), newUser("outsider")
+
+	// Las requests se autentican con el token de currentUser
+	currentUser := owner
+	send := func(method, path string, payload interface{}) (int, map[string]interface{}) {
+		resp, decoded := env.request(t, method, path, env.token(t, currentUser), payload, nil)
+		return resp.StatusCode, decoded
+	}
+
+	//  CASO 1: EL CREADOR DEL PROYECTO QUEDA COMO OWNER
+	t.Log("🧪 Probando caso 1: Crear proyecto")
+	status, created := send(http.MethodPost, "/projects", map[string]string{"name": "Proyecto de prueba"})
+	assert.Equal(t, http.StatusCreated, status, "La creación del proyecto debería ser exitosa")
+	projectID := created["data"].(map[string]interface{})["id"]
+	projectPath := fmt.Sprintf("/projects/%v", projectID)
+
+	status, _ = send(http.MethodPost, projectPath+"/members", map[string]string{"user_id": member.ID})
+	assert.Equal(t, http.StatusCreated, status, "El owner debería poder agregar miembros")
+
+	status, created = send(http.MethodPost, "/tasks", map[string]interface{}{
+		"title":       "Tarea del proyecto",
+		"description": "Tarea visible para los miembros",
+		"due_date":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+		"project_id":  projectID,
+	})
+	assert.Equal(t, http.StatusCreated, status, "La creación de la tarea en el proyecto debería ser exitosa")
+	taskPath := fmt.Sprintf("/tasks/%v", created["data"].(map[string]interface{})["id"])
+
+	//  CASO 2: LOS MIEMBROS VEN TODAS LAS TAREAS DEL PROYECTO
+	t.Log("🧪 Probando caso 2: Visibilidad para miembros")
+	currentUser = member
+	status, _ = send(http.MethodGet, taskPath, nil)
+	assert.Equal(t, http.StatusOK, status, "Un miembro debería ver las tareas del proyecto")
+	status, list := send(http.MethodGet, fmt.Sprintf("/tasks?project_id=%v", projectID), nil)
+	assert.Equal(t, http.StatusOK, status, "El listado filtrado por proyecto debería ser exitoso")
+	assert.Len(t, list["data"], 1, "El miembro debería ver la tarea del proyecto")
+	status, _ = send(http.MethodPost, projectPath+"/members", map[string]string{"user_id": outsider.ID})
+	assert.Equal(t, http.StatusForbidden, status, "Un miembro sin rol owner no debería agregar miembros")
+
+	//  CASO 3: LOS AJENOS NO VEN NI USAN EL PROYECTO
+	t.Log("🧪 Probando caso 3: Usuario ajeno")
+	currentUser = outsider
+	status, _ = send(http.MethodGet, taskPath, nil)
+	assert.Equal(t, http.StatusNotFound, status, "Un usuario ajeno no debería ver la tarea")
+	status, _ = send(http.MethodGet, projectPath, nil)
+	assert.Equal(t, http.StatusNotFound, status, "Un usuario ajeno no debería ver el proyecto")
+	status, _ = send(http.MethodPost, "/tasks", map[string]interface{}{
+		"title":       "Intrusa",
+		"description": "No debería crearse",
+		"due_date":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+		"project_id":  projectID,
+	})
+	assert.Equal(t, http.StatusBadRequest, status, "Un usuario ajeno no debería crear tareas en el proyecto")
+
+	//  CASO 4: BAJAS DE MIEMBROS
+	t.Log("🧪 Probando caso 4: Quitar miembros")
+	currentUser = member
+	status, _ = send(http.MethodDelete, fmt.Sprintf("%s/members/%s", projectPath, member.ID), nil)
+	assert.Equal(t, http.StatusOK, status, "Un miembro debería poder salir del proyecto")
+	status, _ = send(http.MethodGet, taskPath, nil)
+	assert.Equal(t, http.StatusNotFound, status, "Tras salir, el miembro no debería ver la tarea")
+
+	currentUser = owner
+	status, _ = send(http.MethodDelete, fmt.Sprintf("%s/members/%s", projectPath, owner.ID), nil)
+	assert.Equal(t, http.StatusConflict, status, "El último owner no debería poder salir del proyecto")
+	status, _ = send(http.MethodDelete, projectPath+"/members/no-es-un-uuid", nil)
+	assert.Equal(t, http.StatusNotFound, status, "Un ID de usuario que no es UUID debería retornar 404")
+
+	//  CASO 5: ELIMINAR EL PROYECTO DEJA SUS TAREAS SIN PROYECTO Y LO REGISTRA EN SU HISTORIAL
+	t.Log("🧪 Probando caso 5: Eliminar el proyecto")
+	status, _ = send(http.MethodDelete, projectPath, nil)
+	assert.Equal(t, http.StatusOK, status, "El owner debería poder eliminar el proyecto")
+	status, history := send(http.MethodGet, taskPath+"/history", nil)
+	assert.Equal(t, http.StatusOK, status, "El historial debería obtenerse")
+	events := history["data"].([]interface{})
+	last := events[len(events)-1].(map[string]interface{})
+	assert.Equal(t, models.TaskEventUpdated, last["action"], "Debería registrar la actualización de la tarea")
+	projectChange := last["changes"].(map[string]interface{})["project_id"].(map[string]interface{})
+	assert.Equal(t, projectID, projectChange["from"], "Debería registrar el proyecto anterior")
+	assert.Nil(t, projectChange["to"], "Debería registrar que la tarea quedó sin proyecto")
+}
@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/internal/config"
+	"legendaryum/internal/middleware"
+	"legendaryum/pkg/models"
+	"legendaryum/pkg/utils"
+)
+
+// TestRoleMiddleware prueba los middlewares de roles sobre rutas sintéticas, sin base de datos: cubre
+// combinaciones de rol y permiso que no dependen de ninguna ruta concreta. La autorización de las
+// rutas reales se prueba con la aplicación completa en TestAdminManagesAnyTask y en las demás suites.
+func TestRoleMiddleware(t *testing.T) {
+	cfg := &config.Config{
+		JWTSecret: "testsecretkey12345678901234567890",
+		JWTExpiry: "15m",
+	}
+
+	app := fiber.New()
+	requireAuth := middleware.AuthMiddleware(cfg, nil)
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/read", requireAuth, middleware.RequirePermission(models.PermTasksRead), ok)
+	app.Post("/write", requireAuth, middleware.RequirePermission(models.PermTasksWrite), ok)
+	app.Get("/admin", requireAuth, middleware.RequireRole(models.RoleAdmin), ok)
+
+	request := func(method, path, role string) int {
+		token, err := utils.GenerateJWT("user-"+role, role, cfg.JWTSecret, cfg.JWTExpiry)
+		assert.NoError(t, err, "El token debería generarse")
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err, "No debería haber error en la request")
+		return resp.StatusCode
+	}
+
+	//  CASO 1: LOS VIEWERS SOLO LEEN
+	t.Log("🧪 Probando caso 1: Viewer de solo lectura")
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/read", models.RoleViewer), "Un viewer debería poder leer")
+	assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "/write", models.RoleViewer), "Un viewer no debería poder escribir")
+
+	//  CASO 2: LOS MEMBERS ESCRIBEN PERO NO ADMINISTRAN
+	t.Log("🧪 Probando caso 2: Member")
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/write", models.RoleMember), "Un member debería poder escribir")
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/admin", models.RoleMember), "Un member no debería acceder a rutas de administración")
+
+	//  CASO 3: LOS ADMINISTRADORES ACCEDEN A TODO
+	t.Log("🧪 Probando caso 3: Admin")
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/write", models.RoleAdmin), "Un admin debería poder escribir")
+	assert.Equal(t, http.StatusOK, request(http.MethodGet, "/admin", models.RoleAdmin), "Un admin debería acceder a rutas de administración")
+
+	//  CASO 4: TOKENS SIN ROL SE TRATAN COMO MEMBER
+	t.Log("🧪 Probando caso 4: Token sin rol")
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/write", ""), "Un token sin rol debería tratarse como member")
+	assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/admin", ""), "Un token sin rol no debería ser admin")
+}
+
+func TestAdminManagesAnyTask(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
+
+	timestamp := time.Now().UnixNano()
+	newUser := func(name, role string) *models.User {
+		user := &models.User{
+			FirstName:    "Test",
+			LastName:     name,
+			Email:        fmt.Sprintf("rbac_%s_%d@example.com", name, timestamp),
+			PasswordHash: "hash",
+			Role:         role,
+		}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("❌ No se pudo crear el usuario %s: %v", name, err)
+		}
+		return user
+	}
+	owner, admin, other := newUser("owner", models.RoleMember), newUser("admin", models.RoleAdmin), newUser("other", models.RoleMember)
+
+	task := models.Task{
+		Title:       "Tarea ajena",
+		Description: "Tarea para probar roles",
+		Status:      "pending",
+		Priority:    "medium",
+		DueDate:     time.Now().Add(24 * time.Hour),
+		CreatorID:   owner.ID,
+		AssigneeID:  owner.ID,
+	}
+	if err := db.Create(&task).Error; err != nil {
+		t.Fatalf("❌ No se pudo crear la tarea: %v", err)
+	}
+
+	// Las requests se autentican con el token de currentUser, que lleva su rol
+	currentUser := other
+	send := func(method, path string, payload interface{}) int {
+		// Estos casos no prueban la concurrencia: If-Match * acepta cualquier versión de la tarea
+		headers := map[string]string{"If-Match": "*"}
+		if method == http.MethodPatch {
+			headers["Content-Type"] = "application/merge-patch+json"
+		}
+		resp, _ := env.request(t, method, path, env.token(t, currentUser), payload, headers)
+		return resp.StatusCode
+	}
+	taskPath := fmt.Sprintf("/tasks/%d", task.ID)
+
+	//  CASO 1: UN MEMBER AJENO NO VE NI MODIFICA LA TAREA
+	t.Log("🧪 Probando caso 1: Member ajeno")
+	assert.Equal(t, http.StatusNotFound, send(http.MethodGet, taskPath, nil), "Un member ajeno no debería ver la tarea")
+	assert.Equal(t, http.StatusForbidden, send(http.MethodPatch, taskPath, map[string]string{"status": "in_progress"}), "Un member ajeno no debería actualizar la tarea")
+
+	//  CASO 2: UN ADMIN GESTIONA CUALQUIER TAREA
+	t.Log("🧪 Probando caso 2: Admin")
+	currentUser = admin
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, taskPath, nil), "Un admin debería ver cualquier tarea")
+	assert.Equal(t, http.StatusOK, send(http.MethodPatch, taskPath, map[string]string{"status": "in_progress"}), "Un admin debería actualizar cualquier tarea")
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, taskPath, nil), "Un admin debería eliminar cualquier tarea")
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	admin := env.createUser(t, "Administrador", models.RoleAdmin)
+	member := env.createUser(t, "Miembro", models.RoleMember)
+	rolePath := fmt.Sprintf("/users/%s/role", member.ID)
+
+	//  CASO 1: SOLO UN ADMIN CAMBIA ROLES
+	t.Log("🧪 Probando caso 1: Permisos")
+	resp, _ := env.request(t, http.MethodPut, rolePath, env.token(t, member), map[string]string{"role": models.RoleAdmin}, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "Un member no debería cambiar roles")
+
+	//  CASO 2: ROL INVÁLIDO
+	t.Log("🧪 Probando caso 2: Rol inválido")
+	resp, body := env.request(t, http.MethodPut, rolePath, env.token(t, admin), map[string]string{"role": "superuser"}, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Un rol desconocido debería retornar 400")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"field": "role", "error": "debe ser uno de: admin, member, viewer"},
+	}, body["errors"], "Debería informar el error del campo role")
+
+	//  CASO 3: USUARIO INEXISTENTE
+	t.Log("🧪 Probando caso 3: Usuario inexistente")
+	resp, _ = env.request(t, http.MethodPut, "/users/no-es-un-uuid/role", env.token(t, admin), map[string]string{"role": models.RoleViewer}, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Un ID que no es UUID debería retornar 404")
+	resp, _ = env.request(t, http.MethodPut, "/users/00000000-0000-0000-0000-000000000000/role", env.token(t, admin), map[string]string{"role": models.RoleViewer}, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Un usuario inexistente debería retornar 404")
+
+	//  CASO 4: CAMBIO DE ROL
+	t.Log("🧪 Probando caso 4: Cambio de rol")
+	resp, body = env.request(t, http.MethodPut, rolePath, env.token(t, admin), map[string]string{"role": models.RoleViewer}, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "El admin debería poder cambiar el rol")
+	assert.Equal(t, models.RoleViewer, body["data"].(map[string]interface{})["role"], "Debería devolver el rol nuevo")
+	var stored models.User
+	assert.NoError(t, env.DB.First(&stored, "id = ?", member.ID).Error)
+	assert.Equal(t, models.RoleViewer, stored.Role, "El rol debería quedar persistido")
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/pkg/models"
+	"legendaryum/pkg/utils"
+)
+
+func TestAuthRefresh(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
+
+	testEmail := fmt.Sprintf("refresh_%d@example.com", time.Now().UnixNano())
+	testPassword := "testrefresh123"
+	hash, err := utils.HashPassword(testPassword)
+	if err != nil {
+		t.Fatalf("❌ No se pudo hashear la contraseña: %v", err)
+	}
+	if err := db.Create(&models.User{FirstName: "Test", LastName: "Refresh", Email: testEmail, PasswordHash: hash}).Error; err != nil {
+		t.Fatalf("❌ No se pudo crear el usuario de prueba: %v", err)
+	}
+
+	post := func(path string, payload interface{}) (int, map[string]interface{}) {
+		resp, decoded := env.request(t, http.MethodPost, path, "", payload, nil)
+		return resp.StatusCode, decoded
+	}
+	refreshTokenOf := func(response map[string]interface{}) string {
+		data, _ := response["data"].(map[string]interface{})
+		token, _ := data["refresh_token"].(string)
+		return token
+	}
+
+	//  CASO 1: EL LOGIN DEVUELVE AMBOS TOKENS
+	t.Log("🧪 Probando caso 1: Login devuelve access y refresh token")
+	status, loginResponse := post("/auth/login", map[string]interface{}{"email": testEmail, "password": testPassword})
+	assert.Equal(t, http.StatusOK, status, "Login debería ser exitoso")
+	original := refreshTokenOf(loginResponse)
+	assert.NotEmpty(t, original, "El login debería devolver un refresh token")
+
+	//  CASO 2: RENOVAR CON EL REFRESH TOKEN
+	t.Log("🧪 Probando caso 2: Renovar sesión")
+	status, refreshResponse := post("/auth/refresh", map[string]interface{}{"refresh_token": original})
+	assert.Equal(t, http.StatusOK, status, "La renovación debería ser exitosa")
+	rotated := refreshTokenOf(refreshResponse)
+	assert.NotEmpty(t, rotated, "La renovación debería devolver un refresh token nuevo")
+	assert.NotEqual(t, original, rotated, "El refresh token debería rotar")
+
+	// Solo se guarda el hash del token
+	var stored models.RefreshToken
+	assert.NoError(t, db.Where("token_hash = ?", utils.HashToken(rotated)).First(&stored).Error, "El token rotado debería estar persistido por su hash")
+
+	//  CASO 3: REUTILIZAR EL TOKEN YA ROTADO REVOCA LA FAMILIA
+	t.Log("🧪 Probando caso 3: Reutilización de un token rotado")
+	status, _ = post("/auth/refresh", map[string]interface{}{"refresh_token": original})
+	assert.Equal(t, http.StatusUnauthorized, status, "Un token ya rotado debería ser rechazado")
+
+	status, _ = post("/auth/refresh", map[string]interface{}{"refresh_token": rotated})
+	assert.Equal(t, http.StatusUnauthorized, status, "Tras detectar la reutilización toda la familia debería estar revocada")
+
+	//  CASO 4: TOKEN DESCONOCIDO
+	t.Log("🧪 Probando caso 4: Refresh token desconocido")
+	status, _ = post("/auth/refresh", map[string]interface{}{"refresh_token": "no-existe"})
+	assert.Equal(t, http.StatusUnauthorized, status, "Un token desconocido debería ser rechazado")
+}
@@ -2,91 +2,32 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
-	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 
 	// Importa tus paquetes internos
-	"legendaryum/internal/auth"
-	"legendaryum/internal/config"
+	"legendaryum/internal/users"
 	"legendaryum/pkg/models"
-
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
 )
 
 func TestAuthRegister(t *testing.T) {
-	// Setup de la aplicación Fiber
-	app := fiber.New()
-
-	// Cargar configuración del .env
-	cfg, err := config.Load()
-	if nil != err {
-		t.Fatalf("❌ No se pudo cargar la configuración: %v", err)
-	}
-
-	// Configuración para el test (JWT todavía hardcodeado, pero DB del .env)
-	// cfg := &config.Config{
-	// 	JWTSecret: "testsecretkey12345678901234567890",
-	// 	JWTExpiry: "24h",
-	// }
-
-	// Conexión a la base de datos real usando configuración del .env
-	// dsn := "host=localhost user=postgres password=postgres dbname=legendaryum_db port=5432 sslmode=disable"
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPass, cfg.DBName)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		t.Fatalf("❌ No se pudo conectar a la base de datos: %v", err)
-	}
-	t.Log("✅ Conexión a base de datos establecida")
-
-	// Migración automática de tablas
-	if err := db.AutoMigrate(&models.User{}); err != nil {
-		t.Fatalf("❌ No se pudo migrar el modelo User: %v", err)
-	}
-	t.Log("✅ Migración de tablas completada")
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
 
 	// Generar email único para evitar conflictos
 	timestamp := time.Now().UnixNano()
 	testEmail := fmt.Sprintf("test_%d@example.com", timestamp)
 	t.Logf("📧 Email de prueba: %s", testEmail)
 
-	//  INICIAR TRANSACCIÓN AQUÍ
-	tx := db.Begin()
-	if tx.Error != nil {
-		t.Fatalf("❌ No se pudo iniciar la transacción: %v", tx.Error)
-	}
-	t.Log("🔄 Transacción iniciada")
-
-	// Asegurar rollback en caso de panic
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-			t.Logf("🚨 Panic detectado, rollback ejecutado: %v", r)
-		}
-	}()
-
-	// Rollback automático al final del test
-	defer func() {
-		if err := tx.Rollback().Error; err != nil {
-			t.Logf("⚠️ Error en rollback: %v", err)
-		} else {
-			t.Log("🔙 Rollback ejecutado - base de datos limpia")
-		}
-	}()
-
-	//  CONFIGURAR HANDLER CON LA TRANSACCIÓN
-	h := auth.NewHandler(tx, cfg) // Usar 'tx' en lugar de 'db'
-	app.Post("/auth/register", h.Register)
-	t.Log("🎯 Handler configurado con transacción")
-
 	// Preparar payload del test
 	payload := map[string]interface{}{
 		"first_name": "Lucas Nahuel",
@@ -104,7 +45,7 @@ func TestAuthRegister(t *testing.T) {
 
 	//  EJECUTAR EL TEST CON HTTPTEST
 	t.Log("🚀 Ejecutando request...")
-	resp, err := app.Test(req, -1) // app.Test usa httptest internamente
+	resp, err := env.App.Test(req, -1) // app.Test usa httptest internamente
 	if err != nil {
 		t.Fatalf("❌ Error ejecutando request: %v", err)
 	}
@@ -117,14 +58,14 @@ func TestAuthRegister(t *testing.T) {
 		t.Logf("❌ Status code incorrecto: %d", resp.StatusCode)
 	}
 
-	// Verificar que el usuario se creó en la base de datos (dentro de la transacción)
+	// Verificar que el usuario se creó en la base de datos
 	var user models.User
-	result := tx.Where("email = ?", testEmail).First(&user)
+	result := db.Where("email = ?", testEmail).First(&user)
 
 	// Assertions detalladas
 	assert.NoError(t, result.Error, "El usuario debería existir en la base de datos")
 	if result.Error == nil {
-		t.Log("✅ Usuario encontrado en la base de datos (dentro de transacción)")
+		t.Log("✅ Usuario encontrado en la base de datos")
 
 		assert.Equal(t, "Lucas Nahuel", user.FirstName, "El nombre debería coincidir")
 		assert.Equal(t, "Rodriguez", user.LastName, "El apellido debería coincidir")
@@ -147,37 +88,35 @@ func TestAuthRegister(t *testing.T) {
 	t.Log("🎉 Test completado exitosamente")
 }
 
-// Test adicional para verificar que la limpieza funciona
-func TestDatabaseCleanup(t *testing.T) {
-	//  CONECTAR A LA BASE DE DATOS REAL (SIN TRANSACCIÓN)
-	dsn := "host=localhost user=postgres password=postgres dbname=legendaryum_db port=5432 sslmode=disable"
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		t.Fatalf("❌ No se pudo conectar a la base de datos: %v", err)
-	}
-
-	//  INICIAR TRANSACCIÓN PARA ESTE TEST TAMBIÉN
-	tx := db.Begin()
-	if tx.Error != nil {
-		t.Fatalf("❌ No se pudo iniciar la transacción: %v", tx.Error)
+func TestUserRepositoryConcurrentRegistration(t *testing.T) {
+	// Base aislada y migrada del entorno de pruebas (ver harness_test.go). Sin transacción: cada
+	// registro usa su propia conexión, como dos requests simultáneas.
+	env := newTestEnv(t)
+	repo := users.NewPostgresUserRepository(env.DB)
+	email := fmt.Sprintf("concurrent_%d@example.com", time.Now().UnixNano())
+
+	t.Log("🧪 Probando registros simultáneos con el mismo email")
+	const attempts = 5
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = repo.Create(context.Background(), &models.User{
+				FirstName: "Test", LastName: "Concurrente", Email: email, PasswordHash: "hash", Role: models.RoleMember,
+			})
+		}(i)
 	}
-	defer tx.Rollback()
-
-	// Verificar que no hay usuarios de test en la base de datos real
-	var count int64
-	tx.Model(&models.User{}).Where("email LIKE ?", "test_%@example.com").Count(&count)
-
-	assert.Equal(t, int64(0), count, "No debería haber usuarios de test en la base de datos real")
-	if count == 0 {
-		t.Log("✅ Base de datos limpia - no hay usuarios de test residuales")
-	} else {
-		t.Logf("⚠️  Encontrados %d usuarios de test en la base de datos real", count)
+	wg.Wait()
 
-		// Si hay usuarios residuales, mostrarlos
-		var users []models.User
-		tx.Where("email LIKE ?", "test_%@example.com").Find(&users)
-		for _, u := range users {
-			t.Logf("   - Usuario residual: ID=%s, Email=%s", u.ID, u.Email)
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+			continue
 		}
+		assert.ErrorIs(t, err, users.ErrEmailTaken, "Los registros repetidos deberían devolver ErrEmailTaken")
 	}
+	assert.Equal(t, 1, created, "Solo un registro debería crear el usuario")
 }
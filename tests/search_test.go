@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/pkg/models"
+)
+
+func TestTaskSearch(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
+
+	newUser := func(name string) *models.User {
+		user := &models.User{
+			FirstName:    "Test",
+			LastName:     name,
+			Email:        fmt.Sprintf("search_%s_%d@example.com", strings.ToLower(name), time.Now().UnixNano()),
+			PasswordHash: "hash",
+			Role:         models.RoleMember,
+		}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+		}
+		return user
+	}
+	user := newUser("Buscador")
+	other := newUser("Ajeno")
+
+	token := env.token(t, user)
+
+	// Un término exclusivo evita coincidencias con datos de otros tests
+	term := fmt.Sprintf("zorzal%d", time.Now().UnixNano())
+	createTask := func(creatorID, title, description string) uint {
+		task := &models.Task{
+			Title:       title,
+			Description: description,
+			Status:      "pending",
+			Priority:    "medium",
+			DueDate:     time.Now().Add(24 * time.Hour),
+			CreatorID:   creatorID,
+			AssigneeID:  creatorID,
+		}
+		if err := db.Create(task).Error; err != nil {
+			t.Fatalf("❌ No se pudo crear la tarea: %v", err)
+		}
+		return task.ID
+	}
+	titleMatch := createTask(user.ID, "Migrar "+term, "Cambiar la base de datos")
+	descriptionMatch := createTask(user.ID, "Revisar despliegue", "Pendiente: validar "+term+" en staging")
+	createTask(user.ID, "Tarea sin relación", "Nada que ver con la búsqueda")
+	createTask(other.ID, "Ajena con "+term, "No debería aparecer "+term)
+
+	search := func(query url.Values) (int, map[string]interface{}) {
+		resp, decoded := env.request(t, http.MethodGet, "/tasks?"+query.Encode(), token, nil, nil)
+		return resp.StatusCode, decoded
+	}
+
+	//  CASO 1: RESULTADOS ORDENADOS POR RELEVANCIA
+	t.Log("🧪 Probando caso 1: Búsqueda con ranking")
+	status, body := search(url.Values{"q": {term}})
+	assert.Equal(t, http.StatusOK, status, "La búsqueda debería ser exitosa")
+	data := body["data"].([]interface{})
+	if assert.Len(t, data, 2, "Solo deberían encontrarse las 2 tareas visibles que contienen el término") {
+		first := data[0].(map[string]interface{})
+		second := data[1].(map[string]interface{})
+		assert.Equal(t, float64(titleMatch), first["id"], "La coincidencia en el título debería tener mayor relevancia")
+		assert.Equal(t, float64(descriptionMatch), second["id"], "La coincidencia en la descripción debería ir después")
+		assert.Greater(t, first["rank"].(float64), second["rank"].(float64), "El rank debería ser descendente")
+		assert.Contains(t, second["snippet"], "<mark>"+term+"</mark>", "El fragmento debería resaltar el término buscado")
+	}
+	assert.Equal(t, "-rank,id", body["meta"].(map[string]interface{})["sort"], "El orden por defecto con q debería ser por relevancia")
+
+	//  CASO 2: PAGINACIÓN SOBRE LOS RESULTADOS
+	t.Log("🧪 Probando caso 2: Paginación por cursor con ranking")
+	status, body = search(url.Values{"q": {term}, "limit": {"1"}})
+	assert.Equal(t, http.StatusOK, status, "La primera página debería ser exitosa")
+	cursor, _ := body["meta"].(map[string]interface{})["next_cursor"].(string)
+	assert.NotEmpty(t, cursor, "Debería haber una página siguiente")
+	status, body = search(url.Values{"q": {term}, "limit": {"1"}, "cursor": {cursor}})
+	assert.Equal(t, http.StatusOK, status, "La segunda página debería ser exitosa")
+	if data := body["data"].([]interface{}); assert.Len(t, data, 1, "La segunda página debería tener 1 tarea") {
+		assert.Equal(t, float64(descriptionMatch), data[0].(map[string]interface{})["id"], "La segunda página debería continuar el ranking")
+	}
+
+	//  CASO 3: SIN COINCIDENCIAS
+	t.Log("🧪 Probando caso 3: Búsqueda sin resultados")
+	status, body = search(url.Values{"q": {term + "inexistente"}})
+	assert.Equal(t, http.StatusOK, status, "Una búsqueda sin resultados debería ser exitosa")
+	assert.Empty(t, body["data"], "No debería haber resultados")
+
+	//  CASO 4: ORDEN POR RANK SIN BÚSQUEDA
+	t.Log("🧪 Probando caso 4: sort=rank sin q")
+	status, _ = search(url.Values{"sort": {"-rank"}})
+	assert.Equal(t, http.StatusBadRequest, status, "Ordenar por rank sin q debería retornar 400")
+
+	//  CASO 5: EL FRAGMENTO ESCAPA EL MARCADO DEL TÍTULO Y LA DESCRIPCIÓN
+	t.Log("🧪 Probando caso 5: Fragmento con HTML")
+	xssTerm := fmt.Sprintf("cardenal%d", time.Now().UnixNano())
+	createTask(user.ID, "<script>alert('xss')</script> "+xssTerm, `<img src=x onerror="alert(1)"> & más`)
+	status, body = search(url.Values{"q": {xssTerm}})
+	assert.Equal(t, http.StatusOK, status, "La búsqueda debería ser exitosa")
+	if data := body["data"].([]interface{}); assert.Len(t, data, 1, "Debería encontrarse la tarea") {
+		snippet := data[0].(map[string]interface{})["snippet"].(string)
+		assert.Contains(t, snippet, "<mark>"+xssTerm+"</mark>", "El fragmento debería resaltar el término buscado")
+		assert.NotContains(t, snippet, "<script", "El fragmento no debería incluir etiquetas del título")
+		assert.NotContains(t, snippet, "<img", "El fragmento no debería incluir etiquetas de la descripción")
+		assert.Contains(t, snippet, "&lt;script&gt;", "El marcado del título debería quedar escapado")
+	}
+
+	t.Log("✅ Todos los casos de búsqueda pasaron correctamente")
+}
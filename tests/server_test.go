@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/internal/config"
+	"legendaryum/internal/problem"
+	"legendaryum/internal/server"
+	"legendaryum/internal/tasks"
+	"legendaryum/internal/users"
+	"legendaryum/pkg/database"
+)
+
+func TestServerNewMemory(t *testing.T) {
+	t.Log("🧪 Iniciando test de server.New con repositorios en memoria")
+
+	userRepo := users.NewMemoryUserRepository()
+	app := server.New(&config.Config{}, server.Deps{
+		Tasks:         tasks.NewMemoryTaskRepository(userRepo),
+		Users:         userRepo,
+		MigrationsDir: "../migrations",
+	})
+
+	t.Log("🧪 Probando caso 1: Health check")
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "La aplicación debería registrar /health")
+
+	t.Log("🧪 Probando caso 2: Rutas protegidas sin token")
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/tasks", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "Las tareas deberían exigir autenticación")
+	assert.Equal(t, problem.ContentType, resp.Header.Get("Content-Type"), "El error debería pasar por el manejador de errores")
+	var body problem.Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, problem.CodeUnauthenticated, body.Code, "Debería devolver el código unauthenticated")
+
+	t.Log("🧪 Probando caso 3: Ruta inexistente")
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/no-existe", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Una ruta inexistente debería devolver 404")
+	assert.Equal(t, problem.ContentType, resp.Header.Get("Content-Type"), "El 404 también debería ser problem+json")
+
+	t.Log("🧪 Probando caso 4: Sondas de estado sin base de datos")
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "/livez no debería depender de la base")
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "Sin base de datos no debería estar lista")
+	var readiness struct {
+		Code   string `json:"code"`
+		Checks map[string]struct {
+			Status    string   `json:"status"`
+			LatencyMS *float64 `json:"latency_ms"`
+			Error     string   `json:"error"`
+		} `json:"checks"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&readiness))
+	assert.Equal(t, problem.CodeNotReady, readiness.Code, "Debería devolver el código not_ready")
+	for _, name := range []string{"database", "migrations"} {
+		check := readiness.Checks[name]
+		assert.Equal(t, "error", check.Status, "La verificación %s debería fallar", name)
+		assert.NotEmpty(t, check.Error, "La verificación %s debería informar el motivo", name)
+		assert.NotNil(t, check.LatencyMS, "La verificación %s debería informar su latencia", name)
+	}
+
+	t.Log("🧪 Probando caso 5: Última migración")
+	head, err := database.LatestMigration("../migrations")
+	assert.NoError(t, err)
+	ups, _ := filepath.Glob("../migrations/*.up.sql")
+	assert.Equal(t, uint(len(ups)), head, "Debería leer la versión de la última migración")
+
+	t.Log("✅ Todos los casos de server.New pasaron correctamente")
+}
+
+// lifecycleRecorder registra el orden en que ocurren los pasos del apagado
+type lifecycleRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *lifecycleRecorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *lifecycleRecorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+// startLifecycleServer atiende en un puerto libre una aplicación con una ruta /slow que tarda delay,
+// una tarea en segundo plano y un recurso, registrando cada paso en recorder. Usa una aplicación
+// mínima porque lo que se prueba es el ciclo de vida de Server, no las rutas de la API.
+func startLifecycleServer(t *testing.T, ctx context.Context, timeout, delay time.Duration, recorder *lifecycleRecorder) (string, chan struct{}, chan error) {
+	t.Helper()
+	started := make(chan struct{})
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/slow", func(c *fiber.Ctx) error {
+		close(started)
+		time.Sleep(delay)
+		recorder.add("request")
+		return c.SendString("ok")
+	})
+
+	srv := server.NewServer(app, timeout)
+	srv.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		recorder.add("worker")
+	})
+	srv.OnClose(func() error {
+		recorder.add("database")
+		return nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("❌ No se pudo abrir un puerto: %v", err)
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(ctx, ln)
+	}()
+	return fmt.Sprintf("http://%s/slow", ln.Addr()), started, served
+}
+
+func TestServerShutdownOrder(t *testing.T) {
+	t.Log("🧪 Iniciando test del orden de apagado del servidor")
+
+	t.Log("🧪 Probando caso 1: Apagado con una request en curso")
+	recorder := &lifecycleRecorder{}
+	ctx, cancel := context.WithCancel(context.Background())
+	url, started, served := startLifecycleServer(t, ctx, 5*time.Second, 200*time.Millisecond, recorder)
+
+	responses := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			responses <- 0
+			return
+		}
+		resp.Body.Close()
+		responses <- resp.StatusCode
+	}()
+	<-started
+	cancel()
+
+	assert.Equal(t, http.StatusOK, <-responses, "La request en curso debería completarse durante el apagado")
+	assert.NoError(t, <-served, "El apagado debería completarse sin errores")
+	assert.Equal(t, []string{"request", "worker", "database"}, recorder.list(),
+		"Deberían terminar las requests, luego las tareas en segundo plano y por último cerrarse la base")
+
+	t.Log("🧪 Probando caso 2: Plazo de apagado vencido")
+	recorder = &lifecycleRecorder{}
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	url, started, served = startLifecycleServer(t, ctx, 50*time.Millisecond, time.Second, recorder)
+
+	go http.Get(url)
+	<-started
+	cancel()
+
+	assert.Error(t, <-served, "Debería informar que el apagado no se completó a tiempo")
+	assert.Equal(t, []string{"worker", "database"}, recorder.list(),
+		"Vencido el plazo de las requests, las tareas y la base deberían cerrarse sin esperar a la request")
+
+	t.Log("✅ Todos los casos del apagado pasaron correctamente")
+}
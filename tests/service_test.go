@@ -0,0 +1,371 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/internal/auth"
+	"legendaryum/internal/config"
+	"legendaryum/internal/problem"
+	"legendaryum/internal/tasks"
+	"legendaryum/internal/users"
+	"legendaryum/pkg/models"
+)
+
+// Pruebas herméticas: el servicio y los handlers sobre los repositorios en memoria, sin base de datos
+
+// problemCode devuelve el código del problema, o "" si err no es un *problem.Problem
+func problemCode(err error) string {
+	if p, ok := err.(*problem.Problem); ok {
+		return p.Code
+	}
+	return ""
+}
+
+func TestTaskServiceMemory(t *testing.T) {
+	ctx := context.Background()
+	userRepo := users.NewMemoryUserRepository()
+	taskRepo := tasks.NewMemoryTaskRepository(userRepo)
+	workflow, err := tasks.ParseWorkflow("")
+	if err != nil {
+		t.Fatalf("❌ No se pudo crear el flujo de estados: %v", err)
+	}
+	svc := tasks.NewService(taskRepo, userRepo, workflow)
+
+	newUser := func(name string) *models.User {
+		user := &models.User{FirstName: "Test", LastName: name, Email: name + "@example.com", PasswordHash: "hash"}
+		if err := userRepo.Create(ctx, user); err != nil {
+			t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+		}
+		return user
+	}
+	owner, other, member := newUser("owner"), newUser("other"), newUser("member")
+	taskRepo.AddProject(1, owner.ID, member.ID)
+	due := time.Now().Add(24 * time.Hour)
+
+	//  CASO 1: VALORES POR DEFECTO
+	t.Log("🧪 Probando caso 1: Valores por defecto al crear")
+	task, err := svc.Create(ctx, models.TaskRequest{Title: "Tarea", Description: "Descripción", DueDate: due}, owner.ID, models.RoleMember)
+	if !assert.NoError(t, err, "La creación debería ser exitosa") {
+		t.FailNow()
+	}
+	assert.Equal(t, "pending", task.Status, "El estado por defecto debería ser pending")
+	assert.Equal(t, "medium", task.Priority, "La prioridad por defecto debería ser medium")
+	assert.Equal(t, owner.ID, task.AssigneeID, "Sin assignee_id la tarea debería asignarse al creador")
+	assert.Equal(t, owner.Email, task.Creator.Email, "Debería completar el creador")
+	assert.Equal(t, 1, task.Version, "La versión inicial debería ser 1")
+	assert.Len(t, taskRepo.Events(task.ID), 1, "Debería registrar el evento de creación")
+	zero := uint(0)
+	topLevel, err := svc.Create(ctx, models.TaskRequest{Title: "Sin proyecto", Description: "x", DueDate: due, ProjectID: &zero, ParentID: &zero}, owner.ID, models.RoleMember)
+	if assert.NoError(t, err, "Un project_id o parent_id 0 debería aceptarse") {
+		assert.Nil(t, topLevel.ProjectID, "project_id 0 debería equivaler a no tener proyecto")
+		assert.Nil(t, topLevel.ParentID, "parent_id 0 debería equivaler a una tarea de primer nivel")
+	}
+
+	//  CASO 2: REFERENCIAS INVÁLIDAS
+	t.Log("🧪 Probando caso 2: Referencias inválidas")
+	_, err = svc.Create(ctx, models.TaskRequest{Title: "Tarea", Description: "x", DueDate: due, AssigneeID: "00000000-0000-0000-0000-000000000000"}, owner.ID, models.RoleMember)
+	assert.Equal(t, problem.CodeInvalidReference, problemCode(err), "Un assignee inexistente debería rechazarse")
+	project := uint(1)
+	_, err = svc.Create(ctx, models.TaskRequest{Title: "Tarea", Description: "x", DueDate: due, ProjectID: &project}, other.ID, models.RoleMember)
+	assert.Equal(t, problem.CodeInvalidReference, problemCode(err), "Un usuario que no es miembro no debería usar el proyecto")
+	_, err = svc.Create(ctx, models.TaskRequest{Title: "", Description: "x", DueDate: due}, owner.ID, models.RoleMember)
+	assert.Equal(t, problem.CodeValidationFailed, problemCode(err), "Una solicitud inválida debería rechazarse")
+
+	//  CASO 3: VISIBILIDAD Y PERMISOS
+	t.Log("🧪 Probando caso 3: Visibilidad y permisos")
+	inProject, err := svc.Create(ctx, models.TaskRequest{Title: "Del proyecto", Description: "x", DueDate: due, ProjectID: &project}, owner.ID, models.RoleMember)
+	if !assert.NoError(t, err, "El miembro debería poder crear en el proyecto") {
+		t.FailNow()
+	}
+	_, err = svc.Get(ctx, inProject.ID, member.ID, models.RoleMember)
+	assert.NoError(t, err, "Un miembro del proyecto debería ver la tarea")
+	_, err = svc.Get(ctx, inProject.ID, other.ID, models.RoleMember)
+	assert.Equal(t, problem.CodeNotFound, problemCode(err), "Un usuario ajeno no debería ver la tarea")
+	_, err = svc.Get(ctx, inProject.ID, other.ID, models.RoleAdmin)
+	assert.NoError(t, err, "Un administrador debería ver cualquier tarea")
+	_, err = svc.GetForWrite(ctx, inProject.ID, member.ID, models.RoleMember, "actualizar")
+	assert.Equal(t, problem.CodeForbidden, problemCode(err), "Solo el creador debería poder modificarla")
+
+	//  CASO 4: FLUJO DE ESTADOS Y REGLAS DE CIERRE
+	t.Log("🧪 Probando caso 4: Flujo de estados, subtareas y dependencias")
+	_, err = svc.Patch(ctx, task, "application/merge-patch+json", []byte(`{"status":"complete"}`), owner.ID, models.RoleMember)
+	assert.Equal(t, problem.CodeInvalidTransition, problemCode(err), "pending -> complete no debería estar permitido")
+
+	parentID := task.ID
+	subtask, err := svc.Create(ctx, models.TaskRequest{Title: "Subtarea", Description: "x", DueDate: due, ParentID: &parentID}, owner.ID, models.RoleMember)
+	if !assert.NoError(t, err, "La subtarea debería crearse") {
+		t.FailNow()
+	}
+	changed, err := svc.Patch(ctx, task, "application/merge-patch+json", []byte(`{"status":"in_progress"}`), owner.ID, models.RoleMember)
+	assert.NoError(t, err, "pending -> in_progress debería estar permitido")
+	assert.True(t, changed, "La tarea debería cambiar")
+	assert.NotNil(t, task.StartedAt, "Debería registrar started_at")
+	assert.Equal(t, 2, task.Version, "La versión debería aumentar")
+
+	_, err = svc.Patch(ctx, task, "application/merge-patch+json", []byte(`{"status":"complete"}`), owner.ID, models.RoleMember)
+	assert.Equal(t, problem.CodeOpenSubtasks, problemCode(err), "No debería completarse con subtareas abiertas")
+
+	_, err = svc.Patch(ctx, task, "application/merge-patch+json", []byte(fmt.Sprintf(`{"parent_id":%d}`, subtask.ID)), owner.ID, models.RoleMember)
+	assert.Equal(t, problem.CodeInvalidReference, problemCode(err), "Una tarea no debería ser subtarea de su propia subtarea")
+
+	if err := svc.Delete(ctx, subtask, owner.ID); !assert.NoError(t, err, "La subtarea debería ir a la papelera") {
+		t.FailNow()
+	}
+	blocker, _ := svc.Create(ctx, models.TaskRequest{Title: "Bloqueante", Description: "x", DueDate: due}, owner.ID, models.RoleMember)
+	taskRepo.AddDependency(task.ID, blocker.ID)
+	_, err = svc.Patch(ctx, task, "application/merge-patch+json", []byte(`{"status":"complete"}`), owner.ID, models.RoleMember)
+	assert.Equal(t, problem.CodeTaskBlocked, problemCode(err), "No debería completarse con bloqueantes abiertos")
+
+	//  CASO 5: SIN CAMBIOS Y CONFLICTO DE VERSIÓN
+	t.Log("🧪 Probando caso 5: Sin cambios y conflicto de versión")
+	changed, err = svc.Patch(ctx, task, "application/merge-patch+json", []byte(`{"title":"Tarea"}`), owner.ID, models.RoleMember)
+	assert.NoError(t, err, "Un patch sin cambios debería aceptarse")
+	assert.False(t, changed, "Un patch sin cambios no debería guardar")
+	assert.Equal(t, 2, task.Version, "Sin cambios la versión no debería aumentar")
+
+	stale := *task
+	_, err = svc.Reassign(ctx, task, member.ID, owner.ID, models.RoleMember)
+	assert.NoError(t, err, "La reasignación debería ser exitosa")
+	_, err = svc.Patch(ctx, &stale, "application/merge-patch+json", []byte(`{"title":"Otra"}`), owner.ID, models.RoleMember)
+	assert.Equal(t, tasks.ErrVersionMismatch, err, "Una versión desactualizada debería rechazarse")
+	assert.Equal(t, task.Version, stale.Version, "Debería devolver el estado vigente")
+
+	//  CASO 6: TRANSACCIONES
+	t.Log("🧪 Probando caso 6: Transacciones")
+	err = svc.Transaction(ctx, func(tx *tasks.Service) error {
+		if _, err := tx.Patch(ctx, task, "application/merge-patch+json", []byte(`{"title":"Renombrada"}`), owner.ID, models.RoleMember); err != nil {
+			return err
+		}
+		return fmt.Errorf("abortar")
+	})
+	assert.Error(t, err, "La transacción debería abortarse")
+	current, _ := svc.Get(ctx, task.ID, owner.ID, models.RoleMember)
+	assert.Equal(t, "Tarea", current.Title, "Los cambios de una transacción abortada no deberían aplicarse")
+
+	t.Log("✅ Todos los casos del servicio de tareas pasaron correctamente")
+}
+
+// TestTaskHandlersMemory prueba los handlers de tareas sobre el repositorio en memoria, sin base de
+// datos. No usa server.New porque el middleware de autenticación consulta la lista de revocación en
+// Postgres; las mismas rutas se prueban con la aplicación completa en las demás suites.
+func TestTaskHandlersMemory(t *testing.T) {
+	ctx := context.Background()
+	userRepo := users.NewMemoryUserRepository()
+	user := &models.User{FirstName: "Test", LastName: "Memoria", Email: "memoria@example.com", PasswordHash: "hash"}
+	if err := userRepo.Create(ctx, user); err != nil {
+		t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+	}
+
+	// Sin base de datos: los endpoints de escritura usan solo el servicio
+	h := tasks.NewHandlerWithRepositories(nil, &config.Config{}, tasks.NewMemoryTaskRepository(userRepo), userRepo)
+	app := fiber.New(fiber.Config{ErrorHandler: problem.ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", user.ID)
+		return c.Next()
+	})
+	app.Post("/tasks", h.Create)
+	app.Get("/tasks/:id", h.Get)
+	app.Patch("/tasks/:id", h.Patch)
+	app.Delete("/tasks/:id", h.Delete)
+	app.Post("/tasks/bulk", h.Bulk)
+
+	send := func(method, path, contentType, ifMatch string, payload interface{}) (*http.Response, map[string]interface{}) {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err, "No debería haber error en la request")
+		var decoded map[string]interface{}
+		_ = json.NewDecoder(resp.Body).Decode(&decoded)
+		return resp, decoded
+	}
+
+	//  CASO 1: CREAR Y OBTENER
+	t.Log("🧪 Probando caso 1: Crear y obtener")
+	resp, body := send(http.MethodPost, "/tasks", "application/json", "", map[string]interface{}{
+		"title":       "Tarea en memoria",
+		"description": "Sin base de datos",
+		"due_date":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+	})
+	if !assert.Equal(t, http.StatusCreated, resp.StatusCode, "La creación debería ser exitosa") {
+		t.FailNow()
+	}
+	etag := resp.Header.Get("ETag")
+	path := fmt.Sprintf("/tasks/%v", body["data"].(map[string]interface{})["id"])
+	resp, body = send(http.MethodGet, path, "application/json", "", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Debería obtener la tarea")
+	assert.Equal(t, "Tarea en memoria", body["data"].(map[string]interface{})["title"], "Debería devolver la tarea creada")
+
+	//  CASO 2: PATCH CON IF-MATCH
+	t.Log("🧪 Probando caso 2: PATCH con If-Match")
+	resp, _ = send(http.MethodPatch, path, "application/merge-patch+json", etag, map[string]interface{}{"priority": "high"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "El patch debería aplicarse")
+	resp, body = send(http.MethodPatch, path, "application/merge-patch+json", etag, map[string]interface{}{"priority": "low"})
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode, "Un ETag desactualizado debería retornar 412")
+	assert.Equal(t, problem.CodeVersionMismatch, body["code"], "Debería informar el conflicto de versión")
+
+	//  CASO 3: OPERACIÓN MASIVA ATÓMICA
+	t.Log("🧪 Probando caso 3: Operación masiva atómica")
+	resp, _ = send(http.MethodPost, "/tasks/bulk", "application/json", "", map[string]interface{}{
+		"operations": []map[string]interface{}{
+			{"op": "update", "id": 1, "patch": map[string]interface{}{"title": "Renombrada"}},
+			{"op": "delete", "id": 99},
+		},
+	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "Una operación fallida debería abortar todas")
+	resp, body = send(http.MethodGet, path, "application/json", "", nil)
+	assert.Equal(t, "Tarea en memoria", body["data"].(map[string]interface{})["title"], "No debería aplicarse ningún cambio")
+
+	//  CASO 4: ELIMINAR
+	t.Log("🧪 Probando caso 4: Eliminar")
+	resp, _ = send(http.MethodDelete, path, "application/json", "*", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "La tarea debería ir a la papelera")
+	resp, _ = send(http.MethodGet, path, "application/json", "", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Una tarea en la papelera no debería encontrarse")
+
+	t.Log("✅ Todos los casos de los handlers en memoria pasaron correctamente")
+}
+
+func TestAuthServiceMemory(t *testing.T) {
+	ctx := context.Background()
+	svc := auth.NewService(users.NewMemoryUserRepository(), auth.NewMemoryRefreshTokenRepository(), &config.Config{JWTSecret: "secreto", JWTExpiry: "15m", RefreshTokenExpiry: "24h"})
+	req := models.RegisterRequest{FirstName: "Ana", LastName: "Pérez", Email: "ana@example.com", Password: "secreto"}
+
+	//  CASO 1: REGISTRO
+	t.Log("🧪 Probando caso 1: Registro")
+	user, err := svc.Register(ctx, req)
+	if !assert.NoError(t, err, "El registro debería ser exitoso") {
+		t.FailNow()
+	}
+	assert.Equal(t, models.RoleMember, user.Role, "El rol por defecto debería ser member")
+	assert.NotEqual(t, req.Password, user.PasswordHash, "La contraseña debería guardarse hasheada")
+	_, err = svc.Register(ctx, req)
+	assert.Equal(t, problem.CodeAlreadyExists, problemCode(err), "Un email repetido debería rechazarse")
+
+	//  CASO 2: CREDENCIALES
+	t.Log("🧪 Probando caso 2: Credenciales")
+	authenticated, err := svc.Authenticate(ctx, req.Email, req.Password)
+	assert.NoError(t, err, "Las credenciales correctas deberían aceptarse")
+	assert.Equal(t, user.ID, authenticated.ID, "Debería devolver el usuario registrado")
+	_, err = svc.Authenticate(ctx, req.Email, "otra")
+	assert.Equal(t, problem.CodeInvalidCredentials, problemCode(err), "Una contraseña incorrecta debería rechazarse")
+	_, err = svc.Authenticate(ctx, "nadie@example.com", req.Password)
+	assert.Equal(t, problem.CodeInvalidCredentials, problemCode(err), "Un email inexistente debería rechazarse")
+
+	t.Log("✅ Todos los casos del servicio de autenticación pasaron correctamente")
+}
+
+// memoryRevoker registra las revocaciones de access tokens en memoria
+type memoryRevoker struct {
+	revoked    []string
+	revokedAll []string
+}
+
+func (r *memoryRevoker) Revoke(jti, userID string, expiresAt time.Time) error {
+	r.revoked = append(r.revoked, jti)
+	return nil
+}
+
+func (r *memoryRevoker) RevokeAllForUser(userID string, at time.Time) error {
+	r.revokedAll = append(r.revokedAll, userID)
+	return nil
+}
+
+// TestAuthHandlersMemory prueba los handlers de autenticación sobre los repositorios en memoria, sin
+// base de datos; por el mismo motivo que TestTaskHandlersMemory no usa server.New.
+func TestAuthHandlersMemory(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "secreto", JWTExpiry: "15m", RefreshTokenExpiry: "24h"}
+	revoker := &memoryRevoker{}
+	h := auth.NewHandlerWithRepositories(cfg, revoker, users.NewMemoryUserRepository(), auth.NewMemoryRefreshTokenRepository())
+
+	// El middleware de autenticación real necesita la base (lista de revocación): se simula
+	var currentUser string
+	app := fiber.New(fiber.Config{ErrorHandler: problem.ErrorHandler})
+	app.Post("/auth/register", h.Register)
+	app.Post("/auth/login", h.Login)
+	app.Post("/auth/refresh", h.Refresh)
+	authenticated := func(c *fiber.Ctx) error {
+		c.Locals("user_id", currentUser)
+		c.Locals("token_id", "jti-memoria")
+		c.Locals("token_expires_at", time.Now().Add(time.Hour))
+		return c.Next()
+	}
+	app.Post("/auth/logout", authenticated, h.Logout)
+	app.Post("/auth/logout-all", authenticated, h.LogoutAll)
+
+	send := func(path string, payload interface{}) (int, map[string]interface{}) {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err, "No debería haber error en la request")
+		var decoded map[string]interface{}
+		_ = json.NewDecoder(resp.Body).Decode(&decoded)
+		return resp.StatusCode, decoded
+	}
+	refreshToken := func(body map[string]interface{}) string {
+		token, _ := body["data"].(map[string]interface{})["refresh_token"].(string)
+		return token
+	}
+
+	//  CASO 1: REGISTRO E INICIO DE SESIÓN
+	t.Log("🧪 Probando caso 1: Registro e inicio de sesión")
+	status, body := send("/auth/register", map[string]string{
+		"first_name": "Ana", "last_name": "Pérez", "email": "ana@example.com", "password": "secreto123",
+	})
+	if !assert.Equal(t, http.StatusCreated, status, "El registro debería ser exitoso") {
+		t.FailNow()
+	}
+	currentUser = body["data"].(map[string]interface{})["user"].(map[string]interface{})["id"].(string)
+	assert.NotEmpty(t, refreshToken(body), "El registro debería devolver un refresh token")
+	status, body = send("/auth/login", map[string]string{"email": "ana@example.com", "password": "secreto123"})
+	assert.Equal(t, http.StatusOK, status, "El login debería ser exitoso")
+	first := refreshToken(body)
+	status, _ = send("/auth/login", map[string]string{"email": "ana@example.com", "password": "otra"})
+	assert.Equal(t, http.StatusUnauthorized, status, "Una contraseña incorrecta debería rechazarse")
+
+	//  CASO 2: ROTACIÓN Y REUTILIZACIÓN
+	t.Log("🧪 Probando caso 2: Rotación del refresh token")
+	status, body = send("/auth/refresh", map[string]string{"refresh_token": first})
+	assert.Equal(t, http.StatusOK, status, "La renovación debería ser exitosa")
+	second := refreshToken(body)
+	assert.NotEqual(t, first, second, "La renovación debería emitir un refresh token nuevo")
+	status, body = send("/auth/refresh", map[string]string{"refresh_token": first})
+	assert.Equal(t, http.StatusUnauthorized, status, "Un refresh token ya rotado debería rechazarse")
+	assert.Equal(t, problem.CodeRefreshTokenReused, body["code"], "Debería informarse la reutilización")
+	status, _ = send("/auth/refresh", map[string]string{"refresh_token": second})
+	assert.Equal(t, http.StatusUnauthorized, status, "La reutilización debería revocar toda la familia")
+
+	//  CASO 3: CIERRE DE SESIÓN
+	t.Log("🧪 Probando caso 3: Logout y logout-all")
+	_, body = send("/auth/login", map[string]string{"email": "ana@example.com", "password": "secreto123"})
+	session := refreshToken(body)
+	status, _ = send("/auth/logout", map[string]string{"refresh_token": session})
+	assert.Equal(t, http.StatusOK, status, "El logout debería ser exitoso")
+	assert.Equal(t, []string{"jti-memoria"}, revoker.revoked, "Debería revocar el access token usado")
+	status, _ = send("/auth/refresh", map[string]string{"refresh_token": session})
+	assert.Equal(t, http.StatusUnauthorized, status, "El refresh token de la sesión cerrada debería rechazarse")
+
+	_, body = send("/auth/login", map[string]string{"email": "ana@example.com", "password": "secreto123"})
+	other := refreshToken(body)
+	status, _ = send("/auth/logout-all", nil)
+	assert.Equal(t, http.StatusOK, status, "El logout-all debería ser exitoso")
+	assert.Equal(t, []string{currentUser}, revoker.revokedAll, "Debería invalidar todos los access tokens del usuario")
+	status, _ = send("/auth/refresh", map[string]string{"refresh_token": other})
+	assert.Equal(t, http.StatusUnauthorized, status, "Los refresh tokens de todas las sesiones deberían revocarse")
+
+	t.Log("✅ Todos los casos de los handlers de autenticación en memoria pasaron correctamente")
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/pkg/models"
+)
+
+func TestSubtasks(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
+
+	user := &models.User{
+		FirstName:    "Test",
+		LastName:     "Subtareas",
+		Email:        fmt.Sprintf("subtasks_%d@example.com", time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Role:         models.RoleMember,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+	}
+
+	token := env.token(t, user)
+	send := func(method, path string, payload interface{}) (int, map[string]interface{}) {
+		// Estos casos no prueban la concurrencia: If-Match * acepta cualquier versión de la tarea
+		headers := map[string]string{"If-Match": "*"}
+		if method == http.MethodPatch {
+			headers["Content-Type"] = "application/merge-patch+json"
+		}
+		resp, decoded := env.request(t, method, path, token, payload, headers)
+		return resp.StatusCode, decoded
+	}
+	createTask := func(title string, parentID interface{}) interface{} {
+		payload := map[string]interface{}{
+			"title":       title,
+			"description": "Tarea para probar subtareas",
+			"due_date":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+		}
+		if parentID != nil {
+			payload["parent_id"] = parentID
+		}
+		status, created := send(http.MethodPost, "/tasks", payload)
+		if !assert.Equal(t, http.StatusCreated, status, "La creación de %q debería ser exitosa", title) {
+			t.FailNow()
+		}
+		return created["data"].(map[string]interface{})["id"]
+	}
+
+	parentID := createTask("Tarea padre", nil)
+	firstID := createTask("Primer paso", parentID)
+	secondID := createTask("Segundo paso", parentID)
+	nestedID := createTask("Paso anidado", firstID)
+	parentPath := fmt.Sprintf("/tasks/%v", parentID)
+
+	//  CASO 1: OBTENER LA TAREA CON SUS SUBTAREAS
+	t.Log("🧪 Probando caso 1: include=subtasks")
+	status, body := send(http.MethodGet, parentPath+"?include=subtasks", nil)
+	assert.Equal(t, http.StatusOK, status, "La obtención con subtareas debería ser exitosa")
+	parent := body["data"].(map[string]interface{})
+	assert.Equal(t, float64(0), parent["progress"], "Sin subtareas completadas el progreso debería ser 0")
+	if subtasks, ok := parent["subtasks"].([]interface{}); assert.True(t, ok, "Deberían incluirse las subtareas") {
+		assert.Len(t, subtasks, 2, "La tarea padre debería tener 2 subtareas directas")
+		first := subtasks[0].(map[string]interface{})
+		assert.Len(t, first["subtasks"], 1, "Las subtareas anidadas también deberían incluirse")
+	}
+
+	status, body = send(http.MethodGet, parentPath, nil)
+	assert.Equal(t, http.StatusOK, status, "La obtención sin include debería ser exitosa")
+	assert.NotContains(t, body["data"], "subtasks", "Sin include no deberían incluirse las subtareas")
+
+	status, _ = send(http.MethodGet, parentPath+"?include=comments", nil)
+	assert.Equal(t, http.StatusBadRequest, status, "Un include desconocido debería retornar 400")
+
+	//  CASO 2: NO SE PUEDE CERRAR UN PADRE CON SUBTAREAS ABIERTAS
+	t.Log("🧪 Probando caso 2: Cerrar padre con subtareas abiertas")
+	// El flujo de estados exige pasar por in_progress antes de completar
+	for _, id := range []interface{}{parentID, nestedID, firstID, secondID} {
+		send(http.MethodPatch, fmt.Sprintf("/tasks/%v", id), map[string]string{"status": "in_progress"})
+	}
+	status, body = send(http.MethodPatch, parentPath, map[string]string{"status": "complete"})
+	assert.Equal(t, http.StatusConflict, status, "Cerrar un padre con subtareas abiertas debería retornar 409")
+	assert.Equal(t, float64(3), body["open_subtasks"], "Deberían informarse las subtareas abiertas (incluidas las anidadas)")
+
+	//  CASO 3: EL PROGRESO SE CALCULA CON LAS SUBTAREAS DIRECTAS
+	t.Log("🧪 Probando caso 3: Progreso")
+	send(http.MethodPatch, fmt.Sprintf("/tasks/%v", nestedID), map[string]string{"status": "complete"})
+	send(http.MethodPatch, fmt.Sprintf("/tasks/%v", firstID), map[string]string{"status": "complete"})
+	status, body = send(http.MethodGet, parentPath, nil)
+	assert.Equal(t, http.StatusOK, status, "La obtención debería ser exitosa")
+	assert.Equal(t, float64(50), body["data"].(map[string]interface{})["progress"], "Con 1 de 2 subtareas completadas el progreso debería ser 50")
+
+	send(http.MethodPatch, fmt.Sprintf("/tasks/%v", secondID), map[string]string{"status": "complete"})
+	status, _ = send(http.MethodPatch, parentPath, map[string]string{"status": "complete"})
+	assert.Equal(t, http.StatusOK, status, "Con todas las subtareas completadas el padre debería poder cerrarse")
+
+	//  CASO 4: NO SE PERMITEN CICLOS
+	t.Log("🧪 Probando caso 4: Ciclos")
+	status, _ = send(http.MethodPatch, parentPath, map[string]interface{}{"parent_id": nestedID})
+	assert.Equal(t, http.StatusUnprocessableEntity, status, "Una tarea no debería poder ser subtarea de su propia subtarea")
+
+	//  CASO 5: BORRAR EL PADRE BORRA LAS SUBTAREAS
+	t.Log("🧪 Probando caso 5: Borrado en cascada")
+	status, _ = send(http.MethodDelete, parentPath, nil)
+	assert.Equal(t, http.StatusOK, status, "La eliminación debería ser exitosa")
+	var remaining int64
+	db.Model(&models.Task{}).Where("id IN ?", []interface{}{firstID, secondID, nestedID}).Count(&remaining)
+	assert.Equal(t, int64(0), remaining, "Las subtareas deberían eliminarse junto con el padre")
+}
@@ -10,50 +10,16 @@ import (
 	"testing"
 	"time"
 
-	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 
-	"legendaryum/internal/auth"
-	"legendaryum/internal/config"
-	"legendaryum/internal/tasks"
 	"legendaryum/pkg/models"
 	"legendaryum/pkg/utils"
-
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
 )
 
 func TestTasks(t *testing.T) {
-	// Setup de la aplicación Fiber
-	app := fiber.New()
-
-	// Cargar configuración del .env
-	cfg, err := config.Load()
-	if nil != err {
-		t.Fatalf("❌ No se pudo cargar la configuración: %v", err)
-	}
-
-	// Configuración para el test
-	// cfg := &config.Config{
-	// 	JWTSecret: "testsecretkey12345678901234567890",
-	// 	JWTExpiry: "24h",
-	// }
-
-	// Conexión a la base de datos real usando configuración del .env
-	// dsn := "host=localhost user=postgres password=postgres dbname=legendaryum_db port=5432 sslmode=disable"
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPass, cfg.DBName)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		t.Fatalf("❌ No se pudo conectar a la base de datos: %v", err)
-	}
-	t.Log("✅ Conexión a base de datos establecida")
-
-	// Migración automática de tablas
-	if err := db.AutoMigrate(&models.User{}, &models.Task{}); err != nil {
-		t.Fatalf("❌ No se pudo migrar los modelos: %v", err)
-	}
-	t.Log("✅ Migración de tablas completada")
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
 
 	// Generar datos únicos para evitar conflictos
 	timestamp := time.Now().UnixNano()
@@ -62,38 +28,7 @@ func TestTasks(t *testing.T) {
 	testPassword := "test123"
 	t.Logf("📧 Emails de prueba: %s, %s", creatorEmail, assigneeEmail)
 
-	//  INICIAR TRANSACCIÓN
-	tx := db.Begin()
-	if tx.Error != nil {
-		t.Fatalf("❌ No se pudo iniciar la transacción: %v", tx.Error)
-	}
-	t.Log("🔄 Transacción iniciada")
-
-	// Asegurar rollback en caso de panic
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-			t.Logf("🚨 Panic detectado, rollback ejecutado: %v", r)
-		}
-	}()
-
-	// Rollback automático al final del test
-	defer func() {
-		if err := tx.Rollback().Error; err != nil {
-			t.Logf("⚠️ Error en rollback: %v", err)
-		} else {
-			t.Log("🔙 Rollback ejecutado - base de datos limpia")
-		}
-	}()
-
-	//  CONFIGURAR HANDLERS CON LA TRANSACCIÓN
-	authHandler := auth.NewHandler(tx, cfg)
-	tasksHandler := tasks.NewHandler(tx, cfg)
-
-	// Configurar rutas
-	app.Post("/auth/login", authHandler.Login)
-
-	//  CREAR USUARIOS DE PRUEBA DENTRO DE LA TRANSACCIÓN
+	//  CREAR USUARIOS DE PRUEBA
 	hash, err := utils.HashPassword(testPassword)
 	if err != nil {
 		t.Fatalf("❌ No se pudo hashear la contraseña: %v", err)
@@ -107,27 +42,11 @@ func TestTasks(t *testing.T) {
 		Email:        creatorEmail,
 		PasswordHash: hash,
 	}
-	if err := tx.Create(creator).Error; err != nil {
+	if err := db.Create(creator).Error; err != nil {
 		t.Fatalf("❌ No se pudo crear el usuario creador: %v", err)
 	}
 	t.Logf("👤 Usuario creador creado - ID: %v", creator.ID)
 
-	// Configurar middleware de autenticación
-	app.Use(func(c *fiber.Ctx) error {
-		// En los tests, simulamos que el usuario está autenticado
-		c.Locals("user_id", creator.ID)
-		return c.Next()
-	})
-
-	// Rutas de tareas
-	app.Post("/tasks", tasksHandler.Create)
-	app.Get("/tasks", tasksHandler.List)
-	app.Get("/tasks/:id", tasksHandler.Get)
-	app.Put("/tasks/:id", tasksHandler.Update)
-	app.Delete("/tasks/:id", tasksHandler.Delete)
-
-	t.Log("🎯 Handlers configurados con transacción")
-
 	// Crear usuario asignado
 	assignee := &models.User{
 		FirstName:    "Test",
@@ -135,7 +54,7 @@ func TestTasks(t *testing.T) {
 		Email:        assigneeEmail,
 		PasswordHash: hash,
 	}
-	if err := tx.Create(assignee).Error; err != nil {
+	if err := db.Create(assignee).Error; err != nil {
 		t.Fatalf("❌ No se pudo crear el usuario asignado: %v", err)
 	}
 	t.Logf("👤 Usuario asignado creado - ID: %v", assignee.ID)
@@ -151,7 +70,7 @@ func TestTasks(t *testing.T) {
 	loginReq := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewReader(loginBody))
 	loginReq.Header.Set("Content-Type", "application/json")
 
-	loginResp, err := app.Test(loginReq, -1)
+	loginResp, err := env.App.Test(loginReq, -1)
 	assert.NoError(t, err, "No debería haber error en el login")
 
 	// Leer el cuerpo completo de la respuesta para debugging
@@ -231,9 +150,10 @@ func TestTasks(t *testing.T) {
 	taskBody, _ := json.Marshal(taskPayload)
 
 	createReq := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewReader(taskBody))
+	createReq.Header.Set("Authorization", "Bearer "+token)
 	createReq.Header.Set("Content-Type", "application/json")
 
-	createResp, err := app.Test(createReq, -1)
+	createResp, err := env.App.Test(createReq, -1)
 	assert.NoError(t, err, "No debería haber error en la creación")
 
 	// Leer respuesta para debugging si hay error
@@ -251,18 +171,19 @@ func TestTasks(t *testing.T) {
 	taskID := taskData["id"]
 	t.Logf("✅ Tarea creada exitosamente - ID: %v", taskID)
 
-	// Verificar que la tarea existe en la transacción
+	// Verificar que la tarea existe en la base de datos
 	var createdTask models.Task
-	result := tx.Where("id = ?", taskID).First(&createdTask)
-	assert.NoError(t, result.Error, "La tarea debería existir en la transacción")
+	result := db.Where("id = ?", taskID).First(&createdTask)
+	assert.NoError(t, result.Error, "La tarea debería existir en la base de datos")
 	assert.Equal(t, "Tarea de prueba automatizada", createdTask.Title, "El título debería coincidir")
-	t.Log("✅ Tarea verificada en la base de datos (transacción)")
+	t.Log("✅ Tarea verificada en la base de datos")
 
 	//  CASO 2: LISTAR TAREAS CON HTTPTEST
 	t.Log("🧪 Probando caso 2: Listar tareas")
 	listReq := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	listReq.Header.Set("Authorization", "Bearer "+token)
 
-	listResp, err := app.Test(listReq, -1)
+	listResp, err := env.App.Test(listReq, -1)
 	assert.NoError(t, err, "No debería haber error en el listado")
 	assert.Equal(t, http.StatusOK, listResp.StatusCode, "Listado debería ser exitoso")
 
@@ -278,8 +199,9 @@ func TestTasks(t *testing.T) {
 	//  CASO 3: OBTENER TAREA ESPECÍFICA CON HTTPTEST
 	t.Log("🧪 Probando caso 3: Obtener tarea específica")
 	getReq := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/tasks/%v", taskID), nil)
+	getReq.Header.Set("Authorization", "Bearer "+token)
 
-	getResp, err := app.Test(getReq, -1)
+	getResp, err := env.App.Test(getReq, -1)
 	assert.NoError(t, err, "No debería haber error al obtener la tarea")
 	assert.Equal(t, http.StatusOK, getResp.StatusCode, "Obtención debería ser exitosa")
 
@@ -290,6 +212,8 @@ func TestTasks(t *testing.T) {
 
 	getTaskData := getResponse["data"].(map[string]interface{})
 	assert.Equal(t, taskID, getTaskData["id"], "Debería obtener la tarea correcta")
+	etag := getResp.Header.Get("ETag")
+	assert.NotEmpty(t, etag, "La respuesta debería incluir el ETag de la tarea")
 	t.Log("✅ Obtención de tarea específica exitosa")
 
 	//  CASO 4: ACTUALIZAR TAREA CON HTTPTEST
@@ -299,13 +223,16 @@ func TestTasks(t *testing.T) {
 		"description": "Descripción actualizada usando httptest",
 		"status":      "in_progress",
 		"priority":    "medium",
+		"due_date":    time.Now().Add(72 * time.Hour).Format(time.RFC3339),
 	}
 	updateBody, _ := json.Marshal(updatePayload)
 
 	updateReq := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/tasks/%v", taskID), bytes.NewReader(updateBody))
+	updateReq.Header.Set("Authorization", "Bearer "+token)
 	updateReq.Header.Set("Content-Type", "application/json")
+	updateReq.Header.Set("If-Match", etag)
 
-	updateResp, err := app.Test(updateReq, -1)
+	updateResp, err := env.App.Test(updateReq, -1)
 	assert.NoError(t, err, "No debería haber error en la actualización")
 	assert.Equal(t, http.StatusOK, updateResp.StatusCode, "Actualización debería ser exitosa")
 
@@ -317,11 +244,13 @@ func TestTasks(t *testing.T) {
 	updateTaskData := updateResponse["data"].(map[string]interface{})
 	assert.Equal(t, "Tarea actualizada con httptest", updateTaskData["title"], "Debería actualizarse el título")
 	assert.Equal(t, "in_progress", updateTaskData["status"], "Debería actualizarse el estado")
+	assert.NotEqual(t, etag, updateResp.Header.Get("ETag"), "La actualización debería cambiar el ETag")
+	etag = updateResp.Header.Get("ETag")
 	t.Log("✅ Actualización de tarea exitosa")
 
 	// Verificar actualización en la base de datos
 	var updatedTask models.Task
-	tx.Where("id = ?", taskID).First(&updatedTask)
+	db.Where("id = ?", taskID).First(&updatedTask)
 	assert.Equal(t, "Tarea actualizada con httptest", updatedTask.Title, "El título debería estar actualizado en la DB")
 	assert.Equal(t, "in_progress", updatedTask.Status, "El estado debería estar actualizado en la DB")
 	t.Log("✅ Actualización verificada en la base de datos")
@@ -329,8 +258,10 @@ func TestTasks(t *testing.T) {
 	//  CASO 5: ELIMINAR TAREA CON HTTPTEST
 	t.Log("🧪 Probando caso 5: Eliminar tarea")
 	deleteReq := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/tasks/%v", taskID), nil)
+	deleteReq.Header.Set("Authorization", "Bearer "+token)
+	deleteReq.Header.Set("If-Match", etag)
 
-	deleteResp, err := app.Test(deleteReq, -1)
+	deleteResp, err := env.App.Test(deleteReq, -1)
 	assert.NoError(t, err, "No debería haber error en la eliminación")
 	assert.Equal(t, http.StatusOK, deleteResp.StatusCode, "Eliminación debería ser exitosa")
 
@@ -353,9 +284,10 @@ func TestTasks(t *testing.T) {
 	task2Body, _ := json.Marshal(task2Payload)
 
 	create2Req := httptest.NewRequest(http.MethodPost, "/tasks", bytes.NewReader(task2Body))
+	create2Req.Header.Set("Authorization", "Bearer "+token)
 	create2Req.Header.Set("Content-Type", "application/json")
 
-	create2Resp, err := app.Test(create2Req, -1)
+	create2Resp, err := env.App.Test(create2Req, -1)
 	assert.NoError(t, err, "No debería haber error en la segunda creación")
 	assert.Equal(t, http.StatusCreated, create2Resp.StatusCode, "Segunda creación debería ser exitosa")
 	t.Log("✅ Segunda tarea creada exitosamente")
@@ -363,8 +295,9 @@ func TestTasks(t *testing.T) {
 	//  CASO 7: FILTRAR TAREAS POR ESTADO CON HTTPTEST
 	t.Log("🧪 Probando caso 7: Filtrar tareas por estado")
 	filterReq := httptest.NewRequest(http.MethodGet, "/tasks?status=complete", nil)
+	filterReq.Header.Set("Authorization", "Bearer "+token)
 
-	filterResp, err := app.Test(filterReq, -1)
+	filterResp, err := env.App.Test(filterReq, -1)
 	assert.NoError(t, err, "No debería haber error en el filtrado")
 	assert.Equal(t, http.StatusOK, filterResp.StatusCode, "Filtrado debería ser exitoso")
 
@@ -380,74 +313,17 @@ func TestTasks(t *testing.T) {
 	//  CASO 8: FILTRAR TAREAS POR PRIORIDAD
 	t.Log("🧪 Probando caso 8: Filtrar tareas por prioridad")
 	priorityReq := httptest.NewRequest(http.MethodGet, "/tasks?priority=low", nil)
+	priorityReq.Header.Set("Authorization", "Bearer "+token)
 
-	priorityResp, err := app.Test(priorityReq, -1)
+	priorityResp, err := env.App.Test(priorityReq, -1)
 	assert.NoError(t, err, "No debería haber error en el filtrado por prioridad")
 	assert.Equal(t, http.StatusOK, priorityResp.StatusCode, "Filtrado por prioridad debería ser exitoso")
 	t.Log("✅ Filtrado por prioridad exitoso")
 
-	// Verificar conteo final de tareas en la transacción
+	// Verificar conteo final de tareas en la base de datos
 	var finalCount int64
-	tx.Model(&models.Task{}).Count(&finalCount)
-	t.Logf("🔢 Total de tareas en la transacción: %d", finalCount)
+	db.Model(&models.Task{}).Count(&finalCount)
+	t.Logf("🔢 Total de tareas en la base de datos: %d", finalCount)
 
 	t.Log("🎉 Todos los casos de test completados exitosamente con httptest")
 }
-
-// Test adicional para verificar limpieza específica de las tareas
-func TestTasksDatabaseCleanup(t *testing.T) {
-	//  CONECTAR A LA BASE DE DATOS REAL
-	dsn := "host=localhost user=postgres password=postgres dbname=legendaryum_db port=5432 sslmode=disable"
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		t.Fatalf("❌ No se pudo conectar a la base de datos: %v", err)
-	}
-
-	//  USAR TRANSACCIÓN TAMBIÉN AQUÍ
-	tx := db.Begin()
-	if tx.Error != nil {
-		t.Fatalf("❌ No se pudo iniciar la transacción: %v", tx.Error)
-	}
-	defer tx.Rollback()
-
-	// Verificar que no hay tareas de test residuales en la base de datos real
-	var taskCount int64
-	tx.Model(&models.Task{}).Where("title LIKE ? OR description LIKE ?",
-		"%prueba%", "%httptest%").Count(&taskCount)
-
-	// Verificar que no hay usuarios de test residuales
-	var userCount int64
-	tx.Model(&models.User{}).Where("email LIKE ? OR email LIKE ?",
-		"creator_%@example.com", "assignee_%@example.com").Count(&userCount)
-
-	assert.Equal(t, int64(0), taskCount, "No debería haber tareas de test en la base de datos real")
-	assert.Equal(t, int64(0), userCount, "No debería haber usuarios de test en la base de datos real")
-
-	if taskCount == 0 && userCount == 0 {
-		t.Log("✅ Base de datos limpia - no hay datos de test residuales")
-	} else {
-		if taskCount > 0 {
-			t.Logf("⚠️  Encontradas %d tareas de test en la base de datos real", taskCount)
-
-			// Mostrar tareas residuales
-			var tasks []models.Task
-			tx.Where("title LIKE ? OR description LIKE ?", "%prueba%", "%httptest%").Find(&tasks)
-			for _, task := range tasks {
-				t.Logf("   - Tarea residual: ID=%d, Título=%s, Estado=%s",
-					task.ID, task.Title, task.Status)
-			}
-		}
-
-		if userCount > 0 {
-			t.Logf("⚠️  Encontrados %d usuarios de test en la base de datos real", userCount)
-
-			// Mostrar usuarios residuales
-			var users []models.User
-			tx.Where("email LIKE ? OR email LIKE ?",
-				"creator_%@example.com", "assignee_%@example.com").Find(&users)
-			for _, user := range users {
-				t.Logf("   - Usuario residual: ID=%v, Email=%s", user.ID, user.Email)
-			}
-		}
-	}
-}
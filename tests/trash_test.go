@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/internal/tasks"
+	"legendaryum/pkg/models"
+)
+
+func TestTaskTrash(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
+
+	owner := &models.User{
+		FirstName:    "Test",
+		LastName:     "Papelera",
+		Email:        fmt.Sprintf("trash_owner_%d@example.com", time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Role:         models.RoleMember,
+	}
+	other := &models.User{
+		FirstName:    "Test",
+		LastName:     "Ajeno",
+		Email:        fmt.Sprintf("trash_other_%d@example.com", time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Role:         models.RoleMember,
+	}
+	for _, u := range []*models.User{owner, other} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+		}
+	}
+
+	send := func(method, path string, user *models.User, payload interface{}) (int, map[string]interface{}) {
+		var headers map[string]string
+		if method == http.MethodDelete {
+			headers = map[string]string{"If-Match": "*"}
+		}
+		resp, decoded := env.request(t, method, path, env.token(t, user), payload, headers)
+		return resp.StatusCode, decoded
+	}
+
+	createTask := func(title string, parentID interface{}) uint {
+		payload := map[string]interface{}{
+			"title":       title,
+			"description": "Tarea para probar la papelera",
+			"due_date":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+		}
+		if parentID != nil {
+			payload["parent_id"] = parentID
+		}
+		status, body := send(http.MethodPost, "/tasks", owner, payload)
+		if !assert.Equal(t, http.StatusCreated, status, "La creación debería ser exitosa") {
+			t.FailNow()
+		}
+		return uint(body["data"].(map[string]interface{})["id"].(float64))
+	}
+
+	trashIDs := func(user *models.User) []uint {
+		status, body := send(http.MethodGet, "/tasks/trash", user, nil)
+		assert.Equal(t, http.StatusOK, status, "La papelera debería obtenerse")
+		var ids []uint
+		for _, item := range body["data"].([]interface{}) {
+			ids = append(ids, uint(item.(map[string]interface{})["id"].(float64)))
+		}
+		return ids
+	}
+
+	parentID := createTask("Tarea padre", nil)
+	childID := createTask("Subtarea", parentID)
+
+	//  CASO 1: BORRADO LÓGICO
+	t.Log("🧪 Probando caso 1: Eliminar mueve la tarea y sus subtareas a la papelera")
+	status, _ := send(http.MethodDelete, fmt.Sprintf("/tasks/%d", parentID), owner, nil)
+	assert.Equal(t, http.StatusOK, status, "La eliminación debería ser exitosa")
+	status, _ = send(http.MethodGet, fmt.Sprintf("/tasks/%d", parentID), owner, nil)
+	assert.Equal(t, http.StatusNotFound, status, "Una tarea en la papelera no debería obtenerse")
+	status, _ = send(http.MethodGet, fmt.Sprintf("/tasks/%d", childID), owner, nil)
+	assert.Equal(t, http.StatusNotFound, status, "Sus subtareas tampoco")
+	var stored models.Task
+	assert.NoError(t, db.Unscoped().First(&stored, parentID).Error, "La fila debería seguir en la base")
+	assert.True(t, stored.DeletedAt.Valid, "La tarea debería tener deleted_at")
+
+	//  CASO 2: LISTADO DE LA PAPELERA
+	t.Log("🧪 Probando caso 2: Listar la papelera")
+	ids := trashIDs(owner)
+	assert.Contains(t, ids, parentID, "La papelera debería incluir la tarea eliminada")
+	assert.Contains(t, ids, childID, "La papelera debería incluir la subtarea eliminada")
+	assert.Empty(t, trashIDs(other), "Otro usuario no debería ver la papelera ajena")
+
+	//  CASO 3: RESTAURACIÓN NO PERMITIDA
+	t.Log("🧪 Probando caso 3: Restauraciones rechazadas")
+	status, _ = send(http.MethodPost, fmt.Sprintf("/tasks/%d/restore", parentID), other, nil)
+	assert.Equal(t, http.StatusNotFound, status, "Otro usuario no debería poder restaurarla")
+	status, _ = send(http.MethodPost, fmt.Sprintf("/tasks/%d/restore", childID), owner, nil)
+	assert.Equal(t, http.StatusConflict, status, "Con la tarea padre en la papelera debería responder 409")
+
+	//  CASO 4: RESTAURAR
+	t.Log("🧪 Probando caso 4: Restaurar la tarea y sus subtareas")
+	status, body := send(http.MethodPost, fmt.Sprintf("/tasks/%d/restore", parentID), owner, nil)
+	if assert.Equal(t, http.StatusOK, status, "La restauración debería ser exitosa") {
+		data := body["data"].(map[string]interface{})
+		assert.Nil(t, data["deleted_at"], "La tarea restaurada no debería tener deleted_at")
+		assert.Equal(t, float64(2), data["version"], "La restauración debería aumentar la versión")
+	}
+	status, _ = send(http.MethodGet, fmt.Sprintf("/tasks/%d", childID), owner, nil)
+	assert.Equal(t, http.StatusOK, status, "La subtarea debería restaurarse con su tarea padre")
+	status, _ = send(http.MethodPost, fmt.Sprintf("/tasks/%d/restore", parentID), owner, nil)
+	assert.Equal(t, http.StatusConflict, status, "Restaurar una tarea que no está en la papelera debería responder 409")
+	var restoredEvents int64
+	db.Model(&models.TaskEvent{}).Where("task_id IN ? AND action = ?", []uint{parentID, childID}, models.TaskEventRestored).Count(&restoredEvents)
+	assert.Equal(t, int64(2), restoredEvents, "Debería registrarse un evento por tarea restaurada")
+
+	//  CASO 5: PURGA
+	t.Log("🧪 Probando caso 5: Purga de la papelera")
+	status, _ = send(http.MethodDelete, fmt.Sprintf("/tasks/%d", childID), owner, nil)
+	assert.Equal(t, http.StatusOK, status, "La eliminación debería ser exitosa")
+	purged, err := tasks.PurgeTrash(context.Background(), db, time.Now().Add(-time.Hour))
+	assert.NoError(t, err, "La purga no debería fallar")
+	assert.Equal(t, int64(0), purged, "No debería purgar tareas dentro del período de retención")
+	_, err = tasks.PurgeTrash(context.Background(), db, time.Now().Add(time.Hour))
+	assert.NoError(t, err, "La purga no debería fallar")
+	var remaining int64
+	db.Unscoped().Model(&models.Task{}).Where("id = ?", childID).Count(&remaining)
+	assert.Equal(t, int64(0), remaining, "La subtarea vencida debería eliminarse definitivamente")
+	status, _ = send(http.MethodGet, fmt.Sprintf("/tasks/%d", parentID), owner, nil)
+	assert.Equal(t, http.StatusOK, status, "Las tareas fuera de la papelera no deberían purgarse")
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = tasks.PurgeTrash(canceled, db, time.Now().Add(time.Hour))
+	assert.ErrorIs(t, err, context.Canceled, "Con el contexto cancelado la purga debería interrumpirse")
+
+	t.Log("✅ Todos los casos de la papelera pasaron correctamente")
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/internal/validation"
+	"legendaryum/pkg/models"
+)
+
+func TestValidationStruct(t *testing.T) {
+	t.Log("🧪 Probando la validación por tags")
+
+	//  CASO 1: SOLICITUD VÁLIDA
+	valid := models.RegisterRequest{FirstName: "Ana", LastName: "Pérez", Email: "ana@example.com", Password: "secreto"}
+	assert.NoError(t, validation.Struct(&valid), "Una solicitud válida no debería tener errores")
+
+	//  CASO 2: UN ERROR POR CAMPO, EN ORDEN Y CON EL NOMBRE JSON
+	invalid := models.RegisterRequest{FirstName: "A", LastName: "Pérez 2", Email: "ana", Password: ""}
+	err := validation.Struct(&invalid)
+	errs, ok := err.(validation.Errors)
+	if assert.True(t, ok, "Debería devolver validation.Errors") {
+		assert.Equal(t, validation.Errors{
+			{Field: "first_name", Error: "debe tener al menos 2 caracteres"},
+			{Field: "last_name", Error: "solo puede contener letras y espacios"},
+			{Field: "email", Error: "debe ser un email válido"},
+			{Field: "password", Error: "es requerido"},
+		}, errs, "Debería informar cada campo inválido")
+	}
+
+	//  CASO 3: OMITEMPTY, ONEOF Y LARGO MÁXIMO
+	task := models.TaskRequest{
+		Title:       strings.Repeat("a", 201),
+		Description: "Descripción",
+		Priority:    "urgent",
+		DueDate:     time.Now(),
+	}
+	errs, _ = validation.Struct(task).(validation.Errors)
+	assert.Equal(t, validation.Errors{
+		{Field: "title", Error: "no puede superar los 200 caracteres"},
+		{Field: "priority", Error: "debe ser uno de: low, medium, high"},
+	}, errs, "Status vacío es opcional; priority y title no son válidos")
+
+	task.Title = strings.Repeat("á", 200)
+	task.Priority = ""
+	task.AssigneeID = "no-es-un-uuid"
+	errs, _ = validation.Struct(task).(validation.Errors)
+	assert.Equal(t, validation.Errors{{Field: "assignee_id", Error: "debe ser un UUID válido"}}, errs, "El largo se cuenta en caracteres")
+
+	//  CASO 4: STRINGS CON SOLO ESPACIOS Y FECHAS VACÍAS
+	errs, _ = validation.Struct(models.TaskRequest{Title: "   ", Description: "x"}).(validation.Errors)
+	assert.Equal(t, validation.Errors{
+		{Field: "title", Error: "es requerido"},
+		{Field: "due_date", Error: "es requerido"},
+	}, errs, "Un título con solo espacios y una fecha vacía deberían ser requeridos")
+
+	t.Log("✅ Todos los casos de validación pasaron correctamente")
+}
+
+func TestTaskValidationErrors(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
+
+	user := &models.User{
+		FirstName:    "Test",
+		LastName:     "Validacion",
+		Email:        fmt.Sprintf("validation_%d@example.com", time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Role:         models.RoleMember,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+	}
+
+	token := env.token(t, user)
+	send := func(method, path string, payload interface{}) (int, map[string]interface{}) {
+		var headers map[string]string
+		if method == http.MethodPatch {
+			headers = map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": "*"}
+		}
+		resp, decoded := env.request(t, method, path, token, payload, headers)
+		return resp.StatusCode, decoded
+	}
+
+	//  CASO 1: CREAR CON CAMPOS INVÁLIDOS
+	t.Log("🧪 Probando caso 1: Errores por campo al crear")
+	payload := map[string]interface{}{
+		"title":       strings.Repeat("t", 201),
+		"description": "Tarea para probar la validación",
+		"due_date":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+		"priority":    "urgent",
+	}
+	status, body := send(http.MethodPost, "/tasks", payload)
+	assert.Equal(t, http.StatusBadRequest, status, "Los campos inválidos deberían retornar 400")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"field": "title", "error": "no puede superar los 200 caracteres"},
+		map[string]interface{}{"field": "priority", "error": "debe ser uno de: low, medium, high"},
+	}, body["errors"], "Debería informar el error de cada campo")
+
+	//  CASO 2: PATCH CON UN RESULTADO INVÁLIDO
+	t.Log("🧪 Probando caso 2: Errores por campo en PATCH")
+	payload["title"] = "Tarea válida"
+	delete(payload, "priority")
+	status, body = send(http.MethodPost, "/tasks", payload)
+	if !assert.Equal(t, http.StatusCreated, status, "La creación debería ser exitosa") {
+		t.FailNow()
+	}
+	path := fmt.Sprintf("/tasks/%v", body["data"].(map[string]interface{})["id"])
+	status, body = send(http.MethodPatch, path, map[string]interface{}{"priority": "urgent", "title": nil})
+	assert.Equal(t, http.StatusUnprocessableEntity, status, "Un resultado inválido debería retornar 422")
+	assert.Len(t, body["errors"], 2, "Debería informar los dos campos inválidos")
+
+	t.Log("✅ Todos los casos de errores de validación pasaron correctamente")
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/pkg/models"
+)
+
+func TestSavedViews(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada (ver harness_test.go)
+	env := newTestEnv(t)
+	db := env.DB
+
+	newUser := func(name string) *models.User {
+		user := &models.User{
+			FirstName:    "Test",
+			LastName:     name,
+			Email:        fmt.Sprintf("views_%s_%d@example.com", name, time.Now().UnixNano()),
+			PasswordHash: "hash",
+			Role:         models.RoleMember,
+		}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+		}
+		return user
+	}
+	owner := newUser("owner")
+	stranger := newUser("stranger")
+	current := owner
+
+	// Las requests se autentican con el token de current
+	send := func(method, path string, payload interface{}) (int, map[string]interface{}) {
+		resp, decoded := env.request(t, method, path, env.token(t, current), payload, nil)
+		return resp.StatusCode, decoded
+	}
+
+	createTask := func(title, priority string, due time.Time) uint {
+		task := &models.Task{
+			Title:       title,
+			Description: "Tarea para probar vistas",
+			Status:      "pending",
+			Priority:    priority,
+			DueDate:     due,
+			CreatorID:   owner.ID,
+			AssigneeID:  owner.ID,
+		}
+		if err := db.Create(task).Error; err != nil {
+			t.Fatalf("❌ No se pudo crear la tarea: %v", err)
+		}
+		return task.ID
+	}
+	now := time.Now()
+	later := createTask("Urgente más tarde", "high", now.Add(72*time.Hour))
+	sooner := createTask("Urgente pronto", "high", now.Add(24*time.Hour))
+	createTask("Sin urgencia", "low", now.Add(48*time.Hour))
+
+	//  CASO 1: CREAR VISTA
+	t.Log("🧪 Probando caso 1: Crear vista")
+	status, body := send(http.MethodPost, "/views", map[string]interface{}{
+		"name":    "Urgentes",
+		"filters": map[string]string{"priority": "high", "assignee": "me"},
+		"sort":    "due_date",
+	})
+	if !assert.Equal(t, http.StatusCreated, status, "La creación de la vista debería ser exitosa") {
+		t.FailNow()
+	}
+	viewID := body["data"].(map[string]interface{})["id"]
+	status, _ = send(http.MethodPost, "/views", map[string]interface{}{"name": "urgentes"})
+	assert.Equal(t, http.StatusConflict, status, "Un nombre repetido (sin distinguir mayúsculas) debería retornar 409")
+
+	// El índice único decide entre creaciones simultáneas con el mismo nombre
+	statuses := make(chan int, 5)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, _ := send(http.MethodPost, "/views", map[string]interface{}{"name": "Simultanea"})
+			statuses <- status
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+	created := 0
+	for status := range statuses {
+		if status == http.StatusCreated {
+			created++
+		} else {
+			assert.Equal(t, http.StatusConflict, status, "Las creaciones repetidas deberían retornar 409")
+		}
+	}
+	assert.Equal(t, 1, created, "Solo una de las creaciones simultáneas debería guardarse")
+
+	//  CASO 2: VALIDACIÓN DE FILTROS
+	t.Log("🧪 Probando caso 2: Filtros inválidos")
+	for _, payload := range []map[string]interface{}{
+		{"name": "Mala", "filters": map[string]string{"color": "rojo"}},
+		{"name": "Mala", "filters": map[string]string{"status": "in:archived"}},
+		{"name": "Mala", "filters": map[string]string{"limit": "5"}},
+		{"name": "Mala", "sort": "rank"},
+		{"name": ""},
+	} {
+		status, _ = send(http.MethodPost, "/views", payload)
+		assert.Equal(t, http.StatusBadRequest, status, "La vista %v debería retornar 400", payload)
+	}
+
+	//  CASO 3: EJECUTAR VISTA
+	t.Log("🧪 Probando caso 3: Ejecutar vista")
+	status, body = send(http.MethodGet, fmt.Sprintf("/views/%v/tasks", viewID), nil)
+	assert.Equal(t, http.StatusOK, status, "La ejecución de la vista debería ser exitosa")
+	if data := body["data"].([]interface{}); assert.Len(t, data, 2, "La vista debería devolver solo las tareas urgentes") {
+		assert.Equal(t, float64(sooner), data[0].(map[string]interface{})["id"], "La vista debería respetar el orden guardado")
+		assert.Equal(t, float64(later), data[1].(map[string]interface{})["id"], "La vista debería respetar el orden guardado")
+	}
+	assert.Equal(t, "due_date,id", body["meta"].(map[string]interface{})["sort"], "El meta debería reflejar el orden de la vista")
+	status, _ = send(http.MethodGet, fmt.Sprintf("/views/%v/tasks?status=complete", viewID), nil)
+	assert.Equal(t, http.StatusBadRequest, status, "Una vista no debería aceptar filtros adicionales")
+
+	//  CASO 4: ACTUALIZAR VISTA
+	t.Log("🧪 Probando caso 4: Actualizar vista")
+	status, _ = send(http.MethodPut, fmt.Sprintf("/views/%v", viewID), map[string]interface{}{
+		"name":    "Urgentes",
+		"filters": map[string]string{"priority": "in:high,low"},
+		"sort":    "-due_date",
+	})
+	assert.Equal(t, http.StatusOK, status, "La actualización debería ser exitosa")
+	_, body = send(http.MethodGet, fmt.Sprintf("/views/%v/tasks", viewID), nil)
+	assert.Len(t, body["data"], 3, "La vista actualizada debería incluir las tareas de prioridad baja")
+	status, _ = send(http.MethodPut, fmt.Sprintf("/views/%v", viewID), map[string]interface{}{"name": "SIMULTANEA"})
+	assert.Equal(t, http.StatusConflict, status, "Renombrar con el nombre de otra vista debería retornar 409")
+
+	//  CASO 5: VISTAS AJENAS
+	t.Log("🧪 Probando caso 5: Vistas de otro usuario")
+	current = stranger
+	status, _ = send(http.MethodGet, fmt.Sprintf("/views/%v", viewID), nil)
+	assert.Equal(t, http.StatusNotFound, status, "Las vistas ajenas no deberían ser visibles")
+	status, _ = send(http.MethodGet, fmt.Sprintf("/views/%v/tasks", viewID), nil)
+	assert.Equal(t, http.StatusNotFound, status, "Las vistas ajenas no deberían poder ejecutarse")
+	_, body = send(http.MethodGet, "/views", nil)
+	assert.Empty(t, body["data"], "El listado solo debería incluir las vistas propias")
+	current = owner
+
+	//  CASO 6: ELIMINAR VISTA
+	t.Log("🧪 Probando caso 6: Eliminar vista")
+	status, _ = send(http.MethodDelete, fmt.Sprintf("/views/%v", viewID), nil)
+	assert.Equal(t, http.StatusOK, status, "La eliminación debería ser exitosa")
+	status, _ = send(http.MethodGet, fmt.Sprintf("/views/%v", viewID), nil)
+	assert.Equal(t, http.StatusNotFound, status, "La vista eliminada no debería existir")
+
+	t.Log("✅ Todos los casos de vistas guardadas pasaron correctamente")
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"legendaryum/internal/server"
+	"legendaryum/internal/tasks"
+	"legendaryum/pkg/models"
+)
+
+func TestParseWorkflow(t *testing.T) {
+	t.Log("🧪 Probando definiciones del flujo de estados")
+	_, err := tasks.ParseWorkflow("")
+	assert.NoError(t, err, "Sin definición debería usarse el flujo por defecto")
+	_, err = tasks.ParseWorkflow(tasks.DefaultWorkflow)
+	assert.NoError(t, err, "El flujo por defecto debería ser válido")
+	_, err = tasks.ParseWorkflow("pending->complete@admin|creator, complete->pending")
+	assert.NoError(t, err, "Un flujo con actores debería ser válido")
+
+	for _, spec := range []string{
+		"pending->archived",
+		"pending=>complete",
+		"pending->pending",
+		"pending->complete@assignee",
+		"pending->complete, pending->complete",
+		" , ",
+	} {
+		_, err := tasks.ParseWorkflow(spec)
+		assert.Error(t, err, "La definición %q debería rechazarse", spec)
+	}
+}
+
+func TestTaskStatusWorkflow(t *testing.T) {
+	// Aplicación completa sobre una base aislada y migrada, con el flujo por defecto (ver harness_test.go)
+	env := newTestEnv(t)
+	cfg, db := env.Config, env.DB
+
+	newUser := func(name, role string) *models.User {
+		user := &models.User{
+			FirstName:    "Test",
+			LastName:     name,
+			Email:        fmt.Sprintf("workflow_%s_%d@example.com", role, time.Now().UnixNano()),
+			PasswordHash: "hash",
+			Role:         role,
+		}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("❌ No se pudo crear el usuario: %v", err)
+		}
+		return user
+	}
+	creator := newUser("Creador", models.RoleMember)
+	admin := newUser("Admin", models.RoleAdmin)
+
+	// Las requests se autentican con el token de currentUser, que lleva su rol
+	currentUser := creator
+	send := func(method, path string, payload interface{}) (int, map[string]interface{}) {
+		// Estos casos no prueban la concurrencia: If-Match * acepta cualquier versión de la tarea
+		headers := map[string]string{"If-Match": "*"}
+		if method == http.MethodPatch {
+			headers["Content-Type"] = "application/merge-patch+json"
+		}
+		resp, decoded := env.request(t, method, path, env.token(t, currentUser), payload, headers)
+		return resp.StatusCode, decoded
+	}
+
+	//  CASO 1: ESTADOS DESCONOCIDOS
+	t.Log("🧪 Probando caso 1: Estados desconocidos")
+	payload := map[string]interface{}{
+		"title":       "Tarea con flujo",
+		"description": "Tarea para probar el flujo de estados",
+		"due_date":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+		"status":      "archived",
+	}
+	status, _ := send(http.MethodPost, "/tasks", payload)
+	assert.Equal(t, http.StatusBadRequest, status, "Crear con un estado desconocido debería retornar 400")
+
+	delete(payload, "status")
+	status, body := send(http.MethodPost, "/tasks", payload)
+	if !assert.Equal(t, http.StatusCreated, status, "La creación debería ser exitosa") {
+		t.FailNow()
+	}
+	data := body["data"].(map[string]interface{})
+	path := fmt.Sprintf("/tasks/%v", data["id"])
+	assert.Nil(t, data["started_at"], "Una tarea pendiente no debería tener started_at")
+
+	status, _ = send(http.MethodPatch, path, map[string]string{"status": "archived"})
+	assert.Equal(t, http.StatusUnprocessableEntity, status, "Un estado desconocido debería retornar 422")
+
+	//  CASO 2: TRANSICIÓN NO PERMITIDA
+	t.Log("🧪 Probando caso 2: Transición no permitida")
+	status, body = send(http.MethodPatch, path, map[string]string{"status": "complete"})
+	assert.Equal(t, http.StatusConflict, status, "Completar una tarea pendiente debería retornar 409")
+	assert.Equal(t, "invalid_transition", body["code"], "Debería informar el código del error")
+	assert.Equal(t, []interface{}{"in_progress"}, body["allowed_transitions"], "Deberían informarse las transiciones permitidas")
+
+	//  CASO 3: TRANSICIONES PERMITIDAS Y MARCAS DE TIEMPO
+	t.Log("🧪 Probando caso 3: Marcas de tiempo")
+	status, body = send(http.MethodPatch, path, map[string]string{"status": "in_progress"})
+	assert.Equal(t, http.StatusOK, status, "Iniciar la tarea debería ser exitoso")
+	data = body["data"].(map[string]interface{})
+	startedAt := data["started_at"]
+	assert.NotNil(t, startedAt, "Iniciar la tarea debería registrar started_at")
+	assert.Nil(t, data["completed_at"], "Una tarea en curso no debería tener completed_at")
+
+	status, body = send(http.MethodPatch, path, map[string]string{"status": "complete"})
+	assert.Equal(t, http.StatusOK, status, "Completar la tarea debería ser exitoso")
+	data = body["data"].(map[string]interface{})
+	assert.NotNil(t, data["completed_at"], "Completar la tarea debería registrar completed_at")
+	assert.Equal(t, startedAt, data["started_at"], "Completar no debería cambiar started_at")
+
+	//  CASO 4: SOLO EL CREADOR REABRE
+	t.Log("🧪 Probando caso 4: Reapertura")
+	currentUser = admin
+	status, _ = send(http.MethodPatch, path, map[string]string{"status": "in_progress"})
+	assert.Equal(t, http.StatusForbidden, status, "Un admin que no es el creador no debería reabrir la tarea")
+	currentUser = creator
+	status, body = send(http.MethodPatch, path, map[string]string{"status": "in_progress"})
+	assert.Equal(t, http.StatusOK, status, "El creador debería poder reabrir la tarea")
+	data = body["data"].(map[string]interface{})
+	assert.Nil(t, data["completed_at"], "Reabrir la tarea debería borrar completed_at")
+	assert.Equal(t, startedAt, data["started_at"], "Reabrir no debería cambiar started_at")
+
+	status, body = send(http.MethodPatch, path, map[string]string{"status": "pending"})
+	assert.Equal(t, http.StatusOK, status, "Volver a pendiente debería ser exitoso")
+	assert.Nil(t, body["data"].(map[string]interface{})["started_at"], "Volver a pendiente debería borrar started_at")
+
+	//  CASO 5: FLUJO CONFIGURADO
+	t.Log("🧪 Probando caso 5: Flujo configurado")
+	custom := *cfg
+	custom.TaskWorkflow = "pending->complete"
+	customApp := server.New(&custom, server.Deps{DB: db})
+	req := httptest.NewRequest(http.MethodPatch, path, bytes.NewReader([]byte(`{"status":"complete"}`)))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", "*")
+	req.Header.Set("Authorization", "Bearer "+env.token(t, creator))
+	resp, err := customApp.Test(req, -1)
+	assert.NoError(t, err, "No debería haber error en la request")
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Con el flujo configurado debería poder completarse directamente")
+
+	t.Log("✅ Todos los casos del flujo de estados pasaron correctamente")
+}